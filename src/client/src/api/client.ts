@@ -1,6 +1,51 @@
 export const API_BASE = '/api/v1';
 
-export const request = async <T>(url: string, options: RequestInit = {}): Promise<T> => {
+// RFC 7807 problem body returned for every API error
+export interface Problem {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  instance?: string;
+  request_id?: string;
+  errors?: { field: string; code: string; message: string }[];
+}
+
+export class ApiError extends Error {
+  problem: Problem;
+
+  constructor(problem: Problem) {
+    // Field errors read better in a form than the generic detail
+    const fields = problem.errors?.map(e => `${e.field} ${e.message}`).join(', ');
+    super(fields || problem.detail || problem.title);
+    this.problem = problem;
+  }
+
+  // Message for a single form field, e.g. fieldError('password')
+  fieldError(field: string): string | undefined {
+    return this.problem.errors?.find(e => e.field === field)?.message;
+  }
+}
+
+// Shared in-flight refresh so parallel 401s rotate the refresh token only once
+let refreshing: Promise<boolean> | null = null;
+
+const refreshSession = (): Promise<boolean> => {
+  if (!refreshing) {
+    refreshing = fetch(`${API_BASE}/auth/refresh`, {
+      method: 'POST',
+      credentials: 'include',
+    })
+      .then(res => res.ok)
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
+};
+
+export const request = async <T>(url: string, options: RequestInit = {}, retry = true): Promise<T> => {
   const res = await fetch(`${API_BASE}${url}`, {
     ...options,
     headers: {
@@ -10,9 +55,20 @@ export const request = async <T>(url: string, options: RequestInit = {}): Promis
     credentials: 'include',
   });
 
+  // Access tokens are short-lived: refresh once and replay the request
+  if (res.status === 401 && retry && !url.startsWith('/auth/')) {
+    if (await refreshSession()) {
+      return request<T>(url, options, false);
+    }
+  }
+
   if (!res.ok) {
-    const errorBody = await res.json().catch(() => ({}));
-    throw new Error(errorBody.error || `Request failed with status ${res.status}`);
+    const problem: Problem = await res.json().catch(() => ({
+      type: 'about:blank',
+      title: `Request failed with status ${res.status}`,
+      status: res.status,
+    }));
+    throw new ApiError(problem);
   }
 
   // Handle 204 No Content
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditUseCase interface {
+	List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+	TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+}
+
+// AuditController serves the audit trail: the history of one todo to its
+// owner, and every event to admins.
+type AuditController struct {
+	usecase AuditUseCase
+}
+
+func NewAuditController(usecase AuditUseCase) *AuditController {
+	return &AuditController{usecase: usecase}
+}
+
+// TodoHistoryQuery holds the GET /todos/:id/history query string.
+type TodoHistoryQuery struct {
+	Limit          int        `form:"limit" json:"limit"`
+	Offset         int        `form:"offset" json:"offset"`
+	OccurredAfter  *time.Time `form:"occurred_after" json:"occurred_after"`
+	OccurredBefore *time.Time `form:"occurred_before" json:"occurred_before"`
+}
+
+// ListAuditQuery holds the GET /admin/audit query string.
+type ListAuditQuery struct {
+	TodoHistoryQuery
+	ActorID   *int   `form:"actor_id" json:"actor_id" binding:"omitempty,min=1"`
+	Action    string `form:"action" json:"action" binding:"omitempty,oneof=create update delete trash restore"`
+	Entity    string `form:"entity" json:"entity" binding:"omitempty,oneof=user todo subtask share todo_tag series project tag"`
+	EntityID  *int   `form:"entity_id" json:"entity_id" binding:"omitempty,min=1"`
+	TodoID    *int   `form:"todo_id" json:"todo_id" binding:"omitempty,min=1"`
+	RequestID string `form:"request_id" json:"request_id" binding:"max=128"`
+}
+
+// TodoHistory returns the changes to one of the caller's todos, newest first,
+// including those to its subtasks, shares and tags.
+func (c *AuditController) TodoHistory(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	var query TodoHistoryQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+	filter := models.AuditFilter{OccurredAfter: query.OccurredAfter, OccurredBefore: query.OccurredBefore}
+
+	events, err := c.usecase.TodoHistory(ctx.Request.Context(), todoID, userID, filter, query.page())
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	c.reply(ctx, events)
+}
+
+// List returns events across all users, newest first (admin only).
+func (c *AuditController) List(ctx *gin.Context) {
+	var query ListAuditQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+	filter := models.AuditFilter{
+		ActorID:        query.ActorID,
+		Action:         query.Action,
+		Entity:         query.Entity,
+		EntityID:       query.EntityID,
+		TodoID:         query.TodoID,
+		RequestID:      query.RequestID,
+		OccurredAfter:  query.OccurredAfter,
+		OccurredBefore: query.OccurredBefore,
+	}
+
+	events, err := c.usecase.List(ctx.Request.Context(), filter, query.page())
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	c.reply(ctx, events)
+}
+
+func (q TodoHistoryQuery) page() pagination.Page {
+	return pagination.Page{Limit: q.Limit, Offset: q.Offset}
+}
+
+func (c *AuditController) reply(ctx *gin.Context, events []models.AuditEvent) {
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+	reply.OK(ctx, gin.H{"events": events, "count": len(events)})
+}
@@ -7,18 +7,28 @@ import (
 
 	"github.com/fayzzzm/go-bro/middleware"
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/auth"
 	"github.com/fayzzzm/go-bro/pkg/reply"
 	"github.com/gin-gonic/gin"
 )
 
 const (
-	AuthCookieName = "auth_token"
-	CookieMaxAge   = 24 * 60 * 60 // 24 hours in seconds
+	AuthCookieName    = "auth_token"
+	RefreshCookieName = "refresh_token"
+	// The refresh cookie is only sent to the auth endpoints that consume it
+	RefreshCookiePath = "/api/v1/auth"
+)
+
+var (
+	AccessCookieMaxAge  = int(auth.AccessTokenTTL.Seconds())
+	RefreshCookieMaxAge = int(auth.RefreshTokenTTL.Seconds())
 )
 
 type AuthUseCase interface {
-	Signup(ctx context.Context, name, email, password string) (*models.User, string, error)
-	Login(ctx context.Context, email, password string) (*models.User, string, error)
+	Signup(ctx context.Context, name, email, password string) (*models.User, *auth.TokenPair, error)
+	Login(ctx context.Context, email, password string) (*models.User, *auth.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
 }
 
 type AuthController struct {
@@ -40,67 +50,104 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest lets API clients that don't keep cookies send the refresh token in the body
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
 type AuthResponse struct {
-	User    interface{} `json:"user"`
-	Token   string      `json:"token,omitempty"`
-	Message string      `json:"message,omitempty"`
+	User         interface{} `json:"user,omitempty"`
+	Token        string      `json:"token,omitempty"`
+	RefreshToken string      `json:"refresh_token,omitempty"`
+	Message      string      `json:"message,omitempty"`
 }
 
 func isProduction() bool {
 	return os.Getenv("GIN_MODE") == "release"
 }
 
-func setAuthCookie(ctx *gin.Context, token string) {
+func setAuthCookies(ctx *gin.Context, tokens *auth.TokenPair) {
 	secure := isProduction()
 	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie(AuthCookieName, token, CookieMaxAge, "/", "", secure, true)
+	ctx.SetCookie(AuthCookieName, tokens.AccessToken, AccessCookieMaxAge, "/", "", secure, true)
+	ctx.SetCookie(RefreshCookieName, tokens.RefreshToken, RefreshCookieMaxAge, RefreshCookiePath, "", secure, true)
 }
 
-func clearAuthCookie(ctx *gin.Context) {
+func clearAuthCookies(ctx *gin.Context) {
 	ctx.SetCookie(AuthCookieName, "", -1, "/", "", false, true)
+	ctx.SetCookie(RefreshCookieName, "", -1, RefreshCookiePath, "", false, true)
+}
+
+// refreshTokenFrom reads the refresh token from its cookie, falling back to the JSON body
+func refreshTokenFrom(ctx *gin.Context) string {
+	if token, err := ctx.Cookie(RefreshCookieName); err == nil && token != "" {
+		return token
+	}
+	var req RefreshRequest
+	_ = ctx.ShouldBindJSON(&req)
+	return req.RefreshToken
 }
 
 func (c *AuthController) Signup(ctx *gin.Context) {
 	req := middleware.GetBody[SignupRequest](ctx)
 
-	user, token, err := c.usecase.Signup(ctx.Request.Context(), req.Name, req.Email, req.Password)
-	if err != nil {
-		code := http.StatusBadRequest
-		if err.Error() == "email already exists" {
-			code = http.StatusConflict
-		}
-		reply.Error(ctx, code, err.Error(), err)
+	user, tokens, err := c.usecase.Signup(ctx.Request.Context(), req.Name, req.Email, req.Password)
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
-	setAuthCookie(ctx, token)
+	setAuthCookies(ctx, tokens)
 
 	reply.Created(ctx, AuthResponse{
-		User:    user,
-		Token:   token,
-		Message: "Account created successfully",
+		User:         user,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Message:      "Account created successfully",
 	})
 }
 
 func (c *AuthController) Login(ctx *gin.Context) {
 	req := middleware.GetBody[LoginRequest](ctx)
 
-	user, token, err := c.usecase.Login(ctx.Request.Context(), req.Email, req.Password)
+	user, tokens, err := c.usecase.Login(ctx.Request.Context(), req.Email, req.Password)
 	if reply.Error(ctx, http.StatusUnauthorized, "invalid credentials", err) {
 		return
 	}
 
-	setAuthCookie(ctx, token)
+	setAuthCookies(ctx, tokens)
+
+	reply.OK(ctx, AuthResponse{
+		User:         user,
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Message:      "Login successful",
+	})
+}
+
+func (c *AuthController) Refresh(ctx *gin.Context) {
+	tokens, err := c.usecase.Refresh(ctx.Request.Context(), refreshTokenFrom(ctx))
+	if err != nil {
+		clearAuthCookies(ctx)
+		reply.Error(ctx, http.StatusUnauthorized, "invalid refresh token", err)
+		return
+	}
+
+	setAuthCookies(ctx, tokens)
 
 	reply.OK(ctx, AuthResponse{
-		User:    user,
-		Token:   token,
-		Message: "Login successful",
+		Token:        tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		Message:      "Token refreshed",
 	})
 }
 
 func (c *AuthController) Logout(ctx *gin.Context) {
-	clearAuthCookie(ctx)
+	err := c.usecase.Logout(ctx.Request.Context(), refreshTokenFrom(ctx))
+	clearAuthCookies(ctx)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
 	reply.OK(ctx, gin.H{"message": "Logged out successfully"})
 }
 
@@ -0,0 +1,25 @@
+package controller
+
+import (
+	"github.com/fayzzzm/go-bro/pkg/auth"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type KeySetProvider interface {
+	JWKS() auth.JWKSet
+}
+
+type KeysController struct {
+	keys KeySetProvider
+}
+
+func NewKeysController(keys KeySetProvider) *KeysController {
+	return &KeysController{keys: keys}
+}
+
+// JWKS publishes the public signing keys so other services can verify our tokens
+func (c *KeysController) JWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	reply.OK(ctx, c.keys.JWKS())
+}
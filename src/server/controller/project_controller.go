@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type ProjectUseCase interface {
+	Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error)
+	GetByID(ctx context.Context, projectID, userID int) (*models.Project, error)
+	Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error)
+	Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error)
+}
+
+// ProjectController serves /projects. A project's todos are listed with
+// GET /todos?project_id=.
+type ProjectController struct {
+	usecase ProjectUseCase
+}
+
+func NewProjectController(usecase ProjectUseCase) *ProjectController {
+	return &ProjectController{usecase: usecase}
+}
+
+type CreateProjectRequest struct {
+	Name        string  `json:"name" binding:"required,max=200"`
+	Description *string `json:"description"`
+}
+
+type UpdateProjectRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=200"`
+	Description *string `json:"description"`
+}
+
+// ListProjectsQuery holds the GET /projects query string.
+type ListProjectsQuery struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+// DeleteProjectQuery holds the DELETE /projects/:id query string.
+type DeleteProjectQuery struct {
+	Mode string `form:"mode" json:"mode" binding:"omitempty,oneof=inbox cascade"`
+}
+
+func (c *ProjectController) Create(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	req := middleware.GetBody[CreateProjectRequest](ctx)
+
+	project, err := c.usecase.Create(ctx.Request.Context(), userID, req.Name, req.Description)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.Created(ctx, gin.H{"project": project})
+}
+
+// List returns the caller's projects by name.
+func (c *ProjectController) List(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var query ListProjectsQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+
+	projects, err := c.usecase.List(ctx.Request.Context(), userID, pagination.Page{Limit: query.Limit, Offset: query.Offset})
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	if projects == nil {
+		projects = []models.Project{}
+	}
+
+	reply.OK(ctx, gin.H{"projects": projects, "count": len(projects)})
+}
+
+func (c *ProjectController) GetByID(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	project, err := c.usecase.GetByID(ctx.Request.Context(), projectID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"project": project})
+}
+
+func (c *ProjectController) Update(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	req := middleware.GetBody[UpdateProjectRequest](ctx)
+
+	project, err := c.usecase.Update(ctx.Request.Context(), projectID, userID, req.Name, req.Description)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"project": project})
+}
+
+// Delete removes a project. Its todos move to the inbox, or to the trash
+// with ?mode=cascade.
+func (c *ProjectController) Delete(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	projectID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	var query DeleteProjectQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+	mode := models.ProjectDeleteMode(query.Mode)
+	if mode == "" {
+		mode = models.DeleteToInbox
+	}
+
+	moved, err := c.usecase.Delete(ctx.Request.Context(), projectID, userID, mode)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "project deleted", "mode": mode, "todos_moved": moved})
+}
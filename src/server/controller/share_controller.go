@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type ShareUseCase interface {
+	Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error)
+	Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error
+	ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error)
+	ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error)
+}
+
+// ShareController serves both /todos/:id/shares, which shares one todo, and
+// /todos/shares, which shares the caller's whole list.
+type ShareController struct {
+	usecase ShareUseCase
+}
+
+func NewShareController(usecase ShareUseCase) *ShareController {
+	return &ShareController{usecase: usecase}
+}
+
+// ShareTodoRequest is the body of POST /todos/:id/shares and POST /todos/shares.
+type ShareTodoRequest struct {
+	Email      string `json:"email" binding:"required,email"`
+	Permission string `json:"permission" binding:"required,oneof=viewer editor"`
+}
+
+// ListSharedQuery holds the GET /todos/shared query string.
+type ListSharedQuery struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+// Share grants another user access by email; sharing again changes the permission.
+func (c *ShareController) Share(ctx *gin.Context) {
+	ownerID, _ := middleware.GetUserID(ctx)
+	todoID, ok := shareScope(ctx)
+	if !ok {
+		return
+	}
+	req := middleware.GetBody[ShareTodoRequest](ctx)
+
+	share, err := c.usecase.Share(ctx.Request.Context(), ownerID, todoID, req.Email, models.SharePermission(req.Permission))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.Created(ctx, gin.H{"share": share})
+}
+
+// Unshare revokes the access of the user in :user_id.
+func (c *ShareController) Unshare(ctx *gin.Context) {
+	ownerID, _ := middleware.GetUserID(ctx)
+	todoID, ok := shareScope(ctx)
+	if !ok {
+		return
+	}
+	userID, err := strconv.Atoi(ctx.Param("user_id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid user_id format", err) {
+		return
+	}
+
+	err = c.usecase.Unshare(ctx.Request.Context(), ownerID, todoID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "share removed"})
+}
+
+// List returns the collaborators of the todo or the whole list.
+func (c *ShareController) List(ctx *gin.Context) {
+	ownerID, _ := middleware.GetUserID(ctx)
+	todoID, ok := shareScope(ctx)
+	if !ok {
+		return
+	}
+
+	shares, err := c.usecase.ListShares(ctx.Request.Context(), ownerID, todoID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	if shares == nil {
+		shares = []models.Share{}
+	}
+
+	reply.OK(ctx, gin.H{"shares": shares, "count": len(shares)})
+}
+
+// Shared lists the todos other users shared with the caller, with the
+// caller's permission on each.
+func (c *ShareController) Shared(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var query ListSharedQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+
+	todos, err := c.usecase.ListShared(ctx.Request.Context(), userID, pagination.Page{Limit: query.Limit, Offset: query.Offset})
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	if todos == nil {
+		todos = []models.SharedTodo{}
+	}
+
+	reply.OK(ctx, gin.H{"todos": todos, "count": len(todos)})
+}
+
+// shareScope returns the todo in :id, or nil on the whole-list routes.
+func shareScope(ctx *gin.Context) (*int, bool) {
+	raw := ctx.Param("id")
+	if raw == "" {
+		return nil, true
+	}
+	todoID, err := strconv.Atoi(raw)
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return nil, false
+	}
+	return &todoID, true
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	LastEventIDHeader = "Last-Event-ID"
+
+	// HeartbeatInterval keeps idle streams open through proxies that time out
+	// quiet connections (nginx proxy_read_timeout is 60s).
+	HeartbeatInterval = 25 * time.Second
+)
+
+var ErrLastEventID = apperr.Validation("Last-Event-ID must be a non-negative event id")
+
+type StreamUseCase interface {
+	Subscribe(userID int) *events.Subscription
+	Replay(ctx context.Context, userID int, afterID int64) ([]models.TodoEvent, error)
+}
+
+// StreamController serves GET /todos/stream, the caller's todo changes as
+// Server-Sent Events.
+type StreamController struct {
+	usecase StreamUseCase
+}
+
+func NewStreamController(usecase StreamUseCase) *StreamController {
+	return &StreamController{usecase: usecase}
+}
+
+// Stream sends an event for every change to the caller's todos until the
+// client disconnects. A client resuming with Last-Event-ID (or
+// ?last_event_id= on first connect) first gets the events it missed. When the
+// server drops a stream, because the client fell behind or on shutdown, the
+// client reconnects and resumes.
+func (c *StreamController) Stream(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	lastID, err := lastEventID(ctx)
+	if reply.Error(ctx, http.StatusBadRequest, "invalid Last-Event-ID", err) {
+		return
+	}
+
+	// Subscribe before replaying so nothing published in between is lost
+	sub := c.usecase.Subscribe(userID)
+	defer sub.Close()
+
+	// The first page of missed events is read before the response starts, so
+	// a failure is still a problem response; later pages are sent as read
+	var page []models.TodoEvent
+	if lastID > 0 {
+		page, err = c.usecase.Replay(ctx.Request.Context(), userID, lastID)
+		if reply.InternalError(ctx, err) {
+			return
+		}
+	}
+
+	ctx.Header("Content-Type", "text/event-stream")
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no") // tell nginx not to buffer the stream
+	ctx.Status(http.StatusOK)
+
+	for {
+		for _, event := range page {
+			if err := writeEvent(ctx.Writer, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		}
+		ctx.Writer.Flush()
+		if len(page) < events.ReplayLimit {
+			break
+		}
+		// The client resumes from the last event sent if a later page fails
+		if page, err = c.usecase.Replay(ctx.Request.Context(), userID, lastID); err != nil {
+			log.Printf("⚠️ Warning: replay for user %d failed: %v", userID, err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Request.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			// Already sent by the replay
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(ctx.Writer, event); err != nil {
+				return
+			}
+			lastID = event.ID
+		case <-heartbeat.C:
+			if _, err := io.WriteString(ctx.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		ctx.Writer.Flush()
+	}
+}
+
+// lastEventID reads the event a client last saw; 0 means start from now.
+func lastEventID(ctx *gin.Context) (int64, error) {
+	raw := ctx.GetHeader(LastEventIDHeader)
+	if raw == "" {
+		raw = ctx.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, ErrLastEventID
+	}
+	return id, nil
+}
+
+func writeEvent(w io.Writer, event models.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type TagUseCase interface {
+	Create(ctx context.Context, userID int, name string) (*models.Tag, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error)
+	GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error)
+	Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error)
+	Delete(ctx context.Context, tagID, userID int) error
+}
+
+// TagController serves /tags. Todos are tagged by name through /todos and
+// listed with GET /todos?tag=.
+type TagController struct {
+	usecase TagUseCase
+}
+
+func NewTagController(usecase TagUseCase) *TagController {
+	return &TagController{usecase: usecase}
+}
+
+type CreateTagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+type UpdateTagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// ListTagsQuery holds the GET /tags query string.
+type ListTagsQuery struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+func (c *TagController) Create(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	req := middleware.GetBody[CreateTagRequest](ctx)
+
+	tag, err := c.usecase.Create(ctx.Request.Context(), userID, req.Name)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.Created(ctx, gin.H{"tag": tag})
+}
+
+// List returns the caller's tags by name, each with the number of todos it labels.
+func (c *TagController) List(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+
+	var query ListTagsQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+
+	tags, err := c.usecase.List(ctx.Request.Context(), userID, pagination.Page{Limit: query.Limit, Offset: query.Offset})
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+
+	reply.OK(ctx, gin.H{"tags": tags, "count": len(tags)})
+}
+
+func (c *TagController) GetByID(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	tagID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	tag, err := c.usecase.GetByID(ctx.Request.Context(), tagID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"tag": tag})
+}
+
+// Update renames a tag on every todo it labels.
+func (c *TagController) Update(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	tagID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	req := middleware.GetBody[UpdateTagRequest](ctx)
+
+	tag, err := c.usecase.Rename(ctx.Request.Context(), tagID, userID, req.Name)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"tag": tag})
+}
+
+// Delete removes a tag from every todo it labels; the todos stay.
+func (c *TagController) Delete(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	tagID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	if reply.InternalError(ctx, c.usecase.Delete(ctx.Request.Context(), tagID, userID)) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "tag deleted"})
+}
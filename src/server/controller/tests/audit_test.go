@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// MockAuditUseCase records the last filter and todo it was given
+type MockAuditUseCase struct {
+	err    error
+	filter models.AuditFilter
+	todoID int
+	page   pagination.Page
+}
+
+func (m *MockAuditUseCase) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	m.filter, m.page = filter, page
+	return nil, m.err
+}
+
+func (m *MockAuditUseCase) TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	m.filter, m.todoID, m.page = filter, todoID, page
+	if m.err != nil {
+		return nil, m.err
+	}
+	after := json.RawMessage(`{"title": "New"}`)
+	return []models.AuditEvent{{ID: 1, ActorID: &userID, Action: "update", Entity: "todo", TodoID: &todoID, After: after}}, nil
+}
+
+func setupAuditRouter(uc *MockAuditUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewAuditController(uc)
+	api := router.Group("/api/v1", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	})
+	api.GET("/todos/:id/history", ctrl.TodoHistory)
+	api.GET("/admin/audit", ctrl.List)
+	return router
+}
+
+func TestAudit_TodoHistory(t *testing.T) {
+	uc := &MockAuditUseCase{}
+	w := doProject(setupAuditRouter(uc), "GET", "/api/v1/todos/5/history?limit=10&occurred_after=2026-01-01T00:00:00Z", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.todoID != 5 || uc.page.Limit != 10 || uc.filter.OccurredAfter == nil {
+		t.Errorf("Expected todo 5, limit 10 and occurred_after, got todo %d page %+v filter %+v", uc.todoID, uc.page, uc.filter)
+	}
+
+	var resp struct {
+		Events []models.AuditEvent `json:"events"`
+		Count  int                 `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Count != 1 || resp.Events[0].Action != "update" {
+		t.Errorf("Expected one update event, got %+v", resp)
+	}
+}
+
+func TestAudit_List(t *testing.T) {
+	uc := &MockAuditUseCase{}
+	w := doProject(setupAuditRouter(uc), "GET", "/api/v1/admin/audit?actor_id=3&entity=todo&action=trash&request_id=req-1&offset=20", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	f := uc.filter
+	if f.ActorID == nil || *f.ActorID != 3 || f.Entity != "todo" || f.Action != "trash" || f.RequestID != "req-1" || uc.page.Offset != 20 {
+		t.Errorf("Expected the query to reach the filter, got %+v page %+v", f, uc.page)
+	}
+	if body := w.Body.String(); body != `{"count":0,"events":[]}` {
+		t.Errorf("Expected an empty list, got %s", body)
+	}
+}
+
+func TestAudit_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		path       string
+		wantStatus int
+	}{
+		{"Invalid id", nil, "/api/v1/todos/abc/history", http.StatusBadRequest},
+		{"Invalid time", nil, "/api/v1/todos/1/history?occurred_after=yesterday", http.StatusBadRequest},
+		{"Unknown entity", nil, "/api/v1/admin/audit?entity=session", http.StatusBadRequest},
+		{"Unknown action", nil, "/api/v1/admin/audit?action=read", http.StatusBadRequest},
+		{"Invalid actor", nil, "/api/v1/admin/audit?actor_id=0", http.StatusBadRequest},
+		{"Not the owner", apperr.NotFound("todo not found"), "/api/v1/todos/9/history", http.StatusNotFound},
+		{"Empty window", apperr.Validation("occurred_after must be before occurred_before"), "/api/v1/admin/audit", http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProject(setupAuditRouter(&MockAuditUseCase{err: tc.err}), "GET", tc.path, "")
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
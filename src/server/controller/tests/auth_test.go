@@ -2,6 +2,7 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/fayzzzm/go-bro/controller"
 	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/pkg/auth"
 	"github.com/fayzzzm/go-bro/routes"
 	"github.com/gin-gonic/gin"
 )
@@ -35,6 +37,19 @@ func (m *MockAuthService) Login(ctx interface{}, email, password string) (interf
 // MockTodoService is a mock implementation of TodoServicer
 type MockTodoService struct{}
 
+// newTestTokens signs and validates tokens with a fixed HS256 key
+func newTestTokens() *auth.TokenManager {
+	keys, _ := auth.NewStaticKeyProvider(auth.NewHMACKey("test", []byte("test-secret")))
+	return auth.NewTokenManager(keys)
+}
+
+// MockSessionChecker treats every session as active
+type MockSessionChecker struct{}
+
+func (m *MockSessionChecker) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return true, nil
+}
+
 // SetupTestRouter creates a test router with mocked services
 func SetupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
@@ -166,7 +181,7 @@ func TestProtectedRouteWithoutAuth(t *testing.T) {
 	router := SetupTestRouter()
 
 	// Protected route
-	router.GET("/api/v1/todos", middleware.AuthMiddleware(), func(c *gin.Context) {
+	router.GET("/api/v1/todos", middleware.AuthMiddleware(newTestTokens(), &MockSessionChecker{}), func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"todos": []interface{}{}})
 	})
 
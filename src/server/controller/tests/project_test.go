@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// MockProjectUseCase records the last delete mode
+type MockProjectUseCase struct {
+	err  error
+	mode models.ProjectDeleteMode
+}
+
+func (m *MockProjectUseCase) Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Project{ID: 1, UserID: userID, Name: name}, nil
+}
+
+func (m *MockProjectUseCase) List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error) {
+	return nil, m.err
+}
+
+func (m *MockProjectUseCase) GetByID(ctx context.Context, projectID, userID int) (*models.Project, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Project{ID: projectID, UserID: userID}, nil
+}
+
+func (m *MockProjectUseCase) Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error) {
+	return &models.Project{ID: projectID, UserID: userID}, m.err
+}
+
+func (m *MockProjectUseCase) Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error) {
+	m.mode = mode
+	return 3, m.err
+}
+
+func setupProjectRouter(uc *MockProjectUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewProjectController(uc)
+	projects := router.Group("/api/v1/projects", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	})
+	projects.GET("", ctrl.List)
+	projects.POST("", middleware.BindJSON[controller.CreateProjectRequest](), ctrl.Create)
+	projects.GET("/:id", ctrl.GetByID)
+	projects.PUT("/:id", middleware.BindJSON[controller.UpdateProjectRequest](), ctrl.Update)
+	projects.DELETE("/:id", ctrl.Delete)
+	return router
+}
+
+func doProject(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestProject_Validation(t *testing.T) {
+	router := setupProjectRouter(&MockProjectUseCase{})
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Valid", method: "POST", path: "/api/v1/projects", body: `{"name": "Home"}`, wantStatus: http.StatusCreated},
+		{name: "Missing name", method: "POST", path: "/api/v1/projects", body: `{"description": "chores"}`, wantStatus: http.StatusBadRequest},
+		{name: "Empty rename", method: "PUT", path: "/api/v1/projects/1", body: `{"name": ""}`, wantStatus: http.StatusBadRequest},
+		{name: "Invalid id", method: "GET", path: "/api/v1/projects/abc", wantStatus: http.StatusBadRequest},
+		{name: "Unknown delete mode", method: "DELETE", path: "/api/v1/projects/1?mode=archive", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProject(router, tc.method, tc.path, tc.body)
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestProject_DeleteMode(t *testing.T) {
+	uc := &MockProjectUseCase{}
+	router := setupProjectRouter(uc)
+
+	w := doProject(router, "DELETE", "/api/v1/projects/1", "")
+	if w.Code != http.StatusOK || uc.mode != models.DeleteToInbox {
+		t.Errorf("Expected inbox by default, got status %d mode %q", w.Code, uc.mode)
+	}
+
+	w = doProject(router, "DELETE", "/api/v1/projects/1?mode=cascade", "")
+	if w.Code != http.StatusOK || uc.mode != models.DeleteCascade {
+		t.Errorf("Expected cascade, got status %d mode %q", w.Code, uc.mode)
+	}
+}
+
+func TestProject_NotFound(t *testing.T) {
+	router := setupProjectRouter(&MockProjectUseCase{err: apperr.NotFound("project not found")})
+
+	w := doProject(router, "GET", "/api/v1/projects/9", "")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,156 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// MockShareUseCase records the scope of the last call and lists shares
+type MockShareUseCase struct {
+	err        error
+	shares     []models.Share
+	todoID     *int
+	userID     int
+	permission models.SharePermission
+	page       pagination.Page
+}
+
+func (m *MockShareUseCase) Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error) {
+	m.todoID, m.permission = todoID, permission
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Share{TodoID: todoID, UserID: 2, Email: email, Permission: permission}, nil
+}
+
+func (m *MockShareUseCase) Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error {
+	m.todoID, m.userID = todoID, userID
+	return m.err
+}
+
+func (m *MockShareUseCase) ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error) {
+	m.todoID = todoID
+	return m.shares, m.err
+}
+
+func (m *MockShareUseCase) ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error) {
+	m.page = page
+	return nil, m.err
+}
+
+func setupShareRouter(uc *MockShareUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewShareController(uc)
+	todos := router.Group("/api/v1/todos", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	})
+	todos.GET("/shared", ctrl.Shared)
+	todos.POST("/shares", middleware.BindJSON[controller.ShareTodoRequest](), ctrl.Share)
+	todos.DELETE("/shares/:user_id", ctrl.Unshare)
+	todos.GET("/:id/shares", ctrl.List)
+	todos.POST("/:id/shares", middleware.BindJSON[controller.ShareTodoRequest](), ctrl.Share)
+	todos.DELETE("/:id/shares/:user_id", ctrl.Unshare)
+	return router
+}
+
+func doShare(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestShare_Scope(t *testing.T) {
+	uc := &MockShareUseCase{}
+	router := setupShareRouter(uc)
+
+	w := doShare(router, "POST", "/api/v1/todos/7/shares", `{"email": "bob@example.com", "permission": "editor"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.todoID == nil || *uc.todoID != 7 || uc.permission != models.PermissionEditor {
+		t.Errorf("Expected an editor share of todo 7, got todo %v as %q", uc.todoID, uc.permission)
+	}
+
+	w = doShare(router, "POST", "/api/v1/todos/shares", `{"email": "bob@example.com", "permission": "viewer"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.todoID != nil {
+		t.Errorf("Expected a whole-list share, got todo %d", *uc.todoID)
+	}
+
+	w = doShare(router, "DELETE", "/api/v1/todos/7/shares/2", "")
+	if w.Code != http.StatusOK || uc.todoID == nil || *uc.todoID != 7 || uc.userID != 2 {
+		t.Errorf("Expected user 2 removed from todo 7, got status %d, todo %v, user %d", w.Code, uc.todoID, uc.userID)
+	}
+}
+
+func TestShare_Validation(t *testing.T) {
+	router := setupShareRouter(&MockShareUseCase{})
+
+	testCases := []struct {
+		name string
+		path string
+		body string
+	}{
+		{name: "Unknown permission", path: "/api/v1/todos/7/shares", body: `{"email": "bob@example.com", "permission": "owner"}`},
+		{name: "Invalid email", path: "/api/v1/todos/7/shares", body: `{"email": "bob", "permission": "viewer"}`},
+		{name: "Invalid todo id", path: "/api/v1/todos/abc/shares", body: `{"email": "bob@example.com", "permission": "viewer"}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doShare(router, "POST", tc.path, tc.body)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestShare_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Not the owner", err: apperr.Forbidden("this todo is shared with you as editor; owner access is required"), wantStatus: http.StatusForbidden},
+		{name: "Unknown user", err: apperr.NotFound("user not found"), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupShareRouter(&MockShareUseCase{err: tc.err})
+			w := doShare(router, "POST", "/api/v1/todos/7/shares", `{"email": "bob@example.com", "permission": "viewer"}`)
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestShare_SharedWithMe(t *testing.T) {
+	uc := &MockShareUseCase{}
+	router := setupShareRouter(uc)
+
+	w := doShare(router, "GET", "/api/v1/todos/shared?limit=20&offset=40", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.page.Limit != 20 || uc.page.Offset != 40 {
+		t.Errorf("Expected limit 20 offset 40, got %+v", uc.page)
+	}
+}
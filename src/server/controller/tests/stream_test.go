@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/gin-gonic/gin"
+)
+
+// MockEventStore replays a fixed set of events and notifies through a channel.
+// Get returns the stored event with the id, or an update to todo 9.
+// Since fails for afterID from failAfter on, when set.
+type MockEventStore struct {
+	missed    []models.TodoEvent
+	stored    map[int64]models.TodoEvent
+	afterID   int64
+	failAfter int64
+	live      chan models.TodoEvent
+}
+
+func (m *MockEventStore) Publish(ctx context.Context, event models.TodoEvent) (*models.TodoEvent, error) {
+	return &event, nil
+}
+
+func (m *MockEventStore) Get(ctx context.Context, id int64) (*models.TodoEvent, error) {
+	if event, ok := m.stored[id]; ok {
+		return &event, nil
+	}
+	return &models.TodoEvent{ID: id, UserID: 1, Type: models.TodoUpdated, TodoID: 9}, nil
+}
+
+func (m *MockEventStore) Since(ctx context.Context, userID int, afterID int64, limit int) ([]models.TodoEvent, error) {
+	m.afterID = afterID
+	if m.failAfter > 0 && afterID >= m.failAfter {
+		return nil, errors.New("db down")
+	}
+	return m.missed, nil
+}
+
+func (m *MockEventStore) Listen(ctx context.Context, handle func(id int64, userID int)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-m.live:
+			handle(event.ID, event.UserID)
+		}
+	}
+}
+
+func setupStreamRouter(bus *events.Bus) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewStreamController(bus)
+	router.GET("/api/v1/todos/stream", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}, ctrl.Stream)
+	return router
+}
+
+func TestStream_ReplaysMissedEvents(t *testing.T) {
+	store := &MockEventStore{missed: []models.TodoEvent{
+		{ID: 6, UserID: 1, Type: models.TodoCreated, TodoID: 3, Todo: &models.Todo{ID: 3}},
+		{ID: 7, UserID: 1, Type: models.TodoDeleted, TodoID: 2},
+	}}
+	router := setupStreamRouter(events.NewBus(store))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/todos/stream", nil)
+	req.Header.Set(controller.LastEventIDHeader, "5")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("Expected a 200 event stream, got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if store.afterID != 5 {
+		t.Errorf("Expected a replay after event 5, got %d", store.afterID)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "id: 6\nevent: created\ndata: {\"id\":6,") || !strings.Contains(body, "id: 7\nevent: deleted\n") {
+		t.Errorf("Expected events 6 and 7 in order, got %q", body)
+	}
+}
+
+func TestStream_SendsReplayPagesAsRead(t *testing.T) {
+	missed := make([]models.TodoEvent, events.ReplayLimit)
+	for i := range missed {
+		missed[i] = models.TodoEvent{ID: int64(6 + i), UserID: 1, Type: models.TodoUpdated, TodoID: 3}
+	}
+	last := missed[len(missed)-1].ID
+	store := &MockEventStore{missed: missed, failAfter: last}
+	router := setupStreamRouter(events.NewBus(store))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "/api/v1/todos/stream", nil)
+	req.Header.Set(controller.LastEventIDHeader, "5")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// The second page fails after the first was sent; the client resumes from it
+	if w.Code != http.StatusOK || store.afterID != last {
+		t.Fatalf("Expected a 200 stream that read a second page after %d, got %d (after %d)", last, w.Code, store.afterID)
+	}
+	if !strings.Contains(w.Body.String(), fmt.Sprintf("id: %d\n", last)) {
+		t.Errorf("Expected the first page to be sent, got %d bytes", w.Body.Len())
+	}
+	if ctx.Err() != nil {
+		t.Error("Expected the stream to end when a page fails")
+	}
+}
+
+func TestStream_InvalidLastEventID(t *testing.T) {
+	router := setupStreamRouter(events.NewBus(&MockEventStore{}))
+
+	w := doProject(router, "GET", "/api/v1/todos/stream?last_event_id=abc", "")
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got %d", w.Code)
+	}
+}
+
+func TestStream_LiveEventsUntilBusStops(t *testing.T) {
+	store := &MockEventStore{live: make(chan models.TodoEvent)}
+	bus := events.NewBus(store)
+	bus.Start()
+	server := httptest.NewServer(setupStreamRouter(bus))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v1/todos/stream")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	// The response starts once the stream is subscribed
+	store.live <- models.TodoEvent{ID: 10, UserID: 1}
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil || line != "id: 10\n" {
+		t.Fatalf("Expected live event 10, got %q (%v)", line, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bus.Stop(ctx); err != nil {
+		t.Fatalf("Expected a clean stop, got %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			if _, err := reader.ReadString('\n'); err != nil {
+				break
+			}
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end when the bus stops")
+	}
+}
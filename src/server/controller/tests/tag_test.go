@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// MockTagUseCase records the last name it was given
+type MockTagUseCase struct {
+	err  error
+	name string
+}
+
+func (m *MockTagUseCase) Create(ctx context.Context, userID int, name string) (*models.Tag, error) {
+	m.name = name
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Tag{ID: 1, UserID: userID, Name: name}, nil
+}
+
+func (m *MockTagUseCase) List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error) {
+	return nil, m.err
+}
+
+func (m *MockTagUseCase) GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Tag{ID: tagID, UserID: userID}, nil
+}
+
+func (m *MockTagUseCase) Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error) {
+	m.name = name
+	return &models.Tag{ID: tagID, UserID: userID, Name: name}, m.err
+}
+
+func (m *MockTagUseCase) Delete(ctx context.Context, tagID, userID int) error {
+	return m.err
+}
+
+func setupTagRouter(uc *MockTagUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTagController(uc)
+	tags := router.Group("/api/v1/tags", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	})
+	tags.GET("", ctrl.List)
+	tags.POST("", middleware.BindJSON[controller.CreateTagRequest](), ctrl.Create)
+	tags.GET("/:id", ctrl.GetByID)
+	tags.PUT("/:id", middleware.BindJSON[controller.UpdateTagRequest](), ctrl.Update)
+	tags.DELETE("/:id", ctrl.Delete)
+	return router
+}
+
+func TestTag_Validation(t *testing.T) {
+	router := setupTagRouter(&MockTagUseCase{})
+	long := `{"name": "` + strings.Repeat("a", 51) + `"}`
+
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "Valid", method: "POST", path: "/api/v1/tags", body: `{"name": "errands"}`, wantStatus: http.StatusCreated},
+		{name: "Missing name", method: "POST", path: "/api/v1/tags", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "Name too long", method: "POST", path: "/api/v1/tags", body: long, wantStatus: http.StatusBadRequest},
+		{name: "Rename", method: "PUT", path: "/api/v1/tags/1", body: `{"name": "chores"}`, wantStatus: http.StatusOK},
+		{name: "Empty rename", method: "PUT", path: "/api/v1/tags/1", body: `{"name": ""}`, wantStatus: http.StatusBadRequest},
+		{name: "Invalid id", method: "DELETE", path: "/api/v1/tags/abc", wantStatus: http.StatusBadRequest},
+		{name: "List", method: "GET", path: "/api/v1/tags?limit=10", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProject(router, tc.method, tc.path, tc.body)
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTag_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"Not found", apperr.NotFound("tag not found"), "GET", "/api/v1/tags/9", "", http.StatusNotFound},
+		{"Duplicate name", apperr.Conflict("tag already exists"), "POST", "/api/v1/tags", `{"name": "Home"}`, http.StatusConflict},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProject(setupTagRouter(&MockTagUseCase{err: tc.err}), tc.method, tc.path, tc.body)
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTodoBatchRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	router.POST("/api/v1/todos/batch", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}, middleware.BindJSON[controller.BatchTodoRequest](), ctrl.Batch)
+	return router
+}
+
+func postBatch(router *gin.Engine, query string, body any) *httptest.ResponseRecorder {
+	raw, _ := json.Marshal(body)
+	req, _ := http.NewRequest("POST", "/api/v1/todos/batch"+query, bytes.NewBuffer(raw))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTodoBatch_Validation(t *testing.T) {
+	router := setupTodoBatchRouter(&MockTodoUseCase{})
+
+	testCases := []struct {
+		name      string
+		ops       []map[string]any
+		wantField string
+	}{
+		{"no operations", []map[string]any{}, "operations"},
+		{"unknown op", []map[string]any{{"op": "archive", "id": 1}}, "operations[0].op"},
+		{"create without title", []map[string]any{{"op": "create"}}, "operations[0].title"},
+		{"create with empty title", []map[string]any{{"op": "create", "title": ""}}, "operations[0].title"},
+		{"update without id", []map[string]any{{"op": "create", "title": "ok"}, {"op": "update", "title": "x"}}, "operations[1].id"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := postBatch(router, "", map[string]any{"operations": tc.ops})
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+			}
+			var problem reply.Problem
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if len(problem.Errors) == 0 || problem.Errors[0].Field != tc.wantField {
+				t.Errorf("Expected field error on %s, got %+v", tc.wantField, problem.Errors)
+			}
+		})
+	}
+}
+
+func TestTodoBatch_Results(t *testing.T) {
+	title := "Buy milk"
+	body := map[string]any{"operations": []map[string]any{
+		{"op": "create", "title": title},
+		{"op": "toggle", "id": 7},
+	}}
+
+	t.Run("all succeeded", func(t *testing.T) {
+		uc := &MockTodoUseCase{batchResults: []models.TodoBatchResult{
+			{Index: 0, Op: models.BatchCreate, Status: models.BatchStatusOK},
+			{Index: 1, Op: models.BatchToggle, Status: models.BatchStatusOK},
+		}}
+		w := postBatch(setupTodoBatchRouter(uc), "", body)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if !uc.batchAtomic {
+			t.Error("Expected batches to be atomic by default")
+		}
+		if len(uc.batchOps) != 2 || *uc.batchOps[0].Todo.Title != title || uc.batchOps[1].Todo.ID != 7 {
+			t.Errorf("Expected operations to reach the use case, got %+v", uc.batchOps)
+		}
+	})
+
+	t.Run("partial failure", func(t *testing.T) {
+		uc := &MockTodoUseCase{batchResults: []models.TodoBatchResult{
+			{Index: 0, Op: models.BatchCreate, Status: models.BatchStatusOK},
+			{Index: 1, Op: models.BatchToggle, Status: models.BatchStatusError,
+				Error: &models.BatchError{Code: "not_found", Message: "todo not found"}},
+		}}
+		w := postBatch(setupTodoBatchRouter(uc), "?atomic=false", body)
+
+		if w.Code != http.StatusMultiStatus {
+			t.Fatalf("Expected status 207, got %d. Body: %s", w.Code, w.Body.String())
+		}
+		if uc.batchAtomic {
+			t.Error("Expected atomic=false to reach the use case")
+		}
+		var resp struct {
+			Succeeded int `json:"succeeded"`
+			Failed    int `json:"failed"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Succeeded != 1 || resp.Failed != 1 {
+			t.Errorf("Expected 1 succeeded and 1 failed, got %s", w.Body.String())
+		}
+	})
+}
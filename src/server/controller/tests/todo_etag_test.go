@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTodoETagRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	auth := func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}
+	router.GET("/api/v1/todos/:id", auth, ctrl.GetByID)
+	router.PUT("/api/v1/todos/:id", auth, middleware.BindJSON[controller.UpdateTodoRequest](), ctrl.Update)
+	router.PATCH("/api/v1/todos/:id/toggle", auth, ctrl.Toggle)
+	return router
+}
+
+func TestTodoGet_ETag(t *testing.T) {
+	uc := &MockTodoUseCase{todo: &models.Todo{ID: 1, Version: 3}}
+	router := setupTodoETagRouter(uc)
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos/1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"v3"` {
+		t.Fatalf("Expected 200 with ETag \"v3\", got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	req.Header.Set("If-None-Match", `"v3"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("Expected an empty 304 for a current tag, got %d %s", w.Code, w.Body.String())
+	}
+
+	req.Header.Set("If-None-Match", `"v2"`)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected 200 for a stale tag, got %d", w.Code)
+	}
+}
+
+func TestTodoUpdate_IfMatch(t *testing.T) {
+	uc := &MockTodoUseCase{todo: &models.Todo{ID: 1, Version: 4}}
+	router := setupTodoETagRouter(uc)
+
+	req, _ := http.NewRequest("PUT", "/api/v1/todos/1", bytes.NewBufferString(`{"title":"new"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", `"v3"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || uc.version != 3 {
+		t.Fatalf("Expected the update to require version 3, got %d (version %d)", w.Code, uc.version)
+	}
+	if w.Header().Get("ETag") != `"v4"` {
+		t.Errorf("Expected the new ETag \"v4\", got %q", w.Header().Get("ETag"))
+	}
+}
+
+func TestTodoUpdate_StaleVersion(t *testing.T) {
+	uc := &MockTodoUseCase{err: apperr.PreconditionFailed("todo has been modified since version 3")}
+	router := setupTodoETagRouter(uc)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/todos/1/toggle", nil)
+	req.Header.Set("If-Match", `"v3"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusPreconditionFailed {
+		t.Errorf("Expected status 412, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestTodoUpdate_InvalidIfMatch(t *testing.T) {
+	uc := &MockTodoUseCase{todo: &models.Todo{ID: 1}}
+	router := setupTodoETagRouter(uc)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/todos/1/toggle", nil)
+	req.Header.Set("If-Match", "3")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a malformed If-Match, got %d", w.Code)
+	}
+}
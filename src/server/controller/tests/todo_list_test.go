@@ -0,0 +1,328 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+// MockTodoUseCase records what it was asked for and returns fixed results
+type MockTodoUseCase struct {
+	filter models.TodoFilter
+	page   pagination.Page
+	result pagination.Result[models.Todo]
+
+	todo    *models.Todo
+	err     error
+	version int
+	patch   models.TodoPatch
+
+	batchOps     []models.TodoBatchOp
+	batchAtomic  bool
+	batchResults []models.TodoBatchResult
+
+	updated  *models.Todo
+	future   bool
+	series   *models.TodoSeries
+	upcoming int
+
+	subtasks      []models.Subtask
+	subtaskUpdate models.SubtaskUpdate
+	order         []int
+
+	move models.TodoMove
+
+	revision int
+	diff     *models.RevisionDiff
+}
+
+func (m *MockTodoUseCase) Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	return nil, nil
+}
+func (m *MockTodoUseCase) GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) (*pagination.Result[models.Todo], error) {
+	m.filter, m.page = filter, page
+	return &m.result, nil
+}
+func (m *MockTodoUseCase) GetByID(ctx context.Context, id, userID int) (*models.Todo, error) {
+	return m.todo, m.err
+}
+func (m *MockTodoUseCase) Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	m.version, m.updated = todo.Version, todo
+	return m.todo, m.err
+}
+func (m *MockTodoUseCase) UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	m.version, m.updated, m.future = todo.Version, todo, true
+	return m.todo, m.err
+}
+func (m *MockTodoUseCase) Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+	m.patch = patch
+	return m.todo, m.err
+}
+func (m *MockTodoUseCase) Delete(ctx context.Context, id, userID int) error { return nil }
+func (m *MockTodoUseCase) Toggle(ctx context.Context, id, userID, version int) (*models.Todo, error) {
+	m.version = version
+	return m.todo, m.err
+}
+func (m *MockTodoUseCase) Restore(ctx context.Context, id, userID int) (*models.Todo, error) {
+	return nil, nil
+}
+func (m *MockTodoUseCase) Purge(ctx context.Context, id, userID int) error { return nil }
+func (m *MockTodoUseCase) Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error) {
+	m.batchOps, m.batchAtomic = ops, atomic
+	return m.batchResults, nil
+}
+func (m *MockTodoUseCase) GetSeries(ctx context.Context, seriesID, userID, upcoming int) (*models.TodoSeries, error) {
+	m.upcoming = upcoming
+	return m.series, m.err
+}
+func (m *MockTodoUseCase) DeleteSeries(ctx context.Context, seriesID, userID int) error { return m.err }
+func (m *MockTodoUseCase) ListSubtasks(ctx context.Context, todoID, userID int) ([]models.Subtask, error) {
+	return m.subtasks, m.err
+}
+func (m *MockTodoUseCase) AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error) {
+	return &models.Subtask{ID: 1, TodoID: todoID, Title: title, Position: 1}, m.err
+}
+func (m *MockTodoUseCase) UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error) {
+	m.subtaskUpdate = update
+	return &models.Subtask{ID: update.ID, TodoID: update.TodoID}, m.err
+}
+func (m *MockTodoUseCase) DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error {
+	return m.err
+}
+func (m *MockTodoUseCase) ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error) {
+	m.order = ids
+	return m.subtasks, m.err
+}
+
+func (m *MockTodoUseCase) Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error) {
+	m.move = move
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Todo{ID: move.ID, UserID: userID, Version: 4}, nil
+}
+
+func (m *MockTodoUseCase) ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error) {
+	m.page = page
+	return []models.TodoRevision{{TodoID: todoID, Revision: 1}}, m.err
+}
+
+func (m *MockTodoUseCase) DiffRevision(ctx context.Context, todoID, userID, revision int) (*models.RevisionDiff, error) {
+	m.revision = revision
+	return m.diff, m.err
+}
+
+func (m *MockTodoUseCase) RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error) {
+	m.revision, m.version = revision, version
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &models.Todo{ID: todoID, UserID: userID, Version: 5}, nil
+}
+
+func setupTodoListRouter(uc *MockTodoUseCase, cursors *pagination.Codec) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, cursors)
+	router.GET("/api/v1/todos", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}, ctrl.List)
+	return router
+}
+
+func TestTodoList_Filters(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?completed=false&q=+buy+milk&sort=title&created_after=2024-01-01T00:00:00Z", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	f := uc.filter
+	if f.Completed == nil || *f.Completed {
+		t.Errorf("Expected completed=false filter, got %v", f.Completed)
+	}
+	if f.Query != "buy milk" {
+		t.Errorf("Expected trimmed query, got %q", f.Query)
+	}
+	if f.Sort != models.SortByTitle || !f.Ascending {
+		t.Errorf("Expected title ascending by default, got %s ascending=%v", f.Sort, f.Ascending)
+	}
+	if f.CreatedAfter == nil || !f.CreatedAfter.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected created_after 2024-01-01, got %v", f.CreatedAfter)
+	}
+}
+
+func TestTodoList_ProjectFilter(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?project_id=3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.filter.ProjectID == nil || *uc.filter.ProjectID != 3 || uc.filter.Inbox {
+		t.Errorf("Expected project 3 filter, got %v inbox=%v", uc.filter.ProjectID, uc.filter.Inbox)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?inbox=true", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !uc.filter.Inbox || uc.filter.ProjectID != nil {
+		t.Errorf("Expected inbox filter, got status %d project=%v inbox=%v", w.Code, uc.filter.ProjectID, uc.filter.Inbox)
+	}
+}
+
+func TestTodoList_DueFilter(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?due=overdue&priority=urgent", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if !uc.filter.Overdue || uc.filter.Priority == nil || *uc.filter.Priority != models.PriorityUrgent {
+		t.Errorf("Expected overdue urgent filter, got overdue=%v priority=%v", uc.filter.Overdue, uc.filter.Priority)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?due=today&tz=Asia/Tokyo", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	after, before := uc.filter.DueAfter, uc.filter.DueBefore
+	if after == nil || before == nil {
+		t.Fatalf("Expected a due window, got %v..%v", after, before)
+	}
+	tokyo, _ := time.LoadLocation("Asia/Tokyo")
+	if h, m, _ := after.In(tokyo).Clock(); h != 0 || m != 0 || before.Sub(*after) != 24*time.Hour {
+		t.Errorf("Expected a Tokyo day, got %v..%v", after, before)
+	}
+	if now := time.Now(); now.Before(*after) || !now.Before(*before) {
+		t.Errorf("Expected the window to contain now, got %v..%v", after, before)
+	}
+}
+
+func TestTodoList_TagFilter(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?tag=home&tag=errands", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || len(uc.filter.Tags) != 2 || uc.filter.AllTags {
+		t.Errorf("Expected any of two tags, got status %d tags=%v all=%v", w.Code, uc.filter.Tags, uc.filter.AllTags)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?tag=home&tag_mode=all", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || !uc.filter.AllTags {
+		t.Errorf("Expected all tags, got status %d all=%v", w.Code, uc.filter.AllTags)
+	}
+}
+
+func TestTodoList_PositionSort(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?sort=position", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK || uc.filter.Sort != models.SortByPosition || !uc.filter.Ascending {
+		t.Errorf("Expected position ascending by default, got status %d %s ascending=%v", w.Code, uc.filter.Sort, uc.filter.Ascending)
+	}
+}
+
+func TestTodoList_InvalidQuery(t *testing.T) {
+	router := setupTodoListRouter(&MockTodoUseCase{}, pagination.NewCodec([]byte("test")))
+
+	for _, query := range []string{"sort=priority", "order=up", "completed=maybe", "created_after=yesterday", "project_id=0", "project_id=2&inbox=true", "priority=soon", "due=tomorrow", "due=today&tz=Mars/Olympus", "due=overdue&due_after=2024-01-01T00:00:00Z", "tag=", "tag=home&tag_mode=some"} {
+		t.Run(query, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/api/v1/todos?"+query, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got %d. Body: %s", w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTodoList_Cursors(t *testing.T) {
+	cursors := pagination.NewCodec([]byte("test"))
+	next := pagination.Cursor{CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ID: 9, Dir: pagination.Next}
+	total := 12
+	uc := &MockTodoUseCase{result: pagination.Result[models.Todo]{Items: []models.Todo{{ID: 10}}, Next: &next, Total: &total}}
+	router := setupTodoListRouter(uc, cursors)
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?limit=1", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var body struct {
+		Count      int    `json:"count"`
+		Total      int    `json:"total"`
+		NextCursor string `json:"next_cursor"`
+		PrevCursor string `json:"prev_cursor"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Count != 1 || body.Total != 12 || w.Header().Get("X-Total-Count") != "12" {
+		t.Errorf("Expected count 1 and total 12, got %s (X-Total-Count %q)", w.Body.String(), w.Header().Get("X-Total-Count"))
+	}
+	if uc.page.SkipTotal {
+		t.Error("Expected the total to be requested by default")
+	}
+	if body.NextCursor == "" || body.PrevCursor != "" {
+		t.Fatalf("Expected only next_cursor, got %s", w.Body.String())
+	}
+	if link := w.Header().Get("Link"); !strings.Contains(link, `rel="next"`) || !strings.Contains(link, "limit=1") {
+		t.Errorf("Expected a next Link header keeping limit, got %q", link)
+	}
+
+	// Following the cursor hands the decoded position to the use case
+	req, _ = http.NewRequest("GET", "/api/v1/todos?limit=1&cursor="+body.NextCursor, nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if uc.page.Cursor == nil || uc.page.Cursor.ID != 9 {
+		t.Errorf("Expected cursor at todo 9, got %+v", uc.page.Cursor)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?include_total=false", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	if !uc.page.SkipTotal {
+		t.Error("Expected include_total=false to skip the count")
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?cursor=forged", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a forged cursor, got %d", w.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupMoveRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	router.PATCH("/api/v1/todos/:id/move", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}, middleware.BindJSON[controller.MoveTodoRequest](), ctrl.Move)
+	return router
+}
+
+func doMove(router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTodoMove(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupMoveRouter(uc)
+
+	w := doMove(router, "/api/v1/todos/5/move", `{"after_id": 2, "before_id": 3}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if m := uc.move; m.ID != 5 || m.AfterID == nil || *m.AfterID != 2 || m.BeforeID == nil || *m.BeforeID != 3 {
+		t.Errorf("Expected todo 5 between 2 and 3, got %+v", m)
+	}
+	if got := w.Header().Get("ETag"); got != `"v4"` {
+		t.Errorf("Expected the unchanged version as ETag, got %q", got)
+	}
+}
+
+func TestTodoMove_Validation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"after only", "/api/v1/todos/5/move", `{"after_id": 2}`, http.StatusOK},
+		{"before only", "/api/v1/todos/5/move", `{"before_id": 3}`, http.StatusOK},
+		{"no neighbour", "/api/v1/todos/5/move", `{}`, http.StatusBadRequest},
+		{"bad neighbour", "/api/v1/todos/5/move", `{"before_id": 0}`, http.StatusBadRequest},
+		{"bad id", "/api/v1/todos/abc/move", `{"after_id": 2}`, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doMove(setupMoveRouter(&MockTodoUseCase{}), tc.path, tc.body)
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestTodoMove_SharedTodo(t *testing.T) {
+	router := setupMoveRouter(&MockTodoUseCase{err: apperr.Forbidden("this todo is shared with you as editor; owner access is required")})
+
+	w := doMove(router, "/api/v1/todos/5/move", `{"after_id": 2}`)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got %d. Body: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTodoPatchRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	router.PATCH("/api/v1/todos/:id", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}, middleware.ContentType(middleware.MergePatchJSON, "application/json"), middleware.BindJSON[controller.PatchTodoRequest](), ctrl.Patch)
+	return router
+}
+
+func patchTodo(router *gin.Engine, contentType, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", "/api/v1/todos/1", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestTodoPatch_AbsentVersusNull(t *testing.T) {
+	uc := &MockTodoUseCase{todo: &models.Todo{ID: 1, Version: 2}}
+	router := setupTodoPatchRouter(uc)
+
+	w := patchTodo(router, "application/merge-patch+json; charset=utf-8", `{"description": null, "completed": true}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+
+	p := uc.patch
+	if p.ID != 1 || p.Title.Set {
+		t.Errorf("Expected title to be left alone, got %+v", p.Title)
+	}
+	if !p.Description.IsNull() {
+		t.Errorf("Expected description to be cleared, got %+v", p.Description)
+	}
+	if !p.Completed.Set || p.Completed.Value == nil || !*p.Completed.Value {
+		t.Errorf("Expected completed=true, got %+v", p.Completed)
+	}
+	if w.Header().Get("ETag") != `"v2"` {
+		t.Errorf("Expected ETag \"v2\", got %q", w.Header().Get("ETag"))
+	}
+}
+
+func TestTodoPatch_ContentType(t *testing.T) {
+	router := setupTodoPatchRouter(&MockTodoUseCase{todo: &models.Todo{ID: 1}})
+
+	if w := patchTodo(router, "text/plain", `{"title": "x"}`); w.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status 415, got %d", w.Code)
+	}
+	if w := patchTodo(router, "application/json", `{"title": "x"}`); w.Code != http.StatusOK {
+		t.Errorf("Expected plain JSON to be accepted, got %d", w.Code)
+	}
+	if w := patchTodo(router, middleware.MergePatchJSON, `[1, 2]`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a non-object patch, got %d", w.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupRevisionsRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	todos := router.Group("/api/v1/todos", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	})
+	todos.GET("/:id/revisions", ctrl.ListRevisions)
+	todos.GET("/:id/revisions/:rev", ctrl.GetRevision)
+	todos.POST("/:id/revisions/:rev/revert", ctrl.RevertRevision)
+	return router
+}
+
+func TestTodoRevisions_List(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	w := doProject(setupRevisionsRouter(uc), "GET", "/api/v1/todos/5/revisions?limit=5&offset=10", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.page.Limit != 5 || uc.page.Offset != 10 {
+		t.Errorf("Expected limit 5 offset 10, got %+v", uc.page)
+	}
+}
+
+func TestTodoRevisions_Get(t *testing.T) {
+	title := "New"
+	uc := &MockTodoUseCase{diff: &models.RevisionDiff{
+		Revision: models.TodoRevision{TodoID: 5, Revision: 2, Title: "Old"},
+		Changes:  map[string]models.FieldChange{"title": {From: "Old", To: &title}},
+	}}
+	w := doProject(setupRevisionsRouter(uc), "GET", "/api/v1/todos/5/revisions/2", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.revision != 2 {
+		t.Errorf("Expected revision 2, got %d", uc.revision)
+	}
+
+	var resp struct {
+		Revision models.TodoRevision `json:"revision"`
+		Changes  map[string]struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	if resp.Revision.Revision != 2 || resp.Changes["title"].From != "Old" || resp.Changes["title"].To != "New" {
+		t.Errorf("Expected the title change from Old to New, got %s", w.Body.String())
+	}
+}
+
+func TestTodoRevisions_Revert(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupRevisionsRouter(uc)
+
+	req, _ := http.NewRequest("POST", "/api/v1/todos/5/revisions/3/revert", nil)
+	req.Header.Set("If-Match", `"v4"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	if uc.revision != 3 || uc.version != 4 {
+		t.Errorf("Expected revision 3 at version 4, got revision %d version %d", uc.revision, uc.version)
+	}
+	if got := w.Header().Get("ETag"); got != `"v5"` {
+		t.Errorf("Expected ETag \"v5\", got %q", got)
+	}
+}
+
+func TestTodoRevisions_Errors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		err        error
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{"Invalid id", nil, "GET", "/api/v1/todos/abc/revisions", http.StatusBadRequest},
+		{"Invalid revision", nil, "GET", "/api/v1/todos/5/revisions/abc", http.StatusBadRequest},
+		{"Revision not found", apperr.NotFound("revision not found"), "GET", "/api/v1/todos/5/revisions/9", http.StatusNotFound},
+		{"Viewer reverting", apperr.Forbidden("editor access is required"), "POST", "/api/v1/todos/5/revisions/1/revert", http.StatusForbidden},
+		{"Stale version", apperr.PreconditionFailed("todo has been modified since version 3"), "POST", "/api/v1/todos/5/revisions/1/revert", http.StatusPreconditionFailed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doProject(setupRevisionsRouter(&MockTodoUseCase{err: tc.err}), tc.method, tc.path, "")
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
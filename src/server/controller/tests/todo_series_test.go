@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupTodoSeriesRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	auth := func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}
+	router.PUT("/api/v1/todos/:id", auth, middleware.BindJSON[controller.UpdateTodoRequest](), ctrl.Update)
+	router.GET("/api/v1/todos/series/:id", auth, ctrl.GetSeries)
+	router.DELETE("/api/v1/todos/series/:id", auth, ctrl.DeleteSeries)
+	return router
+}
+
+func TestTodoUpdate_Scope(t *testing.T) {
+	testCases := []struct {
+		query      string
+		wantStatus int
+		wantFuture bool
+	}{
+		{"", http.StatusOK, false},
+		{"?scope=this", http.StatusOK, false},
+		{"?scope=future", http.StatusOK, true},
+		{"?scope=all", http.StatusBadRequest, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			uc := &MockTodoUseCase{todo: &models.Todo{ID: 1, Version: 2}}
+			router := setupTodoSeriesRouter(uc)
+
+			body := bytes.NewBufferString(`{"title":"Weekly report","rrule":"FREQ=WEEKLY;BYDAY=FR"}`)
+			req, _ := http.NewRequest("PUT", "/api/v1/todos/1"+tc.query, body)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+			if uc.future != tc.wantFuture {
+				t.Errorf("Expected future=%v, got %v", tc.wantFuture, uc.future)
+			}
+			if tc.wantStatus == http.StatusOK && (uc.updated.Recurrence == nil || uc.updated.Recurrence.RRule != "FREQ=WEEKLY;BYDAY=FR") {
+				t.Errorf("Expected the rule to be passed on, got %+v", uc.updated.Recurrence)
+			}
+		})
+	}
+}
+
+func TestTodoSeries_Get(t *testing.T) {
+	uc := &MockTodoUseCase{series: &models.TodoSeries{ID: 3, RRule: "FREQ=DAILY"}}
+	router := setupTodoSeriesRouter(uc)
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos/series/3", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || uc.upcoming != 5 {
+		t.Fatalf("Expected 200 with 5 upcoming, got %d (%d). Body: %s", w.Code, uc.upcoming, w.Body.String())
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos/series/3?upcoming=0", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || uc.upcoming != 0 {
+		t.Errorf("Expected upcoming=0 to be kept, got %d (%d)", w.Code, uc.upcoming)
+	}
+
+	for _, query := range []string{"?upcoming=51", "?upcoming=-1"} {
+		req, _ = http.NewRequest("GET", "/api/v1/todos/series/3"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s, got %d", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/gin-gonic/gin"
+)
+
+func setupSubtaskRouter(uc *MockTodoUseCase) *gin.Engine {
+	router := SetupTestRouter()
+	ctrl := controller.NewTodoController(uc, pagination.NewCodec([]byte("test")))
+	auth := func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, 1)
+		c.Next()
+	}
+	router.GET("/api/v1/todos/:id/subtasks", auth, ctrl.ListSubtasks)
+	router.POST("/api/v1/todos/:id/subtasks", auth, middleware.BindJSON[controller.AddSubtaskRequest](), ctrl.AddSubtask)
+	router.PUT("/api/v1/todos/:id/subtasks/order", auth, middleware.BindJSON[controller.ReorderSubtasksRequest](), ctrl.ReorderSubtasks)
+	router.PATCH("/api/v1/todos/:id/subtasks/:subtask_id", auth, middleware.BindJSON[controller.UpdateSubtaskRequest](), ctrl.UpdateSubtask)
+	router.DELETE("/api/v1/todos/:id/subtasks/:subtask_id", auth, ctrl.DeleteSubtask)
+	return router
+}
+
+func TestSubtasks_Validation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{"add", "POST", "/api/v1/todos/1/subtasks", `{"title":"Buy milk"}`, http.StatusCreated},
+		{"add without title", "POST", "/api/v1/todos/1/subtasks", `{}`, http.StatusBadRequest},
+		{"reorder", "PUT", "/api/v1/todos/1/subtasks/order", `{"ids":[3,1,2]}`, http.StatusOK},
+		{"reorder nothing", "PUT", "/api/v1/todos/1/subtasks/order", `{"ids":[]}`, http.StatusBadRequest},
+		{"reorder bad id", "PUT", "/api/v1/todos/1/subtasks/order", `{"ids":[1,0]}`, http.StatusBadRequest},
+		{"update bad subtask id", "PATCH", "/api/v1/todos/1/subtasks/abc", `{"completed":true}`, http.StatusBadRequest},
+		{"delete", "DELETE", "/api/v1/todos/1/subtasks/2", ``, http.StatusOK},
+		{"list bad todo id", "GET", "/api/v1/todos/abc/subtasks", ``, http.StatusBadRequest},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupSubtaskRouter(&MockTodoUseCase{})
+			req, _ := http.NewRequest(tc.method, tc.path, bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d. Body: %s", tc.wantStatus, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestSubtasks_UpdatePassesCompleteParent(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	router := setupSubtaskRouter(uc)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/todos/4/subtasks/9", bytes.NewBufferString(`{"completed":true,"complete_parent":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d. Body: %s", w.Code, w.Body.String())
+	}
+	got := uc.subtaskUpdate
+	if got.ID != 9 || got.TodoID != 4 || got.Completed == nil || !*got.Completed || !got.CompleteParent {
+		t.Errorf("Unexpected update: %+v", got)
+	}
+}
+
+func TestTodoList_IncludeSubtasks(t *testing.T) {
+	uc := &MockTodoUseCase{result: pagination.Result[models.Todo]{Items: []models.Todo{}}}
+	router := setupTodoListRouter(uc, pagination.NewCodec([]byte("test")))
+
+	req, _ := http.NewRequest("GET", "/api/v1/todos?include=subtasks", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !uc.filter.WithSubtasks {
+		t.Errorf("Expected subtasks to be requested, got %d %+v", w.Code, uc.filter)
+	}
+
+	req, _ = http.NewRequest("GET", "/api/v1/todos?include=shares", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown include, got %d", w.Code)
+	}
+}
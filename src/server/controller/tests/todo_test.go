@@ -56,6 +56,31 @@ func TestCreateTodoValidation(t *testing.T) {
 			body:       map[string]interface{}{"title": "Test Todo"},
 			wantStatus: http.StatusCreated,
 		},
+		{
+			name:       "Recurring todo",
+			body:       map[string]interface{}{"title": "Weekly report", "due_at": "2025-03-03T09:00:00Z", "rrule": "FREQ=WEEKLY", "timezone": "Europe/Berlin"},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Timezone without rrule",
+			body:       map[string]interface{}{"title": "Test Todo", "timezone": "Europe/Berlin"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Unknown timezone",
+			body:       map[string]interface{}{"title": "Test Todo", "rrule": "FREQ=DAILY", "timezone": "Mars/Olympus"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "With tags",
+			body:       map[string]interface{}{"title": "Test Todo", "tags": []string{"home", "errands"}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "Empty tag",
+			body:       map[string]interface{}{"title": "Test Todo", "tags": []string{""}},
+			wantStatus: http.StatusBadRequest,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -138,7 +163,7 @@ func TestTodoRoutesRequireAuth(t *testing.T) {
 
 	// Routes without auth middleware
 	protected := router.Group("/api/v1")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(newTestTokens(), &MockSessionChecker{}))
 	{
 		protected.GET("/todos", func(c *gin.Context) {
 			c.JSON(http.StatusOK, gin.H{"todos": []interface{}{}})
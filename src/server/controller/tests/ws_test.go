@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// wsFrame is any frame the server sends
+type wsFrame struct {
+	controller.WSReply
+	Users []int `json:"users"`
+}
+
+func setupWSServer(t *testing.T, uc *MockTodoUseCase, store *MockEventStore) (*realtime.Hub, string) {
+	return setupWSServerAs(t, 1, uc, &MockShareUseCase{}, store)
+}
+
+func setupWSServerAs(t *testing.T, userID int, uc *MockTodoUseCase, shares *MockShareUseCase, store *MockEventStore) (*realtime.Hub, string) {
+	bus := events.NewBus(store)
+	bus.Start()
+	hub := realtime.NewHub()
+	ctrl := controller.NewWebSocketController(uc, &MockProjectUseCase{}, shares, bus, &MockSessionChecker{}, hub)
+
+	router := SetupTestRouter()
+	router.GET("/api/v1/ws", func(c *gin.Context) {
+		c.Set(middleware.AuthUserIDKey, userID)
+		c.Next()
+	}, ctrl.Connect)
+	server := httptest.NewServer(router)
+	t.Cleanup(func() {
+		hub.Stop(context.Background())
+		bus.Stop(context.Background())
+		server.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http") + "/api/v1/ws"
+}
+
+func dialWS(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func sendWS(t *testing.T, ws *websocket.Conn, frame string) wsFrame {
+	t.Helper()
+	if err := ws.WriteMessage(websocket.TextMessage, []byte(frame)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	return readWS(t, ws)
+}
+
+func readWS(t *testing.T, ws *websocket.Conn) wsFrame {
+	t.Helper()
+	var frame wsFrame
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	if err := ws.ReadJSON(&frame); err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	return frame
+}
+
+func TestWebSocket_SubscribeAndMutate(t *testing.T) {
+	uc := &MockTodoUseCase{todo: &models.Todo{ID: 9, UserID: 1, Version: 4}}
+	store := &MockEventStore{live: make(chan models.TodoEvent)}
+	_, url := setupWSServer(t, uc, store)
+	ws := dialWS(t, url)
+
+	if f := sendWS(t, ws, `{"type": "subscribe", "ref": "s", "channel": "todo:9"}`); f.Type != "subscribed" || f.Ref != "s" || f.Channel != "todo:9" {
+		t.Fatalf("Expected subscribed to todo:9, got %+v", f)
+	}
+	if f := readWS(t, ws); f.Type != "presence" || len(f.Users) != 1 || f.Users[0] != 1 {
+		t.Errorf("Expected presence of user 1, got %+v", f)
+	}
+
+	if f := sendWS(t, ws, `{"type": "toggle", "ref": "t", "todo_id": 9, "version": 3}`); f.Type != "result" || f.Ref != "t" || f.Todo == nil || f.Todo.ID != 9 {
+		t.Errorf("Expected the toggled todo, got %+v", f)
+	}
+
+	// The mock store's events are all about todo 9
+	store.live <- models.TodoEvent{ID: 11, UserID: 1}
+	if f := readWS(t, ws); f.Type != "event" || f.Channel != "todo:9" || f.Event == nil || f.Event.ID != 11 {
+		t.Errorf("Expected event 11 on todo:9, got %+v", f)
+	}
+}
+
+func TestWebSocket_SharedList(t *testing.T) {
+	// User 2 holds a whole-list share of user 1's todos
+	shares := &MockShareUseCase{shares: []models.Share{{UserID: 2, Permission: models.PermissionViewer}}}
+	created := models.TodoEvent{ID: 12, UserID: 1, Type: models.TodoCreated, TodoID: 5}
+	store := &MockEventStore{live: make(chan models.TodoEvent), stored: map[int64]models.TodoEvent{12: created}}
+	_, url := setupWSServerAs(t, 2, &MockTodoUseCase{}, shares, store)
+	ws := dialWS(t, url)
+
+	if f := sendWS(t, ws, `{"type": "subscribe", "ref": "s", "channel": "list:1"}`); f.Type != "subscribed" || f.Channel != "list:1" {
+		t.Fatalf("Expected subscribed to list:1, got %+v", f)
+	}
+	if shares.todoID != nil {
+		t.Errorf("Expected a whole-list share lookup, got todo %d", *shares.todoID)
+	}
+	readWS(t, ws) // presence
+
+	store.live <- created
+	if f := readWS(t, ws); f.Type != "event" || f.Channel != "list:1" || f.Event == nil || f.Event.Type != models.TodoCreated {
+		t.Errorf("Expected the owner's created event on list:1, got %+v", f)
+	}
+
+	shares.shares = nil
+	if f := sendWS(t, ws, `{"type": "subscribe", "ref": "x", "channel": "list:3"}`); f.Error == nil || f.Error.Status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a list not shared with the user, got %+v", f)
+	}
+}
+
+func TestWebSocket_Errors(t *testing.T) {
+	uc := &MockTodoUseCase{}
+	_, url := setupWSServer(t, uc, &MockEventStore{})
+	ws := dialWS(t, url)
+
+	testCases := []struct {
+		name   string
+		frame  string
+		status int
+	}{
+		{"malformed", `{"type":`, http.StatusBadRequest},
+		{"unknown type", `{"type": "purge", "ref": "x"}`, http.StatusBadRequest},
+		{"bad channel", `{"type": "subscribe", "ref": "x", "channel": "tag:1"}`, http.StatusBadRequest},
+		{"missing todo_id", `{"type": "toggle", "ref": "x"}`, http.StatusBadRequest},
+		{"invalid data", `{"type": "move", "ref": "x", "todo_id": 3, "data": {}}`, http.StatusBadRequest},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := sendWS(t, ws, tc.frame)
+			if f.Type != "error" || f.Error == nil || f.Error.Status != tc.status {
+				t.Errorf("Expected a %d error, got %+v", tc.status, f)
+			}
+		})
+	}
+
+	uc.err = apperr.NotFound("todo not found")
+	if f := sendWS(t, ws, `{"type": "subscribe", "ref": "x", "channel": "todo:5"}`); f.Error == nil || f.Error.Status != http.StatusNotFound {
+		t.Errorf("Expected 404 for a todo the user cannot see, got %+v", f)
+	}
+}
+
+func TestWebSocket_RejectsCrossOrigin(t *testing.T) {
+	_, url := setupWSServer(t, &MockTodoUseCase{}, &MockEventStore{})
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, http.Header{"Origin": {"http://evil.example"}})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected 403 for a cross-origin upgrade, got %v", err)
+	}
+}
+
+func TestWebSocket_Shutdown(t *testing.T) {
+	hub, url := setupWSServer(t, &MockTodoUseCase{}, &MockEventStore{})
+	ws := dialWS(t, url)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Stop(ctx); err != nil {
+		t.Fatalf("Expected connections to drain, got %v", err)
+	}
+
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	if _, _, err := ws.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going away close frame, got %v", err)
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 while shutting down, got %v", err)
+	}
+}
@@ -2,87 +2,366 @@ package controller
 
 import (
 	"context"
+	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fayzzzm/go-bro/middleware"
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/etag"
+	"github.com/fayzzzm/go-bro/pkg/optional"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/pkg/reply"
 	"github.com/gin-gonic/gin"
 )
 
 type TodoUseCase interface {
-	Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error)
-	GetByUser(ctx context.Context, userID, limit, offset int) ([]models.Todo, error)
+	Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) (*pagination.Result[models.Todo], error)
 	GetByID(ctx context.Context, id, userID int) (*models.Todo, error)
 	Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error)
 	Delete(ctx context.Context, id, userID int) error
-	Toggle(ctx context.Context, id, userID int) (*models.Todo, error)
+	Toggle(ctx context.Context, id, userID, version int) (*models.Todo, error)
+	Restore(ctx context.Context, id, userID int) (*models.Todo, error)
+	Purge(ctx context.Context, id, userID int) error
+	Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error)
+	UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetSeries(ctx context.Context, seriesID, userID, upcoming int) (*models.TodoSeries, error)
+	DeleteSeries(ctx context.Context, seriesID, userID int) error
+	ListSubtasks(ctx context.Context, todoID, userID int) ([]models.Subtask, error)
+	AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error)
+	UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error)
+	DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error
+	ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error)
+	Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error)
+	ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error)
+	DiffRevision(ctx context.Context, todoID, userID, revision int) (*models.RevisionDiff, error)
+	RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error)
 }
 
 type TodoController struct {
 	usecase TodoUseCase
+	cursors *pagination.Codec
 }
 
-func NewTodoController(usecase TodoUseCase) *TodoController {
-	return &TodoController{usecase: usecase}
+func NewTodoController(usecase TodoUseCase, cursors *pagination.Codec) *TodoController {
+	return &TodoController{usecase: usecase, cursors: cursors}
 }
 
 type CreateTodoRequest struct {
-	Title       string  `json:"title" binding:"required"`
-	Description *string `json:"description"`
+	Title       string     `json:"title" binding:"required"`
+	Description *string    `json:"description"`
+	ProjectID   *int       `json:"project_id" binding:"omitempty,min=1"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    *string    `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Tags        []string   `json:"tags" binding:"omitempty,max=20,dive,min=1,max=50"`
+	// RRule makes the todo the first occurrence of a series; it needs due_at
+	RRule    *string `json:"rrule"`
+	Timezone *string `json:"timezone" binding:"omitempty,excluded_without=RRule,timezone"`
 }
 
+func (r CreateTodoRequest) todo() *models.Todo {
+	return &models.Todo{
+		Title:       &r.Title,
+		Description: r.Description,
+		ProjectID:   r.ProjectID,
+		DueAt:       r.DueAt,
+		Priority:    (*models.TodoPriority)(r.Priority),
+		RemindAt:    r.RemindAt,
+		Tags:        r.Tags,
+		Recurrence:  recurrence(r.RRule, r.Timezone),
+	}
+}
+
+// UpdateTodoRequest changes one todo; with scope=future the rule, zone and
+// template of its series change too.
 type UpdateTodoRequest struct {
-	Title       *string `json:"title"`
-	Description *string `json:"description"`
-	Completed   *bool   `json:"completed"`
+	Title       *string    `json:"title"`
+	Description *string    `json:"description"`
+	Completed   *bool      `json:"completed"`
+	ProjectID   *int       `json:"project_id" binding:"omitempty,min=1"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    *string    `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	RemindAt    *time.Time `json:"remind_at"`
+	// Tags replace the todo's tags; [] removes them all
+	Tags     []string `json:"tags" binding:"omitempty,max=20,dive,min=1,max=50"`
+	RRule    *string  `json:"rrule"`
+	Timezone *string  `json:"timezone" binding:"omitempty,timezone"`
+}
+
+// UpdateTodoQuery holds the PUT /todos/:id query string.
+type UpdateTodoQuery struct {
+	Scope string `form:"scope" binding:"omitempty,oneof=this future"`
+}
+
+// PatchTodoRequest is an RFC 7396 merge patch: absent fields are left alone,
+// null clears a field. Title, completed and priority cannot be cleared; a null
+// project_id moves the todo to the inbox and null tags remove them all.
+type PatchTodoRequest struct {
+	Title       optional.Field[string]              `json:"title"`
+	Description optional.Field[string]              `json:"description"`
+	Completed   optional.Field[bool]                `json:"completed"`
+	ProjectID   optional.Field[int]                 `json:"project_id"`
+	DueAt       optional.Field[time.Time]           `json:"due_at"`
+	Priority    optional.Field[models.TodoPriority] `json:"priority"`
+	RemindAt    optional.Field[time.Time]           `json:"remind_at"`
+	Tags        optional.Field[[]string]            `json:"tags"`
+}
+
+// patch applies the request to todo id; version 0 is unconditional.
+func (r PatchTodoRequest) patch(id, version int) models.TodoPatch {
+	return models.TodoPatch{
+		ID:          id,
+		Title:       r.Title,
+		Description: r.Description,
+		Completed:   r.Completed,
+		ProjectID:   r.ProjectID,
+		DueAt:       r.DueAt,
+		Priority:    r.Priority,
+		RemindAt:    r.RemindAt,
+		Tags:        r.Tags,
+		Version:     version,
+	}
+}
+
+// MoveTodoRequest places a todo right after after_id or right before
+// before_id; with both, between them.
+type MoveTodoRequest struct {
+	BeforeID *int `json:"before_id" binding:"required_without=AfterID,omitempty,min=1"`
+	AfterID  *int `json:"after_id" binding:"omitempty,min=1"`
+}
+
+// BatchTodoRequest is the body of POST /todos/batch.
+type BatchTodoRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// BatchOperation is one entry of a batch: create needs a title, the others an id.
+type BatchOperation struct {
+	Op          string     `json:"op" binding:"required,oneof=create update delete toggle"`
+	ID          int        `json:"id" binding:"required_unless=Op create"`
+	Title       *string    `json:"title" binding:"required_if=Op create,omitempty,min=1,max=500"`
+	Description *string    `json:"description"`
+	Completed   *bool      `json:"completed"`
+	ProjectID   *int       `json:"project_id" binding:"omitempty,min=1"`
+	DueAt       *time.Time `json:"due_at"`
+	Priority    *string    `json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	RemindAt    *time.Time `json:"remind_at"`
+	Tags        []string   `json:"tags" binding:"omitempty,max=20,dive,min=1,max=50"`
+	// Version makes update and toggle conditional, like If-Match on the single endpoints
+	Version int `json:"version" binding:"omitempty,min=1"`
+}
+
+// ListTodosQuery holds the GET /todos query string. Dates are RFC 3339;
+// order defaults to asc for title, due_at and position and to desc otherwise;
+// position is the manual order set with PATCH /todos/:id/move.
+// project_id lists one project, inbox=true the todos without a project.
+// due=overdue lists open todos past their due date; due=today those due
+// between midnight and midnight in tz (an IANA zone, UTC by default).
+// include=subtasks nests each todo's checklist. tag may repeat; todos with
+// any of the tags match, or with all of them when tag_mode=all.
+type ListTodosQuery struct {
+	Limit         int        `form:"limit" json:"limit"`
+	Offset        int        `form:"offset" json:"offset"`
+	Cursor        string     `form:"cursor" json:"cursor"`
+	Completed     *bool      `form:"completed" json:"completed"`
+	CreatedAfter  *time.Time `form:"created_after" json:"created_after"`
+	CreatedBefore *time.Time `form:"created_before" json:"created_before"`
+	UpdatedAfter  *time.Time `form:"updated_after" json:"updated_after"`
+	UpdatedBefore *time.Time `form:"updated_before" json:"updated_before"`
+	Q             string     `form:"q" json:"q" binding:"max=200"`
+	ProjectID     *int       `form:"project_id" json:"project_id" binding:"omitempty,min=1,excluded_with=Inbox"`
+	Inbox         bool       `form:"inbox" json:"inbox"`
+	SeriesID      *int       `form:"series_id" json:"series_id" binding:"omitempty,min=1"`
+	Priority      string     `form:"priority" json:"priority" binding:"omitempty,oneof=low medium high urgent"`
+	DueAfter      *time.Time `form:"due_after" json:"due_after"`
+	DueBefore     *time.Time `form:"due_before" json:"due_before"`
+	Due           string     `form:"due" json:"due" binding:"omitempty,oneof=overdue today,excluded_with=DueAfter DueBefore"`
+	TZ            string     `form:"tz" json:"tz" binding:"omitempty,timezone"`
+	Sort          string     `form:"sort" json:"sort" binding:"omitempty,oneof=title created_at updated_at due_at position"`
+	Order         string     `form:"order" json:"order" binding:"omitempty,oneof=asc desc"`
+	IncludeTotal  *bool      `form:"include_total" json:"include_total"`
+	Include       string     `form:"include" json:"include" binding:"omitempty,oneof=subtasks"`
+	Tag           []string   `form:"tag" json:"tag" binding:"omitempty,max=20,dive,min=1,max=50"`
+	TagMode       string     `form:"tag_mode" json:"tag_mode" binding:"omitempty,oneof=any all"`
+}
+
+// filter builds the listing filter; now anchors due=today.
+func (q ListTodosQuery) filter(now time.Time) models.TodoFilter {
+	sort := models.TodoSort(q.Sort)
+	ascending := q.Order == "asc"
+	if q.Order == "" {
+		ascending = sort == models.SortByTitle || sort == models.SortByDueAt || sort == models.SortByPosition
+	}
+
+	filter := models.TodoFilter{
+		Completed:     q.Completed,
+		CreatedAfter:  q.CreatedAfter,
+		CreatedBefore: q.CreatedBefore,
+		UpdatedAfter:  q.UpdatedAfter,
+		UpdatedBefore: q.UpdatedBefore,
+		Query:         strings.TrimSpace(q.Q),
+		ProjectID:     q.ProjectID,
+		Inbox:         q.Inbox,
+		SeriesID:      q.SeriesID,
+		DueAfter:      q.DueAfter,
+		DueBefore:     q.DueBefore,
+		Overdue:       q.Due == "overdue",
+		WithSubtasks:  q.Include == "subtasks",
+		Tags:          q.Tag,
+		AllTags:       q.TagMode == "all",
+		Sort:          sort,
+		Ascending:     ascending,
+	}
+	if q.Priority != "" {
+		priority := models.TodoPriority(q.Priority)
+		filter.Priority = &priority
+	}
+	if q.Due == "today" {
+		// tz is checked by binding, so a failed lookup means UTC
+		loc, err := time.LoadLocation(q.TZ)
+		if err != nil {
+			loc = time.UTC
+		}
+		y, m, d := now.In(loc).Date()
+		start := time.Date(y, m, d, 0, 0, 0, 0, loc)
+		end := start.AddDate(0, 0, 1)
+		filter.DueAfter, filter.DueBefore = &start, &end
+	}
+	return filter
 }
 
 func (c *TodoController) Create(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
 	req := middleware.GetBody[CreateTodoRequest](ctx)
 
-	todo, err := c.usecase.Create(ctx.Request.Context(), userID, req.Title, req.Description)
+	todo, err := c.usecase.Create(ctx.Request.Context(), userID, req.todo())
 	if reply.InternalError(ctx, err) {
 		return
 	}
 
+	ctx.Header(etag.Header, etag.Format(todo.Version))
 	reply.Created(ctx, gin.H{"todo": todo})
 }
 
 func (c *TodoController) List(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
+	c.list(ctx, userID, false)
+}
+
+// Trash lists soft-deleted todos with the same query parameters as List.
+func (c *TodoController) Trash(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	c.list(ctx, userID, true)
+}
+
+// ListForUser lists the todos of the user in :id with the same query
+// parameters as List (admin only).
+func (c *TodoController) ListForUser(ctx *gin.Context) {
+	userID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	c.list(ctx, userID, false)
+}
 
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
-	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+func (c *TodoController) list(ctx *gin.Context, userID int, trashed bool) {
+	var query ListTodosQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+	filter := query.filter(time.Now())
+	filter.Trashed = trashed
+
+	// ?cursor= takes precedence; ?offset= is kept for older clients
+	// ?include_total=false skips the count query
+	page := pagination.Page{
+		Limit:     query.Limit,
+		Offset:    query.Offset,
+		SkipTotal: query.IncludeTotal != nil && !*query.IncludeTotal,
+	}
+	if query.Cursor != "" {
+		cursor, err := c.cursors.Decode(query.Cursor)
+		if reply.InternalError(ctx, err) {
+			return
+		}
+		page.Cursor = cursor
+	}
 
-	todos, err := c.usecase.GetByUser(ctx.Request.Context(), userID, limit, offset)
+	result, err := c.usecase.GetByUser(ctx.Request.Context(), userID, filter, page)
 	if reply.InternalError(ctx, err) {
 		return
 	}
 
+	todos := result.Items
 	if todos == nil {
 		todos = []models.Todo{}
 	}
 
-	reply.OK(ctx, gin.H{"todos": todos, "count": len(todos)})
+	var next, prev string
+	if result.Next != nil {
+		next = c.cursors.Encode(*result.Next)
+	}
+	if result.Prev != nil {
+		prev = c.cursors.Encode(*result.Prev)
+	}
+	if link := pagination.LinkHeader(ctx.Request.URL, next, prev); link != "" {
+		ctx.Header("Link", link)
+	}
+
+	body := gin.H{"todos": todos, "count": len(todos)}
+	if result.Total != nil {
+		ctx.Header(pagination.TotalCountHeader, strconv.Itoa(*result.Total))
+		body["total"] = *result.Total
+	}
+	if next != "" {
+		body["next_cursor"] = next
+	}
+	if prev != "" {
+		body["prev_cursor"] = prev
+	}
+	reply.OK(ctx, body)
 }
 
 func (c *TodoController) GetByID(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
-	todoID, _ := strconv.Atoi(ctx.Param("id"))
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
 
 	todo, err := c.usecase.GetByID(ctx.Request.Context(), todoID, userID)
-	if reply.NotFound(ctx, err) {
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
+	ctx.Header(etag.Header, etag.Format(todo.Version))
+	if etag.NoneMatch(ctx.GetHeader(etag.IfNoneMatchHeader), todo.Version) {
+		reply.NotModified(ctx)
+		return
+	}
 	reply.OK(ctx, gin.H{"todo": todo})
 }
 
+// Update applies the given fields. With If-Match the update only happens if the
+// todo is still at that version; otherwise it fails with 412 Precondition Failed.
 func (c *TodoController) Update(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
-	todoID, _ := strconv.Atoi(ctx.Param("id"))
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	version, err := etag.ParseIfMatch(ctx.GetHeader(etag.IfMatchHeader))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	var query UpdateTodoQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
 	req := middleware.GetBody[UpdateTodoRequest](ctx)
 
 	todo := &models.Todo{
@@ -90,36 +369,188 @@ func (c *TodoController) Update(ctx *gin.Context) {
 		Title:       req.Title,
 		Description: req.Description,
 		Completed:   req.Completed,
+		ProjectID:   req.ProjectID,
+		DueAt:       req.DueAt,
+		Priority:    (*models.TodoPriority)(req.Priority),
+		RemindAt:    req.RemindAt,
+		Tags:        req.Tags,
+		Recurrence:  recurrence(req.RRule, req.Timezone),
+		Version:     version,
 	}
 
-	updatedTodo, err := c.usecase.Update(ctx.Request.Context(), userID, todo)
-	if reply.NotFound(ctx, err) {
+	update := c.usecase.Update
+	if query.Scope == "future" {
+		update = c.usecase.UpdateFuture
+	}
+	updatedTodo, err := update(ctx.Request.Context(), userID, todo)
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
+	ctx.Header(etag.Header, etag.Format(updatedTodo.Version))
 	reply.OK(ctx, gin.H{"todo": updatedTodo})
 }
 
+// Patch applies a JSON merge patch and honours If-Match like Update.
+func (c *TodoController) Patch(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	version, err := etag.ParseIfMatch(ctx.GetHeader(etag.IfMatchHeader))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+	req := middleware.GetBody[PatchTodoRequest](ctx)
+
+	todo, err := c.usecase.Patch(ctx.Request.Context(), userID, req.patch(todoID, version))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	ctx.Header(etag.Header, etag.Format(todo.Version))
+	reply.OK(ctx, gin.H{"todo": todo})
+}
+
 func (c *TodoController) Delete(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
-	todoID, _ := strconv.Atoi(ctx.Param("id"))
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
 
-	if err := c.usecase.Delete(ctx.Request.Context(), todoID, userID); err != nil {
-		reply.NotFound(ctx, err)
+	err = c.usecase.Delete(ctx.Request.Context(), todoID, userID)
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
-	reply.OK(ctx, gin.H{"message": "todo deleted"})
+	reply.OK(ctx, gin.H{"message": "todo moved to trash"})
 }
 
+func (c *TodoController) Restore(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	todo, err := c.usecase.Restore(ctx.Request.Context(), todoID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"todo": todo})
+}
+
+// Purge permanently deletes a todo that is already in the trash.
+func (c *TodoController) Purge(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	err = c.usecase.Purge(ctx.Request.Context(), todoID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "todo permanently deleted"})
+}
+
+// Toggle flips completed and honours If-Match like Update.
 func (c *TodoController) Toggle(ctx *gin.Context) {
 	userID, _ := middleware.GetUserID(ctx)
-	todoID, _ := strconv.Atoi(ctx.Param("id"))
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	version, err := etag.ParseIfMatch(ctx.GetHeader(etag.IfMatchHeader))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	todo, err := c.usecase.Toggle(ctx.Request.Context(), todoID, userID, version)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	ctx.Header(etag.Header, etag.Format(todo.Version))
+	reply.OK(ctx, gin.H{"todo": todo})
+}
+
+// Move reorders the caller's list. A move is not a change to the todo, so
+// its version and ETag stay the same.
+func (c *TodoController) Move(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	req := middleware.GetBody[MoveTodoRequest](ctx)
 
-	todo, err := c.usecase.Toggle(ctx.Request.Context(), todoID, userID)
-	if reply.NotFound(ctx, err) {
+	todo, err := c.usecase.Move(ctx.Request.Context(), userID, models.TodoMove{ID: todoID, BeforeID: req.BeforeID, AfterID: req.AfterID})
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
+	ctx.Header(etag.Header, etag.Format(todo.Version))
 	reply.OK(ctx, gin.H{"todo": todo})
 }
+
+// Batch applies up to 100 operations in one transaction. By default the batch is
+// atomic; ?atomic=false keeps the operations that succeeded. Responds 200 when
+// every operation succeeded and 207 with per-operation results otherwise.
+func (c *TodoController) Batch(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	req := middleware.GetBody[BatchTodoRequest](ctx)
+
+	atomic, err := strconv.ParseBool(ctx.DefaultQuery("atomic", "true"))
+	if reply.Error(ctx, http.StatusBadRequest, "atomic must be true or false", err) {
+		return
+	}
+
+	ops := make([]models.TodoBatchOp, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = models.TodoBatchOp{
+			Op: models.BatchOp(op.Op),
+			Todo: models.Todo{
+				ID:          op.ID,
+				Title:       op.Title,
+				Description: op.Description,
+				Completed:   op.Completed,
+				ProjectID:   op.ProjectID,
+				DueAt:       op.DueAt,
+				Priority:    (*models.TodoPriority)(op.Priority),
+				RemindAt:    op.RemindAt,
+				Tags:        op.Tags,
+				Version:     op.Version,
+			},
+		}
+	}
+
+	results, err := c.usecase.Batch(ctx.Request.Context(), userID, ops, atomic)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	succeeded := 0
+	for _, r := range results {
+		if r.Status == models.BatchStatusOK {
+			succeeded++
+		}
+	}
+
+	body := gin.H{
+		"results":   results,
+		"atomic":    atomic,
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+	}
+	if succeeded < len(results) {
+		reply.MultiStatus(ctx, body)
+		return
+	}
+	reply.OK(ctx, body)
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/pkg/etag"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+// ListRevisionsQuery holds the GET /todos/:id/revisions query string.
+type ListRevisionsQuery struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+// ListRevisions returns the earlier contents of a todo, newest first.
+func (c *TodoController) ListRevisions(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	var query ListRevisionsQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+
+	revisions, err := c.usecase.ListRevisions(ctx.Request.Context(), todoID, userID, pagination.Page{Limit: query.Limit, Offset: query.Offset})
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"revisions": revisions, "count": len(revisions)})
+}
+
+// GetRevision returns a revision and what has changed since.
+func (c *TodoController) GetRevision(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, revision, ok := revisionIDs(ctx)
+	if !ok {
+		return
+	}
+
+	diff, err := c.usecase.DiffRevision(ctx.Request.Context(), todoID, userID, revision)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"revision": diff.Revision, "changes": diff.Changes})
+}
+
+// RevertRevision gives the todo a revision's content, honouring If-Match.
+// The content it replaces is kept as a new revision.
+func (c *TodoController) RevertRevision(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, revision, ok := revisionIDs(ctx)
+	if !ok {
+		return
+	}
+	version, err := etag.ParseIfMatch(ctx.GetHeader(etag.IfMatchHeader))
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	todo, err := c.usecase.RevertRevision(ctx.Request.Context(), todoID, userID, revision, version)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	ctx.Header(etag.Header, etag.Format(todo.Version))
+	reply.OK(ctx, gin.H{"todo": todo})
+}
+
+// revisionIDs parses :id and :rev, replying 400 when either is malformed.
+func revisionIDs(ctx *gin.Context) (todoID, revision int, ok bool) {
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return 0, 0, false
+	}
+	revision, err = strconv.Atoi(ctx.Param("rev"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid revision format", err) {
+		return 0, 0, false
+	}
+	return todoID, revision, true
+}
@@ -0,0 +1,74 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+const defaultUpcoming = 5
+
+// SeriesQuery holds the GET /todos/series/:id query string.
+type SeriesQuery struct {
+	Upcoming *int `form:"upcoming" binding:"omitempty,min=0,max=50"`
+}
+
+// GetSeries returns a series with its next occurrences; its todos are listed
+// with GET /todos?series_id=.
+func (c *TodoController) GetSeries(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	seriesID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	var query SeriesQuery
+	if reply.Error(ctx, http.StatusBadRequest, "invalid query parameters", ctx.ShouldBindQuery(&query)) {
+		return
+	}
+	upcoming := defaultUpcoming
+	if query.Upcoming != nil {
+		upcoming = *query.Upcoming
+	}
+
+	series, err := c.usecase.GetSeries(ctx.Request.Context(), seriesID, userID, upcoming)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"series": series})
+}
+
+// DeleteSeries stops a todo from repeating; its todos stay.
+func (c *TodoController) DeleteSeries(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	seriesID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	err = c.usecase.DeleteSeries(ctx.Request.Context(), seriesID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "series deleted"})
+}
+
+// recurrence maps the rrule and timezone fields; nil when neither is given.
+func recurrence(rrule, timezone *string) *models.Recurrence {
+	if rrule == nil && timezone == nil {
+		return nil
+	}
+	rec := &models.Recurrence{}
+	if rrule != nil {
+		rec.RRule = *rrule
+	}
+	if timezone != nil {
+		rec.Timezone = *timezone
+	}
+	return rec
+}
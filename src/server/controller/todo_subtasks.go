@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+type AddSubtaskRequest struct {
+	Title string `json:"title" binding:"required,max=500"`
+}
+
+// UpdateSubtaskRequest changes the given fields of a subtask. With
+// complete_parent, completing the last open subtask completes the todo too.
+type UpdateSubtaskRequest struct {
+	Title          *string `json:"title" binding:"omitempty,max=500"`
+	Completed      *bool   `json:"completed"`
+	CompleteParent bool    `json:"complete_parent"`
+}
+
+// ReorderSubtasksRequest lists every subtask of the todo once, in the new order.
+type ReorderSubtasksRequest struct {
+	IDs []int `json:"ids" binding:"required,min=1,dive,min=1"`
+}
+
+// ListSubtasks returns the checklist of a todo; GET /todos?include=subtasks
+// nests the checklists of a whole page instead.
+func (c *TodoController) ListSubtasks(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	subtasks, err := c.usecase.ListSubtasks(ctx.Request.Context(), todoID, userID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"subtasks": subtasks, "count": len(subtasks)})
+}
+
+func (c *TodoController) AddSubtask(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	req := middleware.GetBody[AddSubtaskRequest](ctx)
+
+	subtask, err := c.usecase.AddSubtask(ctx.Request.Context(), userID, todoID, req.Title)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.Created(ctx, gin.H{"subtask": subtask})
+}
+
+func (c *TodoController) UpdateSubtask(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, subtaskID, ok := subtaskIDs(ctx)
+	if !ok {
+		return
+	}
+	req := middleware.GetBody[UpdateSubtaskRequest](ctx)
+
+	update := models.SubtaskUpdate{
+		ID:             subtaskID,
+		TodoID:         todoID,
+		Title:          req.Title,
+		Completed:      req.Completed,
+		CompleteParent: req.CompleteParent,
+	}
+
+	subtask, err := c.usecase.UpdateSubtask(ctx.Request.Context(), userID, update)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"subtask": subtask})
+}
+
+func (c *TodoController) DeleteSubtask(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, subtaskID, ok := subtaskIDs(ctx)
+	if !ok {
+		return
+	}
+
+	err := c.usecase.DeleteSubtask(ctx.Request.Context(), userID, todoID, subtaskID)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "subtask deleted"})
+}
+
+func (c *TodoController) ReorderSubtasks(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+	req := middleware.GetBody[ReorderSubtasksRequest](ctx)
+
+	subtasks, err := c.usecase.ReorderSubtasks(ctx.Request.Context(), userID, todoID, req.IDs)
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"subtasks": subtasks, "count": len(subtasks)})
+}
+
+// subtaskIDs parses :id and :subtask_id, replying 400 when either is malformed.
+func subtaskIDs(ctx *gin.Context) (todoID, subtaskID int, ok bool) {
+	todoID, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return 0, 0, false
+	}
+	subtaskID, err = strconv.Atoi(ctx.Param("subtask_id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid subtask_id format", err) {
+		return 0, 0, false
+	}
+	return todoID, subtaskID, true
+}
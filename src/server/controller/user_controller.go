@@ -6,15 +6,17 @@ import (
 	"strconv"
 
 	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/pkg/reply"
 	"github.com/fayzzzm/go-bro/usecase/users"
 	"github.com/gin-gonic/gin"
 )
 
 type UserUseCase interface {
-	RegisterUser(ctx context.Context, input users.RegisterUserInput) (*users.RegisterUserOutput, error)
 	GetUser(ctx context.Context, input users.GetUserInput) (*users.GetUserOutput, error)
 	ListUsers(ctx context.Context, input users.ListUsersInput) (*users.ListUsersOutput, error)
+	SetUserDisabled(ctx context.Context, input users.SetUserDisabledInput) (*users.SetUserDisabledOutput, error)
+	DeleteUser(ctx context.Context, input users.DeleteUserInput) error
 }
 
 type UserController struct {
@@ -31,7 +33,9 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 		return
 	}
 
-	input := users.GetUserInput{ID: id}
+	requesterID, _ := middleware.GetUserID(ctx)
+	requesterRole, _ := middleware.GetUserRole(ctx)
+	input := users.GetUserInput{ID: id, RequesterID: requesterID, RequesterRole: requesterRole}
 	output, err := c.usecase.GetUser(ctx.Request.Context(), input)
 
 	if reply.Error(ctx, http.StatusNotFound, "user not found", err) {
@@ -41,30 +45,74 @@ func (c *UserController) GetUser(ctx *gin.Context) {
 	reply.OK(ctx, output.User)
 }
 
-func (c *UserController) CreateUser(ctx *gin.Context) {
-	input := middleware.GetBody[users.RegisterUserInput](ctx)
+func (c *UserController) ListUsers(ctx *gin.Context) {
+	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	includeTotal, err := strconv.ParseBool(ctx.DefaultQuery("include_total", "true"))
+	if reply.Error(ctx, http.StatusBadRequest, "include_total must be true or false", err) {
+		return
+	}
 
-	output, err := c.usecase.RegisterUser(ctx.Request.Context(), input)
-	if reply.Error(ctx, http.StatusUnprocessableEntity, "could not create user", err) {
+	input := users.ListUsersInput{
+		Limit:     limit,
+		Offset:    offset,
+		Cursor:    ctx.Query("cursor"),
+		SkipTotal: !includeTotal,
+	}
+
+	output, err := c.usecase.ListUsers(ctx.Request.Context(), input)
+	if reply.InternalError(ctx, err) {
 		return
 	}
 
-	reply.Created(ctx, output)
+	if link := pagination.LinkHeader(ctx.Request.URL, output.NextCursor, output.PrevCursor); link != "" {
+		ctx.Header("Link", link)
+	}
+	if output.Total != nil {
+		ctx.Header(pagination.TotalCountHeader, strconv.Itoa(*output.Total))
+	}
+
+	reply.OK(ctx, output)
 }
 
-func (c *UserController) ListUsers(ctx *gin.Context) {
-	limit, _ := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
-	offset, _ := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+// DisableUser signs the user out everywhere and blocks new logins (admin only).
+func (c *UserController) DisableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, true)
+}
 
-	input := users.ListUsersInput{
-		Limit:  limit,
-		Offset: offset,
+// EnableUser lets a disabled user log in again (admin only).
+func (c *UserController) EnableUser(ctx *gin.Context) {
+	c.setDisabled(ctx, false)
+}
+
+func (c *UserController) setDisabled(ctx *gin.Context, disabled bool) {
+	actorID, _ := middleware.GetUserID(ctx)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
 	}
 
-	output, err := c.usecase.ListUsers(ctx.Request.Context(), input)
+	input := users.SetUserDisabledInput{ActorID: actorID, ID: id, Disabled: disabled}
+	output, err := c.usecase.SetUserDisabled(ctx.Request.Context(), input)
 	if reply.InternalError(ctx, err) {
 		return
 	}
 
 	reply.OK(ctx, output)
 }
+
+// DeleteUser removes a user with all their todos and sessions (admin only).
+func (c *UserController) DeleteUser(ctx *gin.Context) {
+	actorID, _ := middleware.GetUserID(ctx)
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if reply.Error(ctx, http.StatusBadRequest, "invalid id format", err) {
+		return
+	}
+
+	err = c.usecase.DeleteUser(ctx.Request.Context(), users.DeleteUserInput{ActorID: actorID, ID: id})
+	if reply.InternalError(ctx, err) {
+		return
+	}
+
+	reply.OK(ctx, gin.H{"message": "user deleted"})
+}
@@ -0,0 +1,383 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/fayzzzm/go-bro/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// MutationTimeout bounds one mutation sent over a WebSocket.
+	MutationTimeout = 10 * time.Second
+
+	// AccessCheckInterval is how often an open connection's session and
+	// channels are checked again, so a logout or an unshare takes effect.
+	AccessCheckInterval = time.Minute
+)
+
+var (
+	ErrChannel       = apperr.Validation("channel must be todo:<id>, project:<id> or list:<owner id>")
+	ErrListNotShared = apperr.NotFound("list not found")
+)
+
+// WebSocketController serves GET /ws: live, two-way editing of todos and
+// projects.
+type WebSocketController struct {
+	todos    TodoUseCase
+	projects ProjectUseCase
+	shares   ShareUseCase
+	stream   StreamUseCase
+	sessions middleware.SessionChecker
+	hub      *realtime.Hub
+	upgrader websocket.Upgrader
+}
+
+func NewWebSocketController(todos TodoUseCase, projects ProjectUseCase, shares ShareUseCase, stream StreamUseCase, sessions middleware.SessionChecker, hub *realtime.Hub) *WebSocketController {
+	return &WebSocketController{
+		todos:    todos,
+		projects: projects,
+		shares:   shares,
+		stream:   stream,
+		sessions: sessions,
+		hub:      hub,
+		// The default origin check only accepts pages served from this host,
+		// since the auth cookie is sent with cross-site upgrades too
+		upgrader: websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024},
+	}
+}
+
+// WSMessage is a frame from the client. subscribe and unsubscribe name a
+// channel: todo:<id> (viewer access), project:<id> (the caller's own) or
+// list:<owner id> (the caller's own list, or one shared with them whole).
+// create, patch, toggle, delete and move change a todo like their REST
+// counterparts; data is the REST body and version the If-Match value. The
+// reply to a frame echoes its ref.
+type WSMessage struct {
+	Type    string          `json:"type" binding:"required,oneof=subscribe unsubscribe create patch toggle delete move"`
+	Ref     string          `json:"ref" binding:"max=64"`
+	Channel string          `json:"channel"`
+	TodoID  int             `json:"todo_id" binding:"omitempty,min=1"`
+	Version int             `json:"version" binding:"omitempty,min=1"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// WSReply is a frame to the client: subscribed, unsubscribed, result (of a
+// mutation), error, or event (a change on a subscribed channel). Presence
+// frames are realtime.Presence.
+type WSReply struct {
+	Type    string            `json:"type"`
+	Ref     string            `json:"ref,omitempty"`
+	Channel string            `json:"channel,omitempty"`
+	Todo    *models.Todo      `json:"todo,omitempty"`
+	Event   *models.TodoEvent `json:"event,omitempty"`
+	Error   *reply.Problem    `json:"error,omitempty"`
+}
+
+// Connect upgrades an authenticated request and serves it until either side
+// closes. A client told to try again later (close code 1013) fell behind or
+// lost a subscription; it should reconnect and subscribe again.
+func (c *WebSocketController) Connect(ctx *gin.Context) {
+	userID, _ := middleware.GetUserID(ctx)
+	sessionID, _ := middleware.GetSessionID(ctx)
+	if !c.hub.Accepting() {
+		reply.Error(ctx, http.StatusServiceUnavailable, "server shutting down", realtime.ErrShuttingDown)
+		return
+	}
+
+	upgrader := c.upgrader
+	upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		reply.Error(ctx, status, reason.Error(), reason)
+	}
+	ws, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		return
+	}
+	conn, err := c.hub.Register(ws, userID)
+	if err != nil {
+		return
+	}
+
+	s := &wsSession{
+		ctrl:      c,
+		conn:      conn,
+		ctx:       ctx.Request.Context(),
+		requestID: middleware.GetRequestID(ctx),
+		userID:    userID,
+		sessionID: sessionID,
+		channels:  map[string]*events.Subscription{},
+	}
+	go s.watchAccess()
+	conn.Run(s.handle)
+	s.unsubscribeAll()
+}
+
+// wsSession is the state of one connection.
+type wsSession struct {
+	ctrl      *WebSocketController
+	conn      *realtime.Conn
+	ctx       context.Context
+	requestID string
+	userID    int
+	sessionID string
+
+	mu       sync.Mutex
+	channels map[string]*events.Subscription
+}
+
+func (s *wsSession) handle(data []byte) {
+	var msg WSMessage
+	if err := bindFrame(data, &msg); err != nil {
+		s.fail(msg.Ref, err)
+		return
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		if err := s.subscribe(msg.Ref, msg.Channel); err != nil {
+			s.fail(msg.Ref, err)
+		}
+	case "unsubscribe":
+		s.unsubscribe(msg.Channel)
+		s.conn.Send(WSReply{Type: "unsubscribed", Ref: msg.Ref, Channel: msg.Channel})
+	default:
+		todo, err := s.mutate(msg)
+		if err != nil {
+			s.fail(msg.Ref, err)
+			return
+		}
+		s.conn.Send(WSReply{Type: "result", Ref: msg.Ref, Todo: todo})
+	}
+}
+
+func (s *wsSession) fail(ref string, err error) {
+	problem := reply.ProblemFor(s.requestID, http.StatusInternalServerError, "internal server error", err)
+	s.conn.Send(WSReply{Type: "error", Ref: ref, Error: &problem})
+}
+
+// mutate runs msg through the same use case methods as the REST endpoints.
+// Its changes reach subscribers as events, this connection included.
+func (s *wsSession) mutate(msg WSMessage) (*models.Todo, error) {
+	if msg.Type != "create" && msg.TodoID == 0 {
+		return nil, apperr.Validation("todo_id is required")
+	}
+	ctx, cancel := context.WithTimeout(s.ctx, MutationTimeout)
+	defer cancel()
+
+	switch msg.Type {
+	case "create":
+		var req CreateTodoRequest
+		if err := bindFrame(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		return s.ctrl.todos.Create(ctx, s.userID, req.todo())
+	case "patch":
+		var req PatchTodoRequest
+		if err := bindFrame(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		return s.ctrl.todos.Patch(ctx, s.userID, req.patch(msg.TodoID, msg.Version))
+	case "toggle":
+		return s.ctrl.todos.Toggle(ctx, msg.TodoID, s.userID, msg.Version)
+	case "delete":
+		return nil, s.ctrl.todos.Delete(ctx, msg.TodoID, s.userID)
+	default:
+		var req MoveTodoRequest
+		if err := bindFrame(msg.Data, &req); err != nil {
+			return nil, err
+		}
+		return s.ctrl.todos.Move(ctx, s.userID, models.TodoMove{ID: msg.TodoID, BeforeID: req.BeforeID, AfterID: req.AfterID})
+	}
+}
+
+// subscribe checks access to channel and starts forwarding its events.
+// Events come from the stream of the owner of the channel's todos.
+func (s *wsSession) subscribe(ref, channel string) error {
+	owner, err := s.authorize(s.ctx, channel)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if _, ok := s.channels[channel]; ok {
+		s.mu.Unlock()
+		return s.conn.Send(WSReply{Type: "subscribed", Ref: ref, Channel: channel})
+	}
+	sub := s.ctrl.stream.Subscribe(owner)
+	s.channels[channel] = sub
+	s.mu.Unlock()
+
+	go s.forward(channel, sub)
+	s.conn.Send(WSReply{Type: "subscribed", Ref: ref, Channel: channel})
+	s.ctrl.hub.Join(s.conn, channel)
+	return nil
+}
+
+func (s *wsSession) unsubscribe(channel string) {
+	s.mu.Lock()
+	sub := s.channels[channel]
+	delete(s.channels, channel)
+	s.mu.Unlock()
+
+	if sub != nil {
+		sub.Close()
+		s.ctrl.hub.Leave(s.conn, channel)
+	}
+}
+
+func (s *wsSession) unsubscribeAll() {
+	s.mu.Lock()
+	subs := s.channels
+	s.channels = map[string]*events.Subscription{}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.Close()
+	}
+}
+
+// forward sends the channel's events until the subscription closes. If the
+// bus closed it (this connection fell behind, or shutdown) the client has
+// missed events and is asked to reconnect.
+func (s *wsSession) forward(channel string, sub *events.Subscription) {
+	kind, id, _ := parseChannel(channel)
+	for event := range sub.C {
+		if !inChannel(kind, id, event) {
+			continue
+		}
+		s.conn.Send(WSReply{Type: "event", Channel: channel, Event: &event})
+	}
+
+	s.mu.Lock()
+	current := s.channels[channel] == sub
+	s.mu.Unlock()
+	if current {
+		s.conn.Close(websocket.CloseTryAgainLater, "subscription lost")
+	}
+}
+
+// watchAccess closes the connection once its session is revoked and drops
+// channels the user can no longer see.
+func (s *wsSession) watchAccess() {
+	ticker := time.NewTicker(AccessCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.conn.Closing():
+			return
+		case <-ticker.C:
+			s.checkAccess()
+		}
+	}
+}
+
+func (s *wsSession) checkAccess() {
+	ctx, cancel := context.WithTimeout(s.ctx, MutationTimeout)
+	defer cancel()
+
+	active, err := s.ctrl.sessions.IsSessionActive(ctx, s.sessionID)
+	if err == nil && !active {
+		s.conn.Close(websocket.ClosePolicyViolation, "session revoked")
+		return
+	}
+
+	s.mu.Lock()
+	channels := make([]string, 0, len(s.channels))
+	for channel := range s.channels {
+		channels = append(channels, channel)
+	}
+	s.mu.Unlock()
+
+	for _, channel := range channels {
+		_, err := s.authorize(ctx, channel)
+		if kind := apperr.KindOf(err); kind == apperr.KindNotFound || kind == apperr.KindForbidden {
+			s.unsubscribe(channel)
+			problem := reply.ProblemFor(s.requestID, http.StatusForbidden, "access revoked", err)
+			s.conn.Send(WSReply{Type: "unsubscribed", Channel: channel, Error: &problem})
+		}
+	}
+}
+
+// authorize returns the owner of the channel's todos if the user may view it.
+func (s *wsSession) authorize(ctx context.Context, channel string) (int, error) {
+	kind, id, err := parseChannel(channel)
+	if err != nil {
+		return 0, err
+	}
+	switch kind {
+	case "todo":
+		todo, err := s.ctrl.todos.GetByID(ctx, id, s.userID)
+		if err != nil {
+			return 0, err
+		}
+		return todo.UserID, nil
+	case "list":
+		if id == s.userID {
+			return id, nil
+		}
+		shares, err := s.ctrl.shares.ListShares(ctx, id, nil)
+		if err != nil {
+			return 0, err
+		}
+		for _, share := range shares {
+			if share.UserID == s.userID {
+				return id, nil
+			}
+		}
+		return 0, ErrListNotShared
+	}
+	if _, err := s.ctrl.projects.GetByID(ctx, id, s.userID); err != nil {
+		return 0, err
+	}
+	return s.userID, nil
+}
+
+// parseChannel splits "todo:5" into "todo" and 5.
+func parseChannel(channel string) (string, int, error) {
+	kind, raw, _ := strings.Cut(channel, ":")
+	id, err := strconv.Atoi(raw)
+	if (kind != "todo" && kind != "project" && kind != "list") || err != nil || id < 1 {
+		return "", 0, ErrChannel
+	}
+	return kind, id, nil
+}
+
+// inChannel reports whether event belongs on the channel. A list channel gets
+// all of its owner's events. A deleted todo carries no project, so project
+// channels get every deletion of the owner's.
+func inChannel(kind string, id int, event models.TodoEvent) bool {
+	switch kind {
+	case "todo":
+		return event.TodoID == id
+	case "list":
+		return true
+	}
+	if event.Todo == nil {
+		return event.Type == models.TodoDeleted
+	}
+	return event.Todo.ProjectID != nil && *event.Todo.ProjectID == id
+}
+
+// bindFrame decodes and validates JSON like BindJSON does for request bodies.
+func bindFrame(data []byte, v any) error {
+	if len(data) == 0 {
+		data = []byte("{}")
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(v)
+}
@@ -0,0 +1,194 @@
+// Package events fans todo changes out to the streams their owners keep open.
+// Changes are published to a Store shared by every server (Postgres, with
+// LISTEN/NOTIFY); each server's Bus listens to it and delivers events to its
+// own subscribers, so a change made through one replica reaches streams held
+// by all of them.
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+)
+
+const (
+	// SubscriptionBuffer is how many events a subscriber may fall behind
+	// before it is dropped; it can resume from the last event it saw.
+	SubscriptionBuffer = 64
+
+	// ReplayLimit caps how many missed events one resume returns.
+	ReplayLimit = 1000
+
+	listenRetry = 5 * time.Second
+)
+
+// Store is implemented by postgres.EventRepo.
+type Store interface {
+	Publish(ctx context.Context, event models.TodoEvent) (*models.TodoEvent, error)
+	Get(ctx context.Context, id int64) (*models.TodoEvent, error)
+	Since(ctx context.Context, userID int, afterID int64, limit int) ([]models.TodoEvent, error)
+	Listen(ctx context.Context, handle func(id int64, userID int)) error
+}
+
+// Bus publishes todo events and delivers them to local subscribers.
+type Bus struct {
+	store Store
+
+	mu     sync.Mutex
+	subs   map[int]map[*Subscription]struct{}
+	closed bool
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewBus(store Store) *Bus {
+	return &Bus{store: store, subs: map[int]map[*Subscription]struct{}{}}
+}
+
+// Subscription receives the events of one user until it is closed, by the
+// subscriber or by the bus when the subscriber falls behind or the bus stops.
+type Subscription struct {
+	C <-chan models.TodoEvent
+
+	bus    *Bus
+	userID int
+	ch     chan models.TodoEvent
+	once   sync.Once
+}
+
+// Close stops delivery and closes C; it is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.unsubscribe(s)
+}
+
+// Publish stores an event for every server to deliver, this one included.
+func (b *Bus) Publish(ctx context.Context, event models.TodoEvent) error {
+	_, err := b.store.Publish(ctx, event)
+	return err
+}
+
+// Subscribe starts delivering the user's events. On a stopped bus the
+// subscription is closed right away.
+func (b *Bus) Subscribe(userID int) *Subscription {
+	ch := make(chan models.TodoEvent, SubscriptionBuffer)
+	sub := &Subscription{C: ch, bus: b, userID: userID, ch: ch}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		sub.once.Do(func() { close(ch) })
+		return sub
+	}
+	if b.subs[userID] == nil {
+		b.subs[userID] = map[*Subscription]struct{}{}
+	}
+	b.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// Replay returns the user's events after afterID, oldest first, up to ReplayLimit.
+func (b *Bus) Replay(ctx context.Context, userID int, afterID int64) ([]models.TodoEvent, error) {
+	return b.store.Since(ctx, userID, afterID, ReplayLimit)
+}
+
+// Start listens to the store until Stop is called, reconnecting after failures.
+func (b *Bus) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	b.cancel = cancel
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		for {
+			err := b.store.Listen(ctx, func(id int64, userID int) { b.deliver(ctx, id, userID) })
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("⚠️ Warning: event listener stopped, retrying in %s: %v", listenRetry, err)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(listenRetry):
+			}
+		}
+	}()
+}
+
+// Stop closes every subscription, ending their streams, then stops listening
+// and waits for the listener to exit or ctx to expire.
+func (b *Bus) Stop(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	var subs []*Subscription
+	for _, set := range b.subs {
+		for sub := range set {
+			subs = append(subs, sub)
+		}
+	}
+	b.mu.Unlock()
+	for _, sub := range subs {
+		sub.Close()
+	}
+
+	if b.cancel == nil {
+		return nil
+	}
+	b.cancel()
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver fetches an event, only if someone here is subscribed to its user,
+// and hands it to each of them. A subscriber whose buffer is full is dropped.
+func (b *Bus) deliver(ctx context.Context, id int64, userID int) {
+	if !b.hasSubscribers(userID) {
+		return
+	}
+	event, err := b.store.Get(ctx, id)
+	if err != nil {
+		log.Printf("⚠️ Warning: event %d could not be loaded: %v", id, err)
+		return
+	}
+
+	var lagging []*Subscription
+	b.mu.Lock()
+	for sub := range b.subs[userID] {
+		select {
+		case sub.ch <- *event:
+		default:
+			lagging = append(lagging, sub)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, sub := range lagging {
+		sub.Close()
+	}
+}
+
+func (b *Bus) hasSubscribers(userID int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[userID]) > 0
+}
+
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if set := b.subs[sub.userID]; set != nil {
+		delete(set, sub)
+		if len(set) == 0 {
+			delete(b.subs, sub.userID)
+		}
+	}
+	sub.once.Do(func() { close(sub.ch) })
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/models"
+)
+
+type notification struct {
+	id     int64
+	userID int
+}
+
+// MemoryStore keeps events in memory and notifies through a channel, like
+// LISTEN/NOTIFY would
+type MemoryStore struct {
+	mu     sync.Mutex
+	events []models.TodoEvent
+	notify chan notification
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{notify: make(chan notification)}
+}
+
+func (m *MemoryStore) Publish(ctx context.Context, event models.TodoEvent) (*models.TodoEvent, error) {
+	m.mu.Lock()
+	event.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, event)
+	m.mu.Unlock()
+	m.notify <- notification{id: event.ID, userID: event.UserID}
+	return &event, nil
+}
+
+func (m *MemoryStore) Get(ctx context.Context, id int64) (*models.TodoEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	event := m.events[id-1]
+	return &event, nil
+}
+
+func (m *MemoryStore) Since(ctx context.Context, userID int, afterID int64, limit int) ([]models.TodoEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []models.TodoEvent
+	for _, e := range m.events {
+		if e.UserID == userID && e.ID > afterID && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func (m *MemoryStore) Listen(ctx context.Context, handle func(id int64, userID int)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case n := <-m.notify:
+			handle(n.id, n.userID)
+		}
+	}
+}
+
+func startBus(t *testing.T) (*events.Bus, *MemoryStore) {
+	store := NewMemoryStore()
+	bus := events.NewBus(store)
+	bus.Start()
+	t.Cleanup(func() { bus.Stop(context.Background()) })
+	return bus, store
+}
+
+func receive(t *testing.T, sub *events.Subscription) (models.TodoEvent, bool) {
+	t.Helper()
+	select {
+	case event, ok := <-sub.C:
+		return event, ok
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event or a closed subscription")
+		return models.TodoEvent{}, false
+	}
+}
+
+func TestBus_DeliversToTheUsersSubscribers(t *testing.T) {
+	bus, _ := startBus(t)
+	alice := bus.Subscribe(1)
+	bob := bus.Subscribe(2)
+	defer alice.Close()
+	defer bob.Close()
+
+	ctx := context.Background()
+	if err := bus.Publish(ctx, models.TodoEvent{UserID: 2, Type: models.TodoCreated, TodoID: 7}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	if err := bus.Publish(ctx, models.TodoEvent{UserID: 1, Type: models.TodoToggled, TodoID: 3}); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+
+	if event, _ := receive(t, bob); event.ID != 1 || event.TodoID != 7 {
+		t.Errorf("Expected bob to get event 1 for todo 7, got %+v", event)
+	}
+	if event, _ := receive(t, alice); event.ID != 2 || event.Type != models.TodoToggled {
+		t.Errorf("Expected alice to get only her toggled event 2, got %+v", event)
+	}
+}
+
+func TestBus_DropsLaggingSubscriber(t *testing.T) {
+	bus, _ := startBus(t)
+	slow := bus.Subscribe(1)
+
+	for i := 0; i <= events.SubscriptionBuffer; i++ {
+		bus.Publish(context.Background(), models.TodoEvent{UserID: 1, Type: models.TodoUpdated, TodoID: 1})
+	}
+
+	received := 0
+	for {
+		if _, ok := receive(t, slow); !ok {
+			break
+		}
+		received++
+	}
+	if received != events.SubscriptionBuffer {
+		t.Errorf("Expected %d buffered events before the drop, got %d", events.SubscriptionBuffer, received)
+	}
+}
+
+func TestBus_Replay(t *testing.T) {
+	bus, _ := startBus(t)
+	for _, userID := range []int{1, 2, 1, 1} {
+		bus.Publish(context.Background(), models.TodoEvent{UserID: userID, Type: models.TodoUpdated, TodoID: 1})
+	}
+
+	missed, err := bus.Replay(context.Background(), 1, 1)
+	if err != nil {
+		t.Fatalf("Replay failed: %v", err)
+	}
+	if len(missed) != 2 || missed[0].ID != 3 || missed[1].ID != 4 {
+		t.Errorf("Expected events 3 and 4, got %+v", missed)
+	}
+}
+
+func TestBus_StopClosesSubscriptions(t *testing.T) {
+	bus := events.NewBus(NewMemoryStore())
+	bus.Start()
+	sub := bus.Subscribe(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := bus.Stop(ctx); err != nil {
+		t.Fatalf("Expected a clean stop, got %v", err)
+	}
+
+	if _, ok := receive(t, sub); ok {
+		t.Error("Expected the subscription to be closed")
+	}
+	if _, ok := receive(t, bus.Subscribe(1)); ok {
+		t.Error("Expected a subscription on a stopped bus to be closed")
+	}
+	sub.Close()
+}
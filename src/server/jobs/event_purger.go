@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	DefaultEventRetention     = 24 * time.Hour
+	DefaultEventPurgeInterval = time.Hour
+)
+
+// EventRepository is the output port the event purger needs.
+type EventRepository interface {
+	Prune(ctx context.Context, before time.Time) (int, error)
+}
+
+// EventPurger deletes todo events older than the retention. A stream resuming
+// after a longer gap misses them and should reload its todos.
+type EventPurger struct {
+	repo      EventRepository
+	retention time.Duration
+	interval  time.Duration
+	loop      loop
+}
+
+func NewEventPurger(repo EventRepository, retention, interval time.Duration) *EventPurger {
+	return &EventPurger{repo: repo, retention: retention, interval: interval}
+}
+
+// NewEventPurgerFromEnv reads EVENT_RETENTION (default 24h) and
+// EVENT_PURGE_INTERVAL (default 1h) as Go durations.
+func NewEventPurgerFromEnv(repo EventRepository) (*EventPurger, error) {
+	retention, err := durationFromEnv("EVENT_RETENTION", DefaultEventRetention)
+	if err != nil {
+		return nil, err
+	}
+	if retention <= 0 {
+		return nil, fmt.Errorf("EVENT_RETENTION must be positive, got %s", retention)
+	}
+	interval, err := durationFromEnv("EVENT_PURGE_INTERVAL", DefaultEventPurgeInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("EVENT_PURGE_INTERVAL must be positive, got %s", interval)
+	}
+	return NewEventPurger(repo, retention, interval), nil
+}
+
+// PurgeOnce deletes every event created before now minus the retention.
+func (p *EventPurger) PurgeOnce(ctx context.Context) (int, error) {
+	return p.repo.Prune(ctx, time.Now().Add(-p.retention))
+}
+
+// Start purges right away and then on every interval until Stop is called.
+func (p *EventPurger) Start() {
+	p.loop.start(p.interval, func(ctx context.Context) {
+		if n, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Warning: event purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("📡 Purged %d todo events older than %s", n, p.retention)
+		}
+	})
+}
+
+// Stop cancels a running purge and waits for the loop to exit or ctx to expire.
+func (p *EventPurger) Stop(ctx context.Context) error {
+	return p.loop.stop(ctx)
+}
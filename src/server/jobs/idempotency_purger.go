@@ -0,0 +1,55 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const DefaultIdempotencyPurgeInterval = time.Hour
+
+// IdempotencyRepository is the output port the key purger needs.
+type IdempotencyRepository interface {
+	PurgeExpired(ctx context.Context) (int, error)
+}
+
+// IdempotencyPurger deletes Idempotency-Key records past their TTL. Expired keys
+// are already ignored on lookup; this only keeps the table from growing.
+type IdempotencyPurger struct {
+	repo     IdempotencyRepository
+	interval time.Duration
+	loop     loop
+}
+
+func NewIdempotencyPurger(repo IdempotencyRepository, interval time.Duration) *IdempotencyPurger {
+	return &IdempotencyPurger{repo: repo, interval: interval}
+}
+
+// NewIdempotencyPurgerFromEnv reads IDEMPOTENCY_PURGE_INTERVAL as a Go duration (default 1h).
+func NewIdempotencyPurgerFromEnv(repo IdempotencyRepository) (*IdempotencyPurger, error) {
+	interval, err := durationFromEnv("IDEMPOTENCY_PURGE_INTERVAL", DefaultIdempotencyPurgeInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("IDEMPOTENCY_PURGE_INTERVAL must be positive, got %s", interval)
+	}
+	return NewIdempotencyPurger(repo, interval), nil
+}
+
+// Start purges right away and then on every interval until Stop is called.
+func (p *IdempotencyPurger) Start() {
+	p.loop.start(p.interval, func(ctx context.Context) {
+		if n, err := p.repo.PurgeExpired(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Warning: idempotency key purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("🔑 Purged %d expired idempotency keys", n)
+		}
+	})
+}
+
+// Stop cancels a running purge and waits for the loop to exit or ctx to expire.
+func (p *IdempotencyPurger) Stop(ctx context.Context) error {
+	return p.loop.stop(ctx)
+}
@@ -0,0 +1,125 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+)
+
+const (
+	DefaultReminderInterval = time.Minute
+	reminderBatchSize       = 100
+)
+
+// ReminderRepository is the output port the scheduler needs.
+type ReminderRepository interface {
+	ClaimReminders(ctx context.Context, before time.Time, limit int) ([]models.Reminder, error)
+	ReleaseReminder(ctx context.Context, reminder models.Reminder) error
+}
+
+// ReminderNotifier delivers a reminder once it comes due.
+type ReminderNotifier interface {
+	Notify(ctx context.Context, reminder models.Reminder) error
+}
+
+// EventPublisher is the output port EventNotifier needs; implemented by events.Bus.
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.TodoEvent) error
+}
+
+// EventNotifier publishes a reminder event to the todo's owner, which reaches
+// their open streams and WebSocket channels.
+type EventNotifier struct {
+	events EventPublisher
+}
+
+func NewEventNotifier(events EventPublisher) *EventNotifier {
+	return &EventNotifier{events: events}
+}
+
+func (n *EventNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	return n.events.Publish(ctx, models.TodoEvent{
+		UserID: reminder.UserID,
+		Type:   models.TodoReminder,
+		TodoID: reminder.TodoID,
+	})
+}
+
+// ReminderScheduler hands due reminders to the notifier. Reminders are marked
+// sent when claimed, so only one server delivers each; one that fails is
+// released at the end of the pass and retried by the next.
+type ReminderScheduler struct {
+	repo     ReminderRepository
+	notifier ReminderNotifier
+	interval time.Duration
+	loop     loop
+}
+
+func NewReminderScheduler(repo ReminderRepository, notifier ReminderNotifier, interval time.Duration) *ReminderScheduler {
+	return &ReminderScheduler{repo: repo, notifier: notifier, interval: interval}
+}
+
+// NewReminderSchedulerFromEnv reads REMINDER_INTERVAL as a Go duration (default 1m).
+func NewReminderSchedulerFromEnv(repo ReminderRepository, notifier ReminderNotifier) (*ReminderScheduler, error) {
+	interval, err := durationFromEnv("REMINDER_INTERVAL", DefaultReminderInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("REMINDER_INTERVAL must be positive, got %s", interval)
+	}
+	return NewReminderScheduler(repo, notifier, interval), nil
+}
+
+// RunOnce claims every reminder due by now, batch by batch, and notifies each.
+// Failed notifications are logged and released once the pass is over, so this
+// pass does not claim them again but the next one does.
+func (s *ReminderScheduler) RunOnce(ctx context.Context) (int, error) {
+	sent := 0
+	var failed []models.Reminder
+	defer func() { s.release(context.WithoutCancel(ctx), failed) }()
+
+	for {
+		reminders, err := s.repo.ClaimReminders(ctx, time.Now(), reminderBatchSize)
+		if err != nil {
+			return sent, err
+		}
+		for _, reminder := range reminders {
+			if err := s.notifier.Notify(ctx, reminder); err != nil {
+				log.Printf("⚠️ Warning: reminder for todo %d failed: %v", reminder.TodoID, err)
+				failed = append(failed, reminder)
+				continue
+			}
+			sent++
+		}
+		if len(reminders) < reminderBatchSize {
+			return sent, nil
+		}
+	}
+}
+
+// release hands failed reminders back; ctx outlives a cancelled pass.
+func (s *ReminderScheduler) release(ctx context.Context, reminders []models.Reminder) {
+	for _, reminder := range reminders {
+		if err := s.repo.ReleaseReminder(ctx, reminder); err != nil {
+			log.Printf("⚠️ Warning: could not release reminder for todo %d: %v", reminder.TodoID, err)
+		}
+	}
+}
+
+// Start sends due reminders right away and then on every interval until Stop is called.
+func (s *ReminderScheduler) Start() {
+	s.loop.start(s.interval, func(ctx context.Context) {
+		if _, err := s.RunOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Warning: reminder run failed: %v", err)
+		}
+	})
+}
+
+// Stop cancels a running pass and waits for the loop to exit or ctx to expire.
+func (s *ReminderScheduler) Stop(ctx context.Context) error {
+	return s.loop.stop(ctx)
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/jobs"
+	"github.com/fayzzzm/go-bro/models"
+)
+
+// MockReminderRepo hands out queued batches, one per claim, and records releases
+type MockReminderRepo struct {
+	batches  [][]models.Reminder
+	claims   int
+	released []int
+	err      error
+}
+
+func (m *MockReminderRepo) ClaimReminders(ctx context.Context, before time.Time, limit int) ([]models.Reminder, error) {
+	m.claims++
+	if m.err != nil {
+		return nil, m.err
+	}
+	if len(m.batches) == 0 {
+		return nil, nil
+	}
+	batch := m.batches[0]
+	m.batches = m.batches[1:]
+	return batch, nil
+}
+
+// MockNotifier records delivered reminders and fails for the listed todos
+type MockNotifier struct {
+	sent []int
+	fail map[int]bool
+}
+
+func (m *MockNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	if m.fail[reminder.TodoID] {
+		return errors.New("mailbox full")
+	}
+	m.sent = append(m.sent, reminder.TodoID)
+	return nil
+}
+
+func (m *MockReminderRepo) ReleaseReminder(ctx context.Context, reminder models.Reminder) error {
+	m.released = append(m.released, reminder.TodoID)
+	return nil
+}
+
+func reminders(from, n int) []models.Reminder {
+	out := make([]models.Reminder, n)
+	for i := range out {
+		out[i] = models.Reminder{TodoID: from + i, UserID: 1, Title: "todo"}
+	}
+	return out
+}
+
+func TestReminderScheduler_RunOnceDrainsFullBatches(t *testing.T) {
+	repo := &MockReminderRepo{batches: [][]models.Reminder{reminders(1, 100), reminders(101, 3)}}
+	notifier := &MockNotifier{fail: map[int]bool{2: true}}
+	scheduler := jobs.NewReminderScheduler(repo, notifier, time.Minute)
+
+	sent, err := scheduler.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce failed: %v", err)
+	}
+	if repo.claims != 2 {
+		t.Errorf("Expected 2 claims, got %d", repo.claims)
+	}
+	if sent != 102 || len(notifier.sent) != 102 {
+		t.Errorf("Expected 102 reminders sent, got %d (%d notified)", sent, len(notifier.sent))
+	}
+	if len(repo.released) != 1 || repo.released[0] != 2 {
+		t.Errorf("Expected the failed reminder for todo 2 to be released, got %v", repo.released)
+	}
+}
+
+func TestReminderScheduler_RunOnceReturnsClaimError(t *testing.T) {
+	repo := &MockReminderRepo{err: errors.New("db down")}
+	scheduler := jobs.NewReminderScheduler(repo, &MockNotifier{}, time.Minute)
+
+	if _, err := scheduler.RunOnce(context.Background()); err == nil {
+		t.Error("Expected the claim error")
+	}
+}
+
+func TestNewReminderSchedulerFromEnv_RejectsBadInterval(t *testing.T) {
+	t.Setenv("REMINDER_INTERVAL", "0s")
+	if _, err := jobs.NewReminderSchedulerFromEnv(&MockReminderRepo{}, &MockNotifier{}); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
+
+// MockEventPublisher records published events
+type MockEventPublisher struct {
+	published []models.TodoEvent
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, event models.TodoEvent) error {
+	m.published = append(m.published, event)
+	return nil
+}
+
+func TestEventNotifier_PublishesToOwner(t *testing.T) {
+	publisher := &MockEventPublisher{}
+	notifier := jobs.NewEventNotifier(publisher)
+
+	if err := notifier.Notify(context.Background(), models.Reminder{TodoID: 7, UserID: 3, Title: "todo"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	want := models.TodoEvent{UserID: 3, Type: models.TodoReminder, TodoID: 7}
+	if len(publisher.published) != 1 || publisher.published[0] != want {
+		t.Errorf("Expected a reminder event for user 3, got %+v", publisher.published)
+	}
+}
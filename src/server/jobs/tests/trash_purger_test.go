@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/jobs"
+)
+
+// MockTrashRepo records the cut-off of every purge
+type MockTrashRepo struct {
+	mu      sync.Mutex
+	cutoffs []time.Time
+	called  chan struct{}
+}
+
+func (m *MockTrashRepo) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
+	m.mu.Lock()
+	m.cutoffs = append(m.cutoffs, before)
+	m.mu.Unlock()
+	if m.called != nil {
+		select {
+		case m.called <- struct{}{}:
+		default:
+		}
+	}
+	return 1, nil
+}
+
+func TestTrashPurger_PurgeOnceUsesRetention(t *testing.T) {
+	repo := &MockTrashRepo{}
+	purger := jobs.NewTrashPurger(repo, 48*time.Hour, time.Hour)
+
+	if _, err := purger.PurgeOnce(context.Background()); err != nil {
+		t.Fatalf("PurgeOnce failed: %v", err)
+	}
+
+	want := time.Now().Add(-48 * time.Hour)
+	if got := repo.cutoffs[0]; got.Sub(want).Abs() > time.Second {
+		t.Errorf("Expected cut-off around %v, got %v", want, got)
+	}
+}
+
+func TestTrashPurger_StartStop(t *testing.T) {
+	repo := &MockTrashRepo{called: make(chan struct{}, 1)}
+	purger := jobs.NewTrashPurger(repo, time.Hour, time.Hour)
+
+	purger.Start()
+	select {
+	case <-repo.called:
+	case <-time.After(time.Second):
+		t.Fatal("Expected a purge right after Start")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := purger.Stop(ctx); err != nil {
+		t.Errorf("Expected a clean stop, got %v", err)
+	}
+}
+
+func TestTrashPurger_ZeroRetentionDisables(t *testing.T) {
+	repo := &MockTrashRepo{}
+	purger := jobs.NewTrashPurger(repo, 0, time.Hour)
+
+	purger.Start()
+	if err := purger.Stop(context.Background()); err != nil {
+		t.Errorf("Expected Stop to be a no-op, got %v", err)
+	}
+	if len(repo.cutoffs) != 0 {
+		t.Errorf("Expected no purge with retention disabled, got %d", len(repo.cutoffs))
+	}
+}
+
+func TestNewTrashPurgerFromEnv_RejectsBadDurations(t *testing.T) {
+	t.Setenv("TRASH_RETENTION", "a month")
+	if _, err := jobs.NewTrashPurgerFromEnv(&MockTrashRepo{}); err == nil {
+		t.Error("Expected an error for an unparsable retention")
+	}
+
+	t.Setenv("TRASH_RETENTION", "")
+	t.Setenv("TRASH_PURGE_INTERVAL", "0s")
+	if _, err := jobs.NewTrashPurgerFromEnv(&MockTrashRepo{}); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
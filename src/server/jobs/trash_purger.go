@@ -0,0 +1,88 @@
+// Package jobs holds background work that runs for the lifetime of the server.
+// Each job exposes Start and Stop so main.go can tie it to fx.Lifecycle.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	DefaultTrashRetention     = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval = time.Hour
+)
+
+// TrashRepository is the output port the purger needs.
+type TrashRepository interface {
+	PurgeExpired(ctx context.Context, before time.Time) (int, error)
+}
+
+// TrashPurger permanently deletes todos that have sat in the trash longer than the retention.
+type TrashPurger struct {
+	repo      TrashRepository
+	retention time.Duration
+	interval  time.Duration
+	loop      loop
+}
+
+func NewTrashPurger(repo TrashRepository, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{repo: repo, retention: retention, interval: interval}
+}
+
+// NewTrashPurgerFromEnv reads TRASH_RETENTION and TRASH_PURGE_INTERVAL as Go durations
+// (e.g. "720h"). A retention of 0 keeps trashed todos forever.
+func NewTrashPurgerFromEnv(repo TrashRepository) (*TrashPurger, error) {
+	retention, err := durationFromEnv("TRASH_RETENTION", DefaultTrashRetention)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := durationFromEnv("TRASH_PURGE_INTERVAL", DefaultTrashPurgeInterval)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("TRASH_PURGE_INTERVAL must be positive, got %s", interval)
+	}
+	return NewTrashPurger(repo, retention, interval), nil
+}
+
+// PurgeOnce deletes everything trashed before now minus the retention.
+func (p *TrashPurger) PurgeOnce(ctx context.Context) (int, error) {
+	return p.repo.PurgeExpired(ctx, time.Now().Add(-p.retention))
+}
+
+// Start purges right away and then on every interval until Stop is called.
+func (p *TrashPurger) Start() {
+	if p.retention <= 0 {
+		log.Println("🗑️ Trash retention disabled, trashed todos are kept until purged by hand")
+		return
+	}
+
+	p.loop.start(p.interval, func(ctx context.Context) {
+		if n, err := p.PurgeOnce(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("⚠️ Warning: trash purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("🗑️ Purged %d todos trashed more than %s ago", n, p.retention)
+		}
+	})
+}
+
+// Stop cancels a running purge and waits for the loop to exit or ctx to expire.
+func (p *TrashPurger) Stop(ctx context.Context) error {
+	return p.loop.stop(ctx)
+}
+
+func durationFromEnv(key string, fallback time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return d, nil
+}
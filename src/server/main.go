@@ -2,11 +2,22 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/events"
+	"github.com/fayzzzm/go-bro/jobs"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/migrations"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/auth"
+	"github.com/fayzzzm/go-bro/pkg/migrate"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/fayzzzm/go-bro/realtime"
 	"github.com/fayzzzm/go-bro/repository/postgres"
 	"github.com/fayzzzm/go-bro/routes"
 	"github.com/fayzzzm/go-bro/service"
@@ -18,10 +29,24 @@ import (
 )
 
 func main() {
+	// `go run main.go migrate up|down|status` manages the schema without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate.Command(context.Background(), databaseURL(), migrations.FS, os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	fx.New(
 		fx.Provide(
-			// 1. Database Pool
+			// 1. Database Pool, signing keys and cursor codec
 			NewDatabasePool,
+			auth.NewKeyProviderFromEnv,
+			pagination.NewCodecFromEnv,
+			fx.Annotate(
+				auth.NewTokenManager,
+				fx.As(new(service.TokenIssuer), new(middleware.TokenValidator), new(controller.KeySetProvider)),
+			),
 
 			// 2. Repositories (Adapters)
 			fx.Annotate(
@@ -34,9 +59,40 @@ func main() {
 			),
 			fx.Annotate(
 				postgres.NewTodoRepo,
-				fx.As(new(service.TodoRepository)),
+				fx.As(new(service.TodoRepository), new(jobs.TrashRepository), new(jobs.ReminderRepository)),
+			),
+			fx.Annotate(
+				postgres.NewShareRepo,
+				fx.As(new(service.ShareRepository)),
+			),
+			fx.Annotate(
+				postgres.NewProjectRepo,
+				fx.As(new(service.ProjectRepository)),
+			),
+			fx.Annotate(
+				postgres.NewTagRepo,
+				fx.As(new(service.TagRepository)),
+			),
+			fx.Annotate(
+				postgres.NewAuditRepo,
+				fx.As(new(service.AuditRepository)),
+			),
+			fx.Annotate(
+				postgres.NewIdempotencyRepo,
+				fx.As(new(middleware.IdempotencyStore), new(jobs.IdempotencyRepository)),
+			),
+			fx.Annotate(
+				postgres.NewEventRepo,
+				fx.As(new(events.Store), new(jobs.EventRepository)),
 			),
 
+			// Todo events, shared by every replica through the database
+			events.NewBus,
+			func(bus *events.Bus) service.EventPublisher { return bus },
+			func(bus *events.Bus) controller.StreamUseCase { return bus },
+			func(bus *events.Bus) jobs.EventPublisher { return bus },
+			realtime.NewHub,
+
 			// 3. Services (Core)
 			fx.Annotate(
 				service.NewUserService,
@@ -44,12 +100,28 @@ func main() {
 			),
 			fx.Annotate(
 				service.NewAuthService,
-				fx.As(new(controller.AuthUseCase)),
+				fx.As(new(controller.AuthUseCase), new(middleware.SessionChecker)),
 			),
 			fx.Annotate(
 				service.NewTodoService,
 				fx.As(new(controller.TodoUseCase)),
 			),
+			fx.Annotate(
+				service.NewShareService,
+				fx.As(new(controller.ShareUseCase)),
+			),
+			fx.Annotate(
+				service.NewProjectService,
+				fx.As(new(controller.ProjectUseCase)),
+			),
+			fx.Annotate(
+				service.NewTagService,
+				fx.As(new(controller.TagUseCase)),
+			),
+			fx.Annotate(
+				service.NewAuditService,
+				fx.As(new(controller.AuditUseCase)),
+			),
 
 			// 4. Use Cases
 			fx.Annotate(
@@ -61,13 +133,32 @@ func main() {
 			controller.NewUserController,
 			controller.NewAuthController,
 			controller.NewTodoController,
+			controller.NewShareController,
+			controller.NewProjectController,
+			controller.NewTagController,
+			controller.NewAuditController,
+			controller.NewKeysController,
+			controller.NewStreamController,
+			controller.NewWebSocketController,
 
 			// 6. Framework (Gin)
 			NewGinEngine,
+			middleware.NewIdempotencyFromEnv,
+
+			// 7. Background jobs
+			jobs.NewTrashPurgerFromEnv,
+			jobs.NewIdempotencyPurgerFromEnv,
+			jobs.NewReminderSchedulerFromEnv,
+			jobs.NewEventPurgerFromEnv,
+			fx.Annotate(
+				jobs.NewEventNotifier,
+				fx.As(new(jobs.ReminderNotifier)),
+			),
 		),
 		fx.Invoke(
-			// 7. Setup Routes and start server
+			// 8. Setup Routes, start server and background jobs
 			RegisterRoutes,
+			RegisterJobs,
 		),
 	).Run()
 }
@@ -76,11 +167,15 @@ func main() {
 func NewDatabasePool(lc fx.Lifecycle) (*pgxpool.Pool, error) {
 	ctx := context.Background()
 
-	// Get connection string from environment variable
-	connStr := os.Getenv("DATABASE_URL")
-	if connStr == "" {
-		log.Fatal("DATABASE_URL environment variable is required")
+	connStr := databaseURL()
+
+	// Apply pending migrations before the pool connects, so AfterConnect
+	// can find the composite types they create
+	applied, err := migrate.Run(ctx, connStr, migrations.FS)
+	if err != nil {
+		return nil, err
 	}
+	log.Printf("✅ Migrations up to date (%d applied)", applied)
 
 	config, err := pgxpool.ParseConfig(connStr)
 	if err != nil {
@@ -89,8 +184,7 @@ func NewDatabasePool(lc fx.Lifecycle) (*pgxpool.Pool, error) {
 
 	// Register custom composite types
 	config.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
-		types := []string{"users.user_request", "todos.todo_request"}
-		for _, t := range types {
+		for _, t := range postgres.CompositeTypes {
 			dt, err := conn.LoadType(ctx, t)
 			if err != nil {
 				log.Printf("⚠️ Warning: Failed to load type %s: %v", t, err)
@@ -123,25 +217,39 @@ func NewDatabasePool(lc fx.Lifecycle) (*pgxpool.Pool, error) {
 	return pool, nil
 }
 
-// NewGinEngine initializes the Gin framework with CORS
-func NewGinEngine() *gin.Engine {
-	r := gin.Default()
+// databaseURL reads the connection string from the environment
+func databaseURL() string {
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		log.Fatal("DATABASE_URL environment variable is required")
+	}
+	return connStr
+}
+
+// NewGinEngine initializes the Gin framework with CORS, request IDs and problem+json errors
+func NewGinEngine() (*gin.Engine, error) {
+	r := gin.New()
+
+	// Only honour X-Forwarded-For from our own proxy, or clients could forge
+	// the IP recorded in the audit trail
+	if err := r.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+
+	r.Use(gin.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		reply.InternalError(c, fmt.Errorf("panic: %v", recovered))
+	}))
 
 	// Enable CORS for development
-	r.Use(func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	r.Use(middleware.CORS())
 
-		c.Next()
+	r.NoRoute(func(c *gin.Context) {
+		reply.NotFound(c, apperr.NotFound("route not found"))
 	})
 
-	return r
+	return r, nil
 }
 
 // RegisterRoutes ties everything together and starts the HTTP server
@@ -151,8 +259,18 @@ func RegisterRoutes(
 	userCtrl *controller.UserController,
 	authCtrl *controller.AuthController,
 	todoCtrl *controller.TodoController,
+	shareCtrl *controller.ShareController,
+	projectCtrl *controller.ProjectController,
+	tagCtrl *controller.TagController,
+	auditCtrl *controller.AuditController,
+	streamCtrl *controller.StreamController,
+	wsCtrl *controller.WebSocketController,
+	keysCtrl *controller.KeysController,
+	tokens middleware.TokenValidator,
+	sessions middleware.SessionChecker,
+	idempotency *middleware.Idempotency,
 ) {
-	routes.SetupRoutes(r, userCtrl, authCtrl, todoCtrl)
+	routes.SetupRoutes(r, userCtrl, authCtrl, todoCtrl, shareCtrl, projectCtrl, tagCtrl, auditCtrl, streamCtrl, wsCtrl, keysCtrl, tokens, sessions, idempotency)
 
 	port := os.Getenv("PORT")
 	if port == "" {
@@ -167,7 +285,7 @@ func RegisterRoutes(
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			log.Printf("🚀 Todo API starting on :%s", port)
-			log.Println("📦 Endpoints: /api/v1/auth, /api/v1/todos, /api/v1/users")
+			log.Println("📦 Endpoints: /api/v1/auth, /api/v1/todos, /api/v1/projects, /api/v1/tags, /api/v1/users, /api/v1/admin, /api/v1/ws")
 			go func() {
 				if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 					log.Fatalf("Failed to start server: %v", err)
@@ -181,3 +299,56 @@ func RegisterRoutes(
 		},
 	})
 }
+
+// RegisterJobs starts background jobs with the app and stops them before the pool closes.
+// Hooks stop in reverse order: WebSocket connections are drained first, then
+// the event bus closes open streams, before the HTTP server waits for them to
+// finish.
+func RegisterJobs(
+	lc fx.Lifecycle,
+	purger *jobs.TrashPurger,
+	keys *jobs.IdempotencyPurger,
+	reminders *jobs.ReminderScheduler,
+	eventPurger *jobs.EventPurger,
+	bus *events.Bus,
+	hub *realtime.Hub,
+) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			purger.Start()
+			return nil
+		},
+		OnStop: purger.Stop,
+	})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			keys.Start()
+			return nil
+		},
+		OnStop: keys.Stop,
+	})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			reminders.Start()
+			return nil
+		},
+		OnStop: reminders.Stop,
+	})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			eventPurger.Start()
+			return nil
+		},
+		OnStop: eventPurger.Stop,
+	})
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			bus.Start()
+			return nil
+		},
+		OnStop: bus.Stop,
+	})
+	lc.Append(fx.Hook{
+		OnStop: hub.Stop,
+	})
+}
@@ -1,21 +1,39 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/audit"
 	"github.com/fayzzzm/go-bro/pkg/auth"
+	"github.com/fayzzzm/go-bro/pkg/reply"
 	"github.com/gin-gonic/gin"
 )
 
 const (
 	AuthUserIDKey    = "auth_user_id"
 	AuthUserEmailKey = "auth_user_email"
+	AuthSessionIDKey = "auth_session_id"
+	AuthUserRoleKey  = "auth_user_role"
 	AuthCookieName   = "auth_token"
 )
 
+// TokenValidator parses and verifies access tokens; implemented by auth.TokenManager.
+type TokenValidator interface {
+	ValidateToken(tokenString string) (*auth.Claims, error)
+}
+
+// SessionChecker reports whether the session an access token was issued for is still live.
+type SessionChecker interface {
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
 // AuthMiddleware validates JWT tokens from cookies (primary) or Authorization header (fallback)
-func AuthMiddleware() gin.HandlerFunc {
+// and rejects tokens whose session has been revoked
+func AuthMiddleware(tokens TokenValidator, sessions SessionChecker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenString string
 
@@ -38,27 +56,72 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// No token found
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
-			c.Abort()
+			unauthorized(c, "authentication required")
 			return
 		}
 
 		// Validate token
-		claims, err := auth.ValidateToken(tokenString)
+		claims, err := tokens.ValidateToken(tokenString)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired token"})
-			c.Abort()
+			unauthorized(c, "invalid or expired token")
+			return
+		}
+
+		// Reject tokens whose session was revoked (logout or refresh token reuse)
+		if claims.SessionID == "" {
+			unauthorized(c, "invalid or expired token")
+			return
+		}
+		active, err := sessions.IsSessionActive(c.Request.Context(), claims.SessionID)
+		if reply.InternalError(c, err) {
+			return
+		}
+		if !active {
+			unauthorized(c, "session revoked")
 			return
 		}
 
 		// Set user info in context for handlers to use
 		c.Set(AuthUserIDKey, claims.UserID)
 		c.Set(AuthUserEmailKey, claims.Email)
+		c.Set(AuthSessionIDKey, claims.SessionID)
+		c.Set(AuthUserRoleKey, roleOf(claims))
+
+		// Changes made while serving the request are recorded as the user's
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), claims.UserID))
 
 		c.Next()
 	}
 }
 
+// RequireRole aborts with 403 unless the authenticated user has one of the roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...models.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := GetUserRole(c)
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+		reply.Error(c, http.StatusForbidden, "forbidden", apperr.Forbidden("insufficient role"))
+	}
+}
+
+// roleOf defaults tokens issued before roles existed to the user role
+func roleOf(claims *auth.Claims) models.Role {
+	if claims.Role == "" {
+		return models.RoleUser
+	}
+	return models.Role(claims.Role)
+}
+
+// unauthorized aborts with a 401 problem response
+func unauthorized(c *gin.Context, message string) {
+	reply.Error(c, http.StatusUnauthorized, message, apperr.Unauthorized(message))
+}
+
 // GetUserID extracts user ID from context (set by AuthMiddleware)
 func GetUserID(c *gin.Context) (int, bool) {
 	userID, exists := c.Get(AuthUserIDKey)
@@ -69,6 +132,26 @@ func GetUserID(c *gin.Context) (int, bool) {
 	return id, ok
 }
 
+// GetSessionID extracts the session ID the access token was issued for
+func GetSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get(AuthSessionIDKey)
+	if !exists {
+		return "", false
+	}
+	id, ok := sessionID.(string)
+	return id, ok
+}
+
+// GetUserRole extracts the role the access token was issued with
+func GetUserRole(c *gin.Context) (models.Role, bool) {
+	role, exists := c.Get(AuthUserRoleKey)
+	if !exists {
+		return "", false
+	}
+	r, ok := role.(models.Role)
+	return r, ok
+}
+
 // GetUserEmail extracts user email from context
 func GetUserEmail(c *gin.Context) (string, bool) {
 	email, exists := c.Get(AuthUserEmailKey)
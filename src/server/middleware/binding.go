@@ -1,21 +1,33 @@
 package middleware
 
 import (
+	"errors"
+	"mime"
 	"net/http"
+	"strings"
 
+	"github.com/fayzzzm/go-bro/pkg/reply"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 )
 
 const BodyContextKey = "request_body"
 
+// Report validation errors by JSON field name so clients can map them to form fields
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(reply.JSONFieldName)
+	}
+}
+
 // BindJSON is a generic middleware that binds the request body to a struct of type T.
-// If binding fails, it aborts the request with a 400 Bad Request error.
+// If binding fails, it aborts the request with a 400 problem response listing the invalid fields.
 func BindJSON[T any]() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var input T
 		if err := c.ShouldBindJSON(&input); err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-			c.Abort()
+			reply.Error(c, http.StatusBadRequest, "invalid request body", err)
 			return
 		}
 		c.Set(BodyContextKey, input)
@@ -23,6 +35,27 @@ func BindJSON[T any]() gin.HandlerFunc {
 	}
 }
 
+// MergePatchJSON is the RFC 7396 media type for JSON merge patches.
+const MergePatchJSON = "application/merge-patch+json"
+
+// ContentType aborts with 415 Unsupported Media Type unless the request body
+// is one of the given media types. Parameters such as charset are ignored.
+func ContentType(allowed ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil {
+			for _, t := range allowed {
+				if strings.EqualFold(mediaType, t) {
+					c.Next()
+					return
+				}
+			}
+		}
+		reply.Error(c, http.StatusUnsupportedMediaType, "unsupported media type",
+			errors.New("Content-Type must be one of: "+strings.Join(allowed, ", ")))
+	}
+}
+
 // GetBody retrieves the bound body from the context and casts it to type T.
 // It will panic if the body is not present or is of a different type,
 // so it should only be used in handlers where BindJSON[T] was applied.
@@ -0,0 +1,217 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	DefaultIdempotencyTTL     = 24 * time.Hour
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+var (
+	ErrIdempotencyKeyInvalid    = apperr.Validation("Idempotency-Key must be 1 to 255 characters")
+	ErrIdempotencyKeyInProgress = apperr.Conflict("a request with this Idempotency-Key is still in progress")
+	ErrIdempotencyKeyCompleted  = apperr.Conflict("a request with this Idempotency-Key already succeeded")
+	errIdempotencyKeyReused     = errors.New("Idempotency-Key was already used with a different request")
+	errIdempotentBodyTooLarge   = errors.New("request body exceeds 1 MiB")
+)
+
+// IdempotencyStore persists Idempotency-Key responses; implemented by postgres.IdempotencyRepo.
+// A userID of 0 is the anonymous scope.
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, userID int, key, fingerprint string, ttl time.Duration) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, userID int, key string, status int, headers map[string][]string, body []byte) error
+	Release(ctx context.Context, userID int, key string) error
+}
+
+// Idempotency makes retried POSTs safe: the first request with an Idempotency-Key
+// runs and its response is stored; retries with the same key and body get that
+// response back, a different body gets 422. Requests without the header pass through.
+type Idempotency struct {
+	store IdempotencyStore
+	ttl   time.Duration
+}
+
+func NewIdempotency(store IdempotencyStore, ttl time.Duration) *Idempotency {
+	return &Idempotency{store: store, ttl: ttl}
+}
+
+// NewIdempotencyFromEnv reads IDEMPOTENCY_TTL as a Go duration (default 24h).
+func NewIdempotencyFromEnv(store IdempotencyStore) (*Idempotency, error) {
+	ttl := DefaultIdempotencyTTL
+	if raw := os.Getenv("IDEMPOTENCY_TTL"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IDEMPOTENCY_TTL: %w", err)
+		}
+		ttl = d
+	}
+	if ttl < time.Second {
+		return nil, fmt.Errorf("IDEMPOTENCY_TTL must be at least 1s, got %s", ttl)
+	}
+	return NewIdempotency(store, ttl), nil
+}
+
+// Handler returns the middleware. On protected routes it must run after
+// AuthMiddleware so keys are scoped to the user, and before BindJSON so
+// replays skip binding.
+func (i *Idempotency) Handler() gin.HandlerFunc {
+	return i.handler(true)
+}
+
+// StatusOnly is Handler for routes whose successful responses carry
+// credentials, such as signup: a success is stored without its body, since
+// bodies are kept in plaintext, and a retry of it gets 409 instead of a replay.
+func (i *Idempotency) StatusOnly() gin.HandlerFunc {
+	return i.handler(false)
+}
+
+func (i *Idempotency) handler(keepSuccess bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			reply.Error(c, http.StatusBadRequest, "invalid Idempotency-Key", ErrIdempotencyKeyInvalid)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxIdempotentRequestBytes+1))
+		if reply.Error(c, http.StatusBadRequest, "could not read request body", err) {
+			return
+		}
+		if len(body) > maxIdempotentRequestBytes {
+			reply.Error(c, http.StatusRequestEntityTooLarge, "request body too large for an Idempotency-Key", errIdempotentBodyTooLarge)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		userID, _ := GetUserID(c)
+		fp := fingerprint(c.Request, body)
+		stored, err := i.store.Reserve(c.Request.Context(), userID, key, fp, i.ttl)
+		if reply.InternalError(c, err) {
+			return
+		}
+
+		switch {
+		case !stored.Created && stored.Fingerprint != fp:
+			reply.Error(c, http.StatusUnprocessableEntity, "Idempotency-Key reused with a different request", errIdempotencyKeyReused)
+		case !stored.Created && stored.StatusCode == nil:
+			reply.Error(c, http.StatusConflict, "request in progress", ErrIdempotencyKeyInProgress)
+		case !stored.Created && !keepSuccess && *stored.StatusCode < http.StatusMultipleChoices:
+			reply.Error(c, http.StatusConflict, "request already completed", ErrIdempotencyKeyCompleted)
+		case !stored.Created:
+			replay(c, stored)
+		default:
+			i.record(c, userID, key, keepSuccess)
+		}
+	}
+}
+
+// record runs the handler and stores its response, or only the status of a
+// success unless keepSuccess. Server errors release the key instead, so the
+// client can retry.
+func (i *Idempotency) record(c *gin.Context, userID int, key string, keepSuccess bool) {
+	// Save even if the client went away, or its retry would see "in progress" until the key expires
+	ctx := context.WithoutCancel(c.Request.Context())
+	w := &recordingWriter{ResponseWriter: c.Writer}
+	c.Writer = w
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			i.release(ctx, userID, key)
+			panic(recovered)
+		}
+	}()
+	c.Next()
+
+	if w.Status() >= http.StatusInternalServerError {
+		i.release(ctx, userID, key)
+		return
+	}
+	headers, body := storedHeaders(w.Header()), w.body.Bytes()
+	if !keepSuccess && w.Status() < http.StatusMultipleChoices {
+		headers, body = nil, nil
+	}
+	if err := i.store.Complete(ctx, userID, key, w.Status(), headers, body); err != nil {
+		log.Printf("⚠️ Warning: failed to save Idempotency-Key %q: %v", key, err)
+	}
+}
+
+func (i *Idempotency) release(ctx context.Context, userID int, key string) {
+	if err := i.store.Release(ctx, userID, key); err != nil {
+		log.Printf("⚠️ Warning: failed to release Idempotency-Key %q: %v", key, err)
+	}
+}
+
+// replay writes the stored response. Stored headers replace those already
+// set, so none is sent twice.
+func replay(c *gin.Context, stored *models.IdempotencyKey) {
+	for name, values := range stored.Headers {
+		c.Writer.Header()[http.CanonicalHeaderKey(name)] = values
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Writer.WriteHeader(*stored.StatusCode)
+	_, _ = c.Writer.Write(stored.Body)
+	c.Abort()
+}
+
+// fingerprint identifies a request by method, URI and body.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s %s\n", r.Method, r.URL.RequestURI())
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replayedHeaders are the response headers handlers set that describe the
+// result. Everything else, such as cookies, the request ID and the CORS
+// headers set before the handler ran, belongs to the original exchange.
+var replayedHeaders = []string{"Content-Type", "Location", "ETag"}
+
+func storedHeaders(h http.Header) map[string][]string {
+	out := make(map[string][]string, len(replayedHeaders))
+	for _, name := range replayedHeaders {
+		if values := h.Values(name); len(values) > 0 {
+			out[name] = values
+		}
+	}
+	return out
+}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+)
+
+// TrustedProxiesFromEnv reads TRUSTED_PROXIES, a comma-separated list of IPs or
+// CIDRs (e.g. the nginx network), for gin's SetTrustedProxies. Unset trusts no
+// proxy, so X-Forwarded-For and X-Real-IP are ignored and ClientIP, which the
+// audit trail records, is the connecting address.
+func TrustedProxiesFromEnv() []string {
+	var proxies []string
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
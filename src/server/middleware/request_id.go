@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/fayzzzm/go-bro/pkg/audit"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID reuses the caller's X-Request-ID (e.g. from nginx) or generates one,
+// echoes it in the response and stores it for logs, problem responses and,
+// with the client IP, the audit trail
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id = newRequestID()
+		}
+
+		c.Set(reply.RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Request = c.Request.WithContext(audit.WithRequest(c.Request.Context(), id, c.ClientIP()))
+		c.Next()
+	}
+}
+
+// GetRequestID extracts the request ID from context (set by RequestID)
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(reply.RequestIDKey)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/controller"
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+func TestBindJSONProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.POST("/signup", middleware.BindJSON[controller.SignupRequest](), func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+
+	testCases := []struct {
+		name       string
+		body       string
+		wantType   string
+		wantFields map[string]string
+	}{
+		{
+			name:       "Field validation",
+			body:       `{"email": "not-an-email", "password": "123"}`,
+			wantType:   "/problems/validation",
+			wantFields: map[string]string{"name": "required", "email": "email", "password": "min"},
+		},
+		{
+			name:       "Wrong type",
+			body:       `{"name": 42, "email": "a@b.co", "password": "123456"}`,
+			wantType:   "/problems/validation",
+			wantFields: map[string]string{"name": "type"},
+		},
+		{
+			name:     "Malformed JSON",
+			body:     `{"name": `,
+			wantType: "/problems/malformed-body",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPost, "/signup", bytes.NewBufferString(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(middleware.RequestIDHeader, "req-abc")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("Expected status 400, got %d", w.Code)
+			}
+			if w.Header().Get(middleware.RequestIDHeader) != "req-abc" {
+				t.Errorf("Expected request ID to be echoed, got %q", w.Header().Get(middleware.RequestIDHeader))
+			}
+
+			var problem reply.Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("Expected problem+json body, got %s", w.Body.String())
+			}
+			if problem.Type != tc.wantType {
+				t.Errorf("Expected type %s, got %s", tc.wantType, problem.Type)
+			}
+			if problem.RequestID != "req-abc" {
+				t.Errorf("Expected request ID req-abc, got %s", problem.RequestID)
+			}
+
+			got := make(map[string]string)
+			for _, fe := range problem.Errors {
+				got[fe.Field] = fe.Code
+			}
+			for field, code := range tc.wantFields {
+				if got[field] != code {
+					t.Errorf("Expected %s error on field %q, got %v", code, field, problem.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	r := gin.New()
+	r.Use(middleware.RequestID())
+	r.GET("/test", func(c *gin.Context) {
+		c.String(http.StatusOK, middleware.GetRequestID(c))
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	id := w.Header().Get(middleware.RequestIDHeader)
+	if id == "" || id != w.Body.String() {
+		t.Errorf("Expected a generated request ID in header and context, got %q and %q", id, w.Body.String())
+	}
+}
@@ -0,0 +1,225 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/gin-gonic/gin"
+)
+
+// MockIdempotencyStore keeps keys in memory, scoped by user like the SQL table
+type MockIdempotencyStore struct {
+	mu   sync.Mutex
+	keys map[string]*models.IdempotencyKey
+}
+
+func scopedKey(userID int, key string) string {
+	return fmt.Sprintf("%d/%s", userID, key)
+}
+
+func (m *MockIdempotencyStore) Reserve(ctx context.Context, userID int, key, fingerprint string, ttl time.Duration) (*models.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if stored, ok := m.keys[scopedKey(userID, key)]; ok {
+		copied := *stored
+		copied.Created = false
+		return &copied, nil
+	}
+	m.keys[scopedKey(userID, key)] = &models.IdempotencyKey{Fingerprint: fingerprint}
+	return &models.IdempotencyKey{Created: true, Fingerprint: fingerprint}, nil
+}
+
+func (m *MockIdempotencyStore) Complete(ctx context.Context, userID int, key string, status int, headers map[string][]string, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := m.keys[scopedKey(userID, key)]
+	stored.StatusCode, stored.Headers, stored.Body = &status, headers, body
+	return nil
+}
+
+func (m *MockIdempotencyStore) Release(ctx context.Context, userID int, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keys, scopedKey(userID, key))
+	return nil
+}
+
+func setupIdempotencyRouter(store *MockIdempotencyStore, status *int, calls *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	idem := middleware.NewIdempotency(store, time.Hour)
+	r.POST("/todos", func(c *gin.Context) {
+		if id := c.GetHeader("X-User"); id == "2" {
+			c.Set(middleware.AuthUserIDKey, 2)
+		}
+		c.Next()
+	}, idem.Handler(), func(c *gin.Context) {
+		*calls++
+		c.Header("Location", "/todos/1")
+		c.SetCookie("session", "secret", 60, "/", "", false, true)
+		c.JSON(*status, gin.H{"call": *calls})
+	})
+	return r
+}
+
+func postWithKey(r *gin.Engine, key, body, user string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/todos", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_Replay(t *testing.T) {
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	status, calls := http.StatusCreated, 0
+	r := setupIdempotencyRouter(store, &status, &calls)
+
+	first := postWithKey(r, "abc", `{"title":"milk"}`, "")
+	second := postWithKey(r, "abc", `{"title":"milk"}`, "")
+
+	if calls != 1 {
+		t.Fatalf("Expected the handler to run once, ran %d times", calls)
+	}
+	if second.Code != http.StatusCreated || second.Body.String() != first.Body.String() {
+		t.Errorf("Expected the stored response, got %d %s", second.Code, second.Body.String())
+	}
+	if second.Header().Get(middleware.IdempotentReplayedHeader) != "true" || second.Header().Get("Location") != "/todos/1" {
+		t.Errorf("Expected replay headers, got %v", second.Header())
+	}
+	if second.Header().Get("Set-Cookie") != "" {
+		t.Error("Expected cookies not to be replayed")
+	}
+
+	// Keys are scoped per user and requests without a key always run
+	postWithKey(r, "abc", `{"title":"milk"}`, "2")
+	postWithKey(r, "", `{"title":"milk"}`, "")
+	if calls != 3 {
+		t.Errorf("Expected another user's key and keyless requests to run, ran %d times", calls)
+	}
+}
+
+func TestIdempotency_ReplayThroughCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	idem := middleware.NewIdempotency(store, time.Hour)
+
+	r := gin.New()
+	r.Use(middleware.CORS())
+	r.POST("/todos", idem.Handler(), func(c *gin.Context) {
+		c.Header("ETag", `"v1"`)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+	postWithKey(r, "abc", `{}`, "")
+	w := postWithKey(r, "abc", `{}`, "")
+
+	if w.Header().Get(middleware.IdempotentReplayedHeader) != "true" {
+		t.Fatal("Expected a replay")
+	}
+	for _, name := range []string{"Access-Control-Allow-Origin", "Access-Control-Expose-Headers", "Content-Type", "ETag"} {
+		if n := len(w.Header().Values(name)); n != 1 {
+			t.Errorf("Expected one %s header on replay, got %d", name, n)
+		}
+	}
+}
+
+func TestIdempotency_StatusOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	idem := middleware.NewIdempotency(store, time.Hour)
+
+	status, calls := http.StatusCreated, 0
+	r := gin.New()
+	r.POST("/todos", idem.StatusOnly(), func(c *gin.Context) {
+		calls++
+		c.JSON(status, gin.H{"token": "secret"})
+	})
+
+	postWithKey(r, "ok", `{}`, "")
+	if stored := store.keys[scopedKey(0, "ok")]; stored.Body != nil || stored.Headers != nil {
+		t.Errorf("Expected only the status of a success to be stored, got %s", stored.Body)
+	}
+	if w := postWithKey(r, "ok", `{}`, ""); w.Code != http.StatusConflict || calls != 1 {
+		t.Errorf("Expected 409 for a retried success, got %d (calls %d)", w.Code, calls)
+	}
+
+	// Failures hold no credentials and replay as usual
+	status = http.StatusConflict
+	postWithKey(r, "taken", `{}`, "")
+	if w := postWithKey(r, "taken", `{}`, ""); w.Header().Get(middleware.IdempotentReplayedHeader) != "true" || calls != 2 {
+		t.Errorf("Expected a replayed failure, got %d (calls %d)", w.Code, calls)
+	}
+}
+
+func TestIdempotency_DifferentBody(t *testing.T) {
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	status, calls := http.StatusCreated, 0
+	r := setupIdempotencyRouter(store, &status, &calls)
+
+	postWithKey(r, "abc", `{"title":"milk"}`, "")
+	w := postWithKey(r, "abc", `{"title":"bread"}`, "")
+
+	if w.Code != http.StatusUnprocessableEntity || calls != 1 {
+		t.Errorf("Expected 422 without running the handler, got %d (calls %d)", w.Code, calls)
+	}
+}
+
+func TestIdempotency_InProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	idem := middleware.NewIdempotency(store, time.Hour)
+
+	// The handler retries its own request before it has answered
+	r := gin.New()
+	var retry *httptest.ResponseRecorder
+	r.POST("/todos", idem.Handler(), func(c *gin.Context) {
+		if retry == nil {
+			retry = postWithKey(r, "abc", `{}`, "")
+		}
+		c.Status(http.StatusCreated)
+	})
+	postWithKey(r, "abc", `{}`, "")
+
+	if retry == nil || retry.Code != http.StatusConflict {
+		t.Fatalf("Expected 409 while the first request is running, got %v", retry)
+	}
+}
+
+func TestIdempotency_ServerErrorReleasesKey(t *testing.T) {
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	status, calls := http.StatusInternalServerError, 0
+	r := setupIdempotencyRouter(store, &status, &calls)
+
+	postWithKey(r, "abc", `{}`, "")
+	status = http.StatusCreated
+	w := postWithKey(r, "abc", `{}`, "")
+
+	if calls != 2 || w.Code != http.StatusCreated {
+		t.Errorf("Expected the retry to run after a 500, got %d (calls %d)", w.Code, calls)
+	}
+}
+
+func TestIdempotency_InvalidKey(t *testing.T) {
+	store := &MockIdempotencyStore{keys: map[string]*models.IdempotencyKey{}}
+	status, calls := http.StatusCreated, 0
+	r := setupIdempotencyRouter(store, &status, &calls)
+
+	w := postWithKey(r, string(bytes.Repeat([]byte("k"), 256)), `{}`, "")
+	if w.Code != http.StatusBadRequest || calls != 0 {
+		t.Errorf("Expected 400 for an overlong key, got %d", w.Code)
+	}
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -8,15 +9,32 @@ import (
 	"testing"
 
 	"github.com/fayzzzm/go-bro/middleware"
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/audit"
 	"github.com/fayzzzm/go-bro/pkg/auth"
 	"github.com/gin-gonic/gin"
 )
 
+// MockSessionChecker reports only the listed sessions as active
+type MockSessionChecker struct {
+	active map[string]bool
+}
+
+func (m *MockSessionChecker) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return m.active[sessionID], nil
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
+	keys, _ := auth.NewStaticKeyProvider(auth.NewHMACKey("test", []byte("test-secret")))
+	tokens := auth.NewTokenManager(keys)
+
 	// Helper to generate a valid token
-	validToken, _ := auth.GenerateToken(1, "test@example.com")
+	validToken, _ := tokens.GenerateToken(1, "test@example.com", "user", "session-1")
+	revokedToken, _ := tokens.GenerateToken(1, "test@example.com", "user", "session-2")
+	noSessionToken, _ := tokens.GenerateToken(1, "test@example.com", "user", "")
+	sessions := &MockSessionChecker{active: map[string]bool{"session-1": true}}
 
 	tests := []struct {
 		name           string
@@ -55,6 +73,20 @@ func TestAuthMiddleware(t *testing.T) {
 			},
 			expectedStatus: http.StatusUnauthorized,
 		},
+		{
+			name: "Revoked session",
+			setupRequest: func(req *http.Request) {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", revokedToken))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "Token without session",
+			setupRequest: func(req *http.Request) {
+				req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", noSessionToken))
+			},
+			expectedStatus: http.StatusUnauthorized,
+		},
 		{
 			name: "Empty Bearer token",
 			setupRequest: func(req *http.Request) {
@@ -67,7 +99,7 @@ func TestAuthMiddleware(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			r := gin.New()
-			r.Use(middleware.AuthMiddleware())
+			r.Use(middleware.AuthMiddleware(tokens, sessions))
 			r.GET("/test", func(c *gin.Context) {
 				email, _ := middleware.GetUserEmail(c)
 				c.JSON(http.StatusOK, gin.H{"email": email})
@@ -94,6 +126,116 @@ func TestAuthMiddleware(t *testing.T) {
 	}
 }
 
+func TestRequireRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, _ := auth.NewStaticKeyProvider(auth.NewHMACKey("test", []byte("test-secret")))
+	tokens := auth.NewTokenManager(keys)
+	sessions := &MockSessionChecker{active: map[string]bool{"session-1": true}}
+
+	adminToken, _ := tokens.GenerateToken(1, "admin@example.com", "admin", "session-1")
+	userToken, _ := tokens.GenerateToken(2, "user@example.com", "user", "session-1")
+	legacyToken, _ := tokens.GenerateToken(3, "old@example.com", "", "session-1")
+
+	r := gin.New()
+	r.Use(middleware.AuthMiddleware(tokens, sessions), middleware.RequireRole(models.RoleAdmin))
+	r.GET("/admin", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{name: "Admin", token: adminToken, expectedStatus: http.StatusOK},
+		{name: "User", token: userToken, expectedStatus: http.StatusForbidden},
+		{name: "Token without role is a user", token: legacyToken, expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/admin", nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tc.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tc.expectedStatus, w.Code)
+			}
+		})
+	}
+}
+
+func TestAuditMeta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys, _ := auth.NewStaticKeyProvider(auth.NewHMACKey("test", []byte("test-secret")))
+	tokens := auth.NewTokenManager(keys)
+	token, _ := tokens.GenerateToken(42, "test@example.com", "user", "session-1")
+	sessions := &MockSessionChecker{active: map[string]bool{"session-1": true}}
+
+	var meta audit.Meta
+	r := gin.New()
+	r.Use(middleware.RequestID(), middleware.AuthMiddleware(tokens, sessions))
+	r.GET("/test", func(c *gin.Context) {
+		meta = audit.FromContext(c.Request.Context())
+	})
+
+	req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(middleware.RequestIDHeader, "req-1")
+	req.RemoteAddr = "10.0.0.1:1234"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if meta.RequestID != "req-1" || meta.ClientIP != "10.0.0.1" {
+		t.Errorf("Expected request req-1 from 10.0.0.1, got %+v", meta)
+	}
+	if meta.ActorID == nil || *meta.ActorID != 42 {
+		t.Errorf("Expected actor 42, got %v", meta.ActorID)
+	}
+}
+
+func TestAuditMetaTrustedProxies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("TRUSTED_PROXIES", "172.28.0.0/16")
+
+	var meta audit.Meta
+	r := gin.New()
+	if err := r.SetTrustedProxies(middleware.TrustedProxiesFromEnv()); err != nil {
+		t.Fatal(err)
+	}
+	r.Use(middleware.RequestID())
+	r.GET("/test", func(c *gin.Context) {
+		meta = audit.FromContext(c.Request.Context())
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expectedIP string
+	}{
+		// A client talking to the server directly cannot pick its own IP
+		{"Spoofed header from untrusted peer", "203.0.113.9:1234", "1.2.3.4", "203.0.113.9"},
+		// nginx appends the real peer, so a forged prefix is skipped
+		{"Forged prefix via proxy", "172.28.0.5:1234", "1.2.3.4, 198.51.100.7", "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+			req.Header.Set("X-Real-IP", "1.2.3.4")
+			req.RemoteAddr = tt.remoteAddr
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if meta.ClientIP != tt.expectedIP {
+				t.Errorf("Expected client IP %s, got %s", tt.expectedIP, meta.ClientIP)
+			}
+		})
+	}
+}
+
 // Simple helper since encoding/json is common
 func importJson(data []byte, v interface{}) {
 	json.Unmarshal(data, v)
@@ -0,0 +1,3 @@
+-- Revert 001_init_schema.sql
+
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,14 @@
+-- Revert 002_user_functions.sql
+
+DROP FUNCTION IF EXISTS users.list(users.user_request);
+DROP FUNCTION IF EXISTS users.get(users.user_request);
+DROP FUNCTION IF EXISTS users.get_by_email(users.user_request);
+DROP FUNCTION IF EXISTS users.create(users.user_request);
+DROP FUNCTION IF EXISTS users.normalize_name(TEXT);
+DROP FUNCTION IF EXISTS users.normalize_email(TEXT);
+
+DROP TYPE IF EXISTS users.user_auth_response;
+DROP TYPE IF EXISTS users.user_response;
+DROP TYPE IF EXISTS users.user_request;
+
+DROP SCHEMA IF EXISTS users;
@@ -2,6 +2,8 @@
 -- Schema: users
 -- Pattern: Request/Response Composite Types
 
+CREATE SCHEMA IF NOT EXISTS users;
+
 -- =============================================================================
 -- API CONTRACT TYPES
 -- =============================================================================
@@ -0,0 +1,5 @@
+-- Revert 003_todos_schema.sql
+
+DROP TRIGGER IF EXISTS trigger_todos_updated_at ON todos;
+DROP TABLE IF EXISTS todos;
+DROP FUNCTION IF EXISTS update_updated_at_column();
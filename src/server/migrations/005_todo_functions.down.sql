@@ -0,0 +1,13 @@
+-- Revert 005_todo_functions.sql
+
+DROP FUNCTION IF EXISTS todos.toggle(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.delete(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.update(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.get(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.list(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.create(todos.todo_request);
+
+DROP TYPE IF EXISTS todos.todo_response;
+DROP TYPE IF EXISTS todos.todo_request;
+
+DROP SCHEMA IF EXISTS todos;
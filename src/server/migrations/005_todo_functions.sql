@@ -1,6 +1,8 @@
 -- Todo SQL API: Standardized Request/Response Pattern
 -- RESTful naming: todos.create, todos.list, todos.get, todos.update, todos.delete, todos.toggle
 
+CREATE SCHEMA IF NOT EXISTS todos;
+
 -- =============================================================================
 -- API CONTRACT TYPES
 -- =============================================================================
@@ -0,0 +1,6 @@
+-- Revert 006_custom_types.sql
+-- The users and todos schemas belong to 002 and 005 and are dropped there.
+
+DROP TYPE IF EXISTS todo_row;
+DROP TYPE IF EXISTS user_auth_row;
+DROP TYPE IF EXISTS user_row;
@@ -0,0 +1,13 @@
+-- Revert 007_auth_sessions.sql
+
+DROP FUNCTION IF EXISTS auth.is_active(auth.session_request);
+DROP FUNCTION IF EXISTS auth.revoke(auth.session_request);
+DROP FUNCTION IF EXISTS auth.rotate(auth.session_request);
+DROP FUNCTION IF EXISTS auth.create_session(auth.session_request);
+
+DROP TYPE IF EXISTS auth.session_response;
+DROP TYPE IF EXISTS auth.session_request;
+
+DROP TABLE IF EXISTS auth.sessions;
+
+DROP SCHEMA IF EXISTS auth;
@@ -0,0 +1,139 @@
+-- Auth Session SQL API: Refresh Token Rotation
+-- Schema: auth
+-- Every refresh token is one row; rows issued from the same login share a family_id.
+-- Presenting an already-rotated token revokes the whole family (reuse detection).
+
+CREATE SCHEMA IF NOT EXISTS auth;
+
+CREATE TABLE IF NOT EXISTS auth.sessions (
+    id         SERIAL PRIMARY KEY,
+    family_id  UUID NOT NULL,
+    user_id    INTEGER NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    rotated_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Index for revocation checks and family-wide revokes
+CREATE INDEX IF NOT EXISTS idx_sessions_family_id ON auth.sessions(family_id);
+
+-- Index for per-user cleanup
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON auth.sessions(user_id);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all session-related parameters
+CREATE TYPE auth.session_request AS (
+    family_id      TEXT,
+    user_id        INTEGER,
+    token_hash     TEXT,
+    new_token_hash TEXT,
+    ttl_seconds    INTEGER
+);
+
+-- OUTPUT: Session data returned after create/rotate
+CREATE TYPE auth.session_response AS (
+    id         INTEGER,
+    family_id  TEXT,
+    user_id    INTEGER,
+    email      TEXT,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ
+);
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- CREATE (starts a new family on login/signup)
+CREATE OR REPLACE FUNCTION auth.create_session(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_id INTEGER;
+BEGIN
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (
+        COALESCE(r.family_id::UUID, gen_random_uuid()),
+        r.user_id,
+        r.token_hash,
+        NOW() + make_interval(secs => r.ttl_seconds)
+    )
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- ROTATE (exchanges a live refresh token for a new one in the same family)
+-- Returns no rows when the token is unknown, expired, revoked or already rotated.
+-- A rotated token being presented again means it leaked, so its family is revoked.
+CREATE OR REPLACE FUNCTION auth.rotate(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_family_id UUID;
+    v_user_id   INTEGER;
+    v_id        INTEGER;
+BEGIN
+    UPDATE auth.sessions
+    SET rotated_at = NOW()
+    WHERE token_hash = r.token_hash
+      AND rotated_at IS NULL
+      AND revoked_at IS NULL
+      AND expires_at > NOW()
+    RETURNING family_id, user_id INTO v_family_id, v_user_id;
+
+    IF NOT FOUND THEN
+        UPDATE auth.sessions
+        SET revoked_at = NOW()
+        WHERE revoked_at IS NULL
+          AND family_id = (SELECT family_id FROM auth.sessions WHERE token_hash = r.token_hash);
+        RETURN;
+    END IF;
+
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (v_family_id, v_user_id, r.new_token_hash, NOW() + make_interval(secs => r.ttl_seconds))
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- REVOKE (logout: by family_id or by any refresh token in the family)
+CREATE OR REPLACE FUNCTION auth.revoke(r auth.session_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE auth.sessions
+    SET revoked_at = NOW()
+    WHERE revoked_at IS NULL
+      AND family_id = COALESCE(
+          r.family_id::UUID,
+          (SELECT family_id FROM auth.sessions WHERE token_hash = r.token_hash)
+      );
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- IS ACTIVE (checked by the auth middleware on every request)
+CREATE OR REPLACE FUNCTION auth.is_active(r auth.session_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    RETURN EXISTS (
+        SELECT 1 FROM auth.sessions
+        WHERE family_id = r.family_id::UUID
+          AND revoked_at IS NULL
+          AND expires_at > NOW()
+    );
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
@@ -0,0 +1,39 @@
+-- Revert 008_keyset_pagination.sql
+
+CREATE OR REPLACE FUNCTION users.list(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, name, email, created_at
+    FROM public.users
+    ORDER BY created_at DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, user_id, title, description, completed, created_at, updated_at
+    FROM public.todos
+    WHERE user_id = r.user_id
+    ORDER BY created_at DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS idx_todos_user_created_at_id;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE cursor_dir,
+    DROP ATTRIBUTE cursor_id,
+    DROP ATTRIBUTE cursor_created_at;
+
+ALTER TYPE users.user_request
+    DROP ATTRIBUTE cursor_dir,
+    DROP ATTRIBUTE cursor_id,
+    DROP ATTRIBUTE cursor_created_at;
@@ -0,0 +1,96 @@
+-- Keyset (cursor) pagination for users.list and todos.list
+-- A cursor is the (created_at, id) of the row at the edge of the previous page.
+-- cursor_dir = 'next' walks towards older rows, 'prev' towards newer ones.
+-- Without a cursor both functions fall back to limit/offset.
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- Attributes are appended: the Go request structs are encoded positionally
+ALTER TYPE users.user_request
+    ADD ATTRIBUTE cursor_created_at TIMESTAMPTZ,
+    ADD ATTRIBUTE cursor_id         INTEGER,
+    ADD ATTRIBUTE cursor_dir        TEXT;
+
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE cursor_created_at TIMESTAMPTZ,
+    ADD ATTRIBUTE cursor_id         INTEGER,
+    ADD ATTRIBUTE cursor_dir        TEXT;
+
+-- Composite indexes matching the (created_at, id) sort key
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id ON users(created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_todos_user_created_at_id ON todos(user_id, created_at DESC, id DESC);
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- LIST USERS
+CREATE OR REPLACE FUNCTION users.list(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT id, name, email, created_at
+        FROM public.users
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.name, p.email, p.created_at
+        FROM (
+            SELECT u.id, u.name, u.email, u.created_at
+            FROM public.users u
+            WHERE (u.created_at, u.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY u.created_at ASC, u.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT id, name, email, created_at
+        FROM public.users
+        WHERE (created_at, id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+-- LIST TODOS
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT id, user_id, title, description, completed, created_at, updated_at
+        FROM public.todos
+        WHERE user_id = r.user_id
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+            FROM public.todos t
+            WHERE t.user_id = r.user_id
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT id, user_id, title, description, completed, created_at, updated_at
+        FROM public.todos
+        WHERE user_id = r.user_id
+          AND (created_at, id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
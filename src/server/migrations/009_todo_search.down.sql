@@ -0,0 +1,54 @@
+-- Revert 009_todo_search.sql
+
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT id, user_id, title, description, completed, created_at, updated_at
+        FROM public.todos
+        WHERE user_id = r.user_id
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+            FROM public.todos t
+            WHERE t.user_id = r.user_id
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT id, user_id, title, description, completed, created_at, updated_at
+        FROM public.todos
+        WHERE user_id = r.user_id
+          AND (created_at, id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS todos.matches(public.todos, todos.todo_request);
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE sort_desc,
+    DROP ATTRIBUTE sort_by,
+    DROP ATTRIBUTE search,
+    DROP ATTRIBUTE updated_before,
+    DROP ATTRIBUTE updated_after,
+    DROP ATTRIBUTE created_before,
+    DROP ATTRIBUTE created_after;
+
+DROP INDEX IF EXISTS idx_todos_user_due_at;
+DROP INDEX IF EXISTS idx_todos_user_updated_at;
+DROP INDEX IF EXISTS idx_todos_search_vector;
+
+ALTER TABLE todos DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE todos DROP COLUMN IF EXISTS due_at;
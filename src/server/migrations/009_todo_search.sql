@@ -0,0 +1,112 @@
+-- Filtering, sorting and full-text search for todos.list
+-- Filters: completed, created/updated ranges and a websearch-style query over
+-- title and description. Sorting: title, created_at, updated_at or due_at.
+-- Keyset cursors only follow the default created_at DESC order.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+-- Nullable until due dates are exposed through the API; lets lists sort by it already
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS due_at TIMESTAMPTZ;
+
+-- Title weighs more than description when matching
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_todos_search_vector ON todos USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_todos_user_updated_at ON todos(user_id, updated_at DESC);
+CREATE INDEX IF NOT EXISTS idx_todos_user_due_at ON todos(user_id, due_at);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- `completed` doubles as the completed filter on list
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE created_after  TIMESTAMPTZ,
+    ADD ATTRIBUTE created_before TIMESTAMPTZ,
+    ADD ATTRIBUTE updated_after  TIMESTAMPTZ,
+    ADD ATTRIBUTE updated_before TIMESTAMPTZ,
+    ADD ATTRIBUTE search         TEXT,
+    ADD ATTRIBUTE sort_by        TEXT,
+    ADD ATTRIBUTE sort_desc      BOOLEAN;
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- Shared WHERE clause for list (and later count) functions
+CREATE OR REPLACE FUNCTION todos.matches(t public.todos, r todos.todo_request)
+RETURNS BOOLEAN AS $$
+    SELECT t.user_id = r.user_id
+       AND (r.completed IS NULL OR t.completed = r.completed)
+       AND (r.created_after IS NULL OR t.created_at >= r.created_after)
+       AND (r.created_before IS NULL OR t.created_at < r.created_before)
+       AND (r.updated_after IS NULL OR t.updated_at >= r.updated_after)
+       AND (r.updated_before IS NULL OR t.updated_at < r.updated_before)
+       AND (NULLIF(TRIM(r.search), '') IS NULL
+            OR t.search_vector @@ websearch_to_tsquery('english', r.search));
+$$ LANGUAGE sql STABLE;
+
+-- LIST TODOS
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
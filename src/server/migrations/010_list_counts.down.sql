@@ -0,0 +1,4 @@
+-- Revert 010_list_counts.sql
+
+DROP FUNCTION IF EXISTS todos.count(todos.todo_request);
+DROP FUNCTION IF EXISTS users.count(users.user_request);
@@ -0,0 +1,15 @@
+-- Companion count functions for list endpoints
+-- Each takes the same request as its list function and counts every matching
+-- row, ignoring limit, offset and cursor fields.
+
+-- COUNT USERS
+CREATE OR REPLACE FUNCTION users.count(r users.user_request)
+RETURNS BIGINT AS $$
+    SELECT COUNT(*) FROM public.users;
+$$ LANGUAGE sql SECURITY DEFINER STABLE;
+
+-- COUNT TODOS
+CREATE OR REPLACE FUNCTION todos.count(r todos.todo_request)
+RETURNS BIGINT AS $$
+    SELECT COUNT(*) FROM public.todos t WHERE todos.matches(t, r);
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,142 @@
+-- Revert 011_todo_trash.sql
+-- Trashed todos are deleted for good: the reverted schema has no way to hide them.
+
+DROP FUNCTION IF EXISTS todos.purge_expired(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.purge(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.restore(todos.todo_request);
+
+DELETE FROM todos WHERE deleted_at IS NOT NULL;
+
+ALTER TYPE todos.todo_response DROP ATTRIBUTE deleted_at;
+
+CREATE OR REPLACE FUNCTION todos.matches(t public.todos, r todos.todo_request)
+RETURNS BOOLEAN AS $$
+    SELECT t.user_id = r.user_id
+       AND (r.completed IS NULL OR t.completed = r.completed)
+       AND (r.created_after IS NULL OR t.created_at >= r.created_after)
+       AND (r.created_before IS NULL OR t.created_at < r.created_before)
+       AND (r.updated_after IS NULL OR t.updated_at >= r.updated_after)
+       AND (r.updated_before IS NULL OR t.updated_at < r.updated_before)
+       AND (NULLIF(TRIM(r.search), '') IS NULL
+            OR t.search_vector @@ websearch_to_tsquery('english', r.search));
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO public.todos (user_id, title, description)
+    VALUES (r.user_id, r.title, r.description)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, user_id, title, description, completed, created_at, updated_at
+    FROM public.todos
+    WHERE id = r.id AND user_id = r.user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET 
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id
+    RETURNING id, user_id, title, description, completed, created_at, updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.delete(r todos.todo_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM public.todos 
+    WHERE id = r.id AND user_id = r.user_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id
+    RETURNING id, user_id, title, description, completed, created_at, updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE deleted_before,
+    DROP ATTRIBUTE trashed;
+
+DROP INDEX IF EXISTS idx_todos_deleted_at;
+ALTER TABLE todos DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- Revert 012_todo_batch.sql
+
+DROP FUNCTION IF EXISTS todos.batch(todos.batch_request);
+DROP FUNCTION IF EXISTS todos.apply(todos.todo_request);
+
+DROP TYPE IF EXISTS todos.batch_result;
+DROP TYPE IF EXISTS todos.batch_request;
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE op;
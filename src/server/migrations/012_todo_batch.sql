@@ -0,0 +1,126 @@
+-- Bulk todo operations
+-- todos.batch applies a list of create/update/delete/toggle requests in one
+-- transaction and reports a result per operation. Atomic batches roll back
+-- completely when any operation fails; otherwise failures are skipped.
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- op: create | update | delete | toggle (batch only)
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE op TEXT;
+
+CREATE TYPE todos.batch_request AS (
+    atomic BOOLEAN,
+    ops    todos.todo_request[]
+);
+
+-- status: ok | error | rolled_back | skipped
+-- The todo is flattened so failed operations simply leave it NULL.
+CREATE TYPE todos.batch_result AS (
+    idx           INTEGER,
+    op            TEXT,
+    status        TEXT,
+    error_code    TEXT,
+    error_message TEXT,
+    id            INTEGER,
+    user_id       INTEGER,
+    title         VARCHAR(500),
+    description   TEXT,
+    completed     BOOLEAN,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ,
+    deleted_at    TIMESTAMPTZ
+);
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT * INTO v_todo FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,211 @@
+-- Revert 013_todo_versions.sql
+
+ALTER TYPE todos.batch_result DROP ATTRIBUTE version;
+ALTER TYPE todos.todo_response DROP ATTRIBUTE version;
+
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    INSERT INTO public.todos (user_id, title, description)
+    VALUES (r.user_id, r.title, r.description)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, user_id, title, description, completed, created_at, updated_at, deleted_at
+    FROM public.todos
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT * INTO v_todo FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE expected_version;
+
+DROP TRIGGER IF EXISTS trigger_todos_version ON todos;
+DROP FUNCTION IF EXISTS todos.bump_version();
+ALTER TABLE todos DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+-- Revert 014_todo_patch.sql
+
+DROP FUNCTION IF EXISTS todos.patch(todos.todo_request);
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE set_fields;
@@ -0,0 +1,48 @@
+-- JSON Merge Patch for todos
+-- todos.update treats NULL as "leave alone", so a field can never be cleared.
+-- todos.patch only touches the columns listed in r.set_fields and writes their
+-- values as given, NULL included.
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- set_fields: columns a patch assigns (title, description, completed)
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE set_fields TEXT[];
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL AND EXISTS (
+        SELECT 1 FROM public.todos
+        WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
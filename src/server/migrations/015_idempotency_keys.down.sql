@@ -0,0 +1,13 @@
+-- Revert 015_idempotency_keys.sql
+
+DROP FUNCTION IF EXISTS idempotency.purge_expired();
+DROP FUNCTION IF EXISTS idempotency.release(idempotency.key_request);
+DROP FUNCTION IF EXISTS idempotency.complete(idempotency.key_request);
+DROP FUNCTION IF EXISTS idempotency.reserve(idempotency.key_request);
+
+DROP TYPE IF EXISTS idempotency.key_response;
+DROP TYPE IF EXISTS idempotency.key_request;
+
+DROP TABLE IF EXISTS idempotency.keys;
+
+DROP SCHEMA IF EXISTS idempotency;
@@ -0,0 +1,118 @@
+-- Idempotency keys SQL API
+-- Schema: idempotency
+-- A POST sent with an Idempotency-Key header reserves (user, key) here, runs once
+-- and stores its response; retries with the same key replay that response
+-- until the key expires. Anonymous requests (signup) have a NULL user_id.
+-- Stored bodies can hold credentials (signup returns tokens), so keys should
+-- be kept short-lived.
+
+CREATE SCHEMA IF NOT EXISTS idempotency;
+
+CREATE TABLE IF NOT EXISTS idempotency.keys (
+    id          BIGSERIAL PRIMARY KEY,
+    user_id     INTEGER REFERENCES public.users(id) ON DELETE CASCADE,
+    key         VARCHAR(255) NOT NULL,
+    fingerprint TEXT NOT NULL,
+    status_code INTEGER,
+    headers     JSONB,
+    body        BYTEA,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+-- One key per user; anonymous keys share scope 0
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_keys_scope
+    ON idempotency.keys ((COALESCE(user_id, 0)), key);
+
+-- Index for the expiry job
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency.keys(expires_at);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all idempotency key parameters
+CREATE TYPE idempotency.key_request AS (
+    user_id     INTEGER,
+    key         TEXT,
+    fingerprint TEXT,
+    ttl_seconds INTEGER,
+    status_code INTEGER,
+    headers     JSONB,
+    body        BYTEA
+);
+
+-- OUTPUT: The stored key; status_code is NULL while the first request is running
+CREATE TYPE idempotency.key_response AS (
+    created     BOOLEAN,
+    fingerprint TEXT,
+    status_code INTEGER,
+    headers     JSONB,
+    body        BYTEA
+);
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- RESERVE: claims the key, or returns what is stored for it (created = FALSE)
+CREATE OR REPLACE FUNCTION idempotency.reserve(r idempotency.key_request)
+RETURNS SETOF idempotency.key_response AS $$
+DECLARE
+    v_created BOOLEAN;
+BEGIN
+    -- An expired key is free to be used again
+    DELETE FROM idempotency.keys k
+    WHERE COALESCE(k.user_id, 0) = COALESCE(r.user_id, 0)
+      AND k.key = r.key
+      AND k.expires_at <= NOW();
+
+    INSERT INTO idempotency.keys (user_id, key, fingerprint, expires_at)
+    VALUES (r.user_id, r.key, r.fingerprint, NOW() + make_interval(secs => r.ttl_seconds))
+    ON CONFLICT DO NOTHING;
+    v_created := FOUND;
+
+    RETURN QUERY
+    SELECT v_created, k.fingerprint, k.status_code, k.headers, k.body
+    FROM idempotency.keys k
+    WHERE COALESCE(k.user_id, 0) = COALESCE(r.user_id, 0)
+      AND k.key = r.key;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- COMPLETE: stores the response of the request holding the key
+CREATE OR REPLACE FUNCTION idempotency.complete(r idempotency.key_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE idempotency.keys k
+    SET status_code = r.status_code, headers = r.headers, body = r.body
+    WHERE COALESCE(k.user_id, 0) = COALESCE(r.user_id, 0)
+      AND k.key = r.key
+      AND k.status_code IS NULL;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- RELEASE: frees a key whose request failed so it can be retried
+CREATE OR REPLACE FUNCTION idempotency.release(r idempotency.key_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM idempotency.keys k
+    WHERE COALESCE(k.user_id, 0) = COALESCE(r.user_id, 0)
+      AND k.key = r.key
+      AND k.status_code IS NULL;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- PURGE EXPIRED (expiry job)
+CREATE OR REPLACE FUNCTION idempotency.purge_expired()
+RETURNS INTEGER AS $$
+DECLARE
+    v_count INTEGER;
+BEGIN
+    DELETE FROM idempotency.keys WHERE expires_at <= NOW();
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
@@ -0,0 +1,146 @@
+-- Revert 016_user_roles.sql
+
+DROP FUNCTION IF EXISTS users.delete(users.user_request);
+DROP FUNCTION IF EXISTS users.set_disabled(users.user_request);
+
+ALTER TYPE auth.session_response DROP ATTRIBUTE role;
+ALTER TYPE users.user_auth_response DROP ATTRIBUTE role, DROP ATTRIBUTE disabled_at;
+ALTER TYPE users.user_response DROP ATTRIBUTE role, DROP ATTRIBUTE disabled_at;
+
+CREATE OR REPLACE FUNCTION users.create(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+DECLARE
+    v_id INTEGER;
+    v_email TEXT := users.normalize_email(r.email);
+    v_name TEXT := users.normalize_name(r.name);
+BEGIN
+    -- Validations
+    IF v_name = '' THEN RAISE EXCEPTION 'name required' USING ERRCODE = 'check_violation'; END IF;
+    IF v_email = '' OR POSITION('@' IN v_email) = 0 THEN RAISE EXCEPTION 'invalid email' USING ERRCODE = 'check_violation'; END IF;
+
+    IF EXISTS (SELECT 1 FROM public.users WHERE email = v_email) THEN
+        RAISE EXCEPTION 'email already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    INSERT INTO public.users (name, email, password_hash)
+    VALUES (v_name, v_email, r.password_hash)
+    RETURNING id INTO v_id;
+
+    RETURN QUERY SELECT id, name, email, created_at FROM public.users WHERE id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+CREATE OR REPLACE FUNCTION users.get_by_email(r users.user_request)
+RETURNS SETOF users.user_auth_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, name, email, password_hash, created_at
+    FROM public.users
+    WHERE email = users.normalize_email(r.email);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+CREATE OR REPLACE FUNCTION users.get(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, name, email, created_at
+    FROM public.users
+    WHERE id = r.id;
+
+    IF NOT FOUND THEN RAISE EXCEPTION 'user not found' USING ERRCODE = 'no_data_found'; END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+CREATE OR REPLACE FUNCTION users.list(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT id, name, email, created_at
+        FROM public.users
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.name, p.email, p.created_at
+        FROM (
+            SELECT u.id, u.name, u.email, u.created_at
+            FROM public.users u
+            WHERE (u.created_at, u.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY u.created_at ASC, u.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT id, name, email, created_at
+        FROM public.users
+        WHERE (created_at, id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+CREATE OR REPLACE FUNCTION auth.create_session(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_id INTEGER;
+BEGIN
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (
+        COALESCE(r.family_id::UUID, gen_random_uuid()),
+        r.user_id,
+        r.token_hash,
+        NOW() + make_interval(secs => r.ttl_seconds)
+    )
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+CREATE OR REPLACE FUNCTION auth.rotate(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_family_id UUID;
+    v_user_id   INTEGER;
+    v_id        INTEGER;
+BEGIN
+    UPDATE auth.sessions
+    SET rotated_at = NOW()
+    WHERE token_hash = r.token_hash
+      AND rotated_at IS NULL
+      AND revoked_at IS NULL
+      AND expires_at > NOW()
+    RETURNING family_id, user_id INTO v_family_id, v_user_id;
+
+    IF NOT FOUND THEN
+        UPDATE auth.sessions
+        SET revoked_at = NOW()
+        WHERE revoked_at IS NULL
+          AND family_id = (SELECT family_id FROM auth.sessions WHERE token_hash = r.token_hash);
+        RETURN;
+    END IF;
+
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (v_family_id, v_user_id, r.new_token_hash, NOW() + make_interval(secs => r.ttl_seconds))
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+ALTER TYPE users.user_request DROP ATTRIBUTE disabled;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at, DROP COLUMN IF EXISTS role;
@@ -0,0 +1,212 @@
+-- Roles and account status for users
+-- Every user is 'user' or 'admin'; the role is embedded in access tokens and
+-- checked by middleware.RequireRole. Disabling a user revokes their sessions.
+-- There is no endpoint to grant the first admin; promote one by hand:
+--   UPDATE users SET role = 'admin' WHERE email = 'you@example.com';
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user'
+        CONSTRAINT users_role_check CHECK (role IN ('user', 'admin')),
+    ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+ALTER TYPE users.user_request
+    ADD ATTRIBUTE disabled BOOLEAN;
+
+-- Functions returning these types are recreated below with the new columns
+ALTER TYPE users.user_response
+    ADD ATTRIBUTE role        TEXT,
+    ADD ATTRIBUTE disabled_at TIMESTAMPTZ;
+
+ALTER TYPE users.user_auth_response
+    ADD ATTRIBUTE role        TEXT,
+    ADD ATTRIBUTE disabled_at TIMESTAMPTZ;
+
+ALTER TYPE auth.session_response
+    ADD ATTRIBUTE role TEXT;
+
+-- =============================================================================
+-- USER FUNCTIONS
+-- =============================================================================
+
+-- CREATE
+CREATE OR REPLACE FUNCTION users.create(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+DECLARE
+    v_id INTEGER;
+    v_email TEXT := users.normalize_email(r.email);
+    v_name TEXT := users.normalize_name(r.name);
+BEGIN
+    -- Validations
+    IF v_name = '' THEN RAISE EXCEPTION 'name required' USING ERRCODE = 'check_violation'; END IF;
+    IF v_email = '' OR POSITION('@' IN v_email) = 0 THEN RAISE EXCEPTION 'invalid email' USING ERRCODE = 'check_violation'; END IF;
+
+    IF EXISTS (SELECT 1 FROM public.users WHERE email = v_email) THEN
+        RAISE EXCEPTION 'email already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    INSERT INTO public.users (name, email, password_hash)
+    VALUES (v_name, v_email, r.password_hash)
+    RETURNING id INTO v_id;
+
+    RETURN QUERY SELECT id, name, email, created_at, role, disabled_at FROM public.users WHERE id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- GET BY EMAIL (Auth)
+CREATE OR REPLACE FUNCTION users.get_by_email(r users.user_request)
+RETURNS SETOF users.user_auth_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, name, email, password_hash, created_at, role, disabled_at
+    FROM public.users
+    WHERE email = users.normalize_email(r.email);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+-- GET BY ID
+CREATE OR REPLACE FUNCTION users.get(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, name, email, created_at, role, disabled_at
+    FROM public.users
+    WHERE id = r.id;
+
+    IF NOT FOUND THEN RAISE EXCEPTION 'user not found' USING ERRCODE = 'no_data_found'; END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+-- LIST USERS
+CREATE OR REPLACE FUNCTION users.list(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT id, name, email, created_at, role, disabled_at
+        FROM public.users
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.name, p.email, p.created_at, p.role, p.disabled_at
+        FROM (
+            SELECT u.id, u.name, u.email, u.created_at, u.role, u.disabled_at
+            FROM public.users u
+            WHERE (u.created_at, u.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY u.created_at ASC, u.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT id, name, email, created_at, role, disabled_at
+        FROM public.users
+        WHERE (created_at, id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY created_at DESC, id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+-- SET DISABLED (admin): r.disabled disables and revokes every session, FALSE re-enables
+CREATE OR REPLACE FUNCTION users.set_disabled(r users.user_request)
+RETURNS SETOF users.user_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.users
+    SET disabled_at = CASE WHEN r.disabled THEN COALESCE(disabled_at, NOW()) END
+    WHERE id = r.id
+    RETURNING id, name, email, created_at, role, disabled_at;
+
+    IF NOT FOUND THEN RAISE EXCEPTION 'user not found' USING ERRCODE = 'no_data_found'; END IF;
+
+    IF r.disabled THEN
+        UPDATE auth.sessions
+        SET revoked_at = NOW()
+        WHERE user_id = r.id AND revoked_at IS NULL;
+    END IF;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- DELETE (admin): todos, sessions and idempotency keys cascade
+CREATE OR REPLACE FUNCTION users.delete(r users.user_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM public.users WHERE id = r.id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- =============================================================================
+-- SESSION FUNCTIONS (the role goes into the access token)
+-- =============================================================================
+
+-- CREATE (starts a new family on login/signup)
+CREATE OR REPLACE FUNCTION auth.create_session(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_id INTEGER;
+BEGIN
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (
+        COALESCE(r.family_id::UUID, gen_random_uuid()),
+        r.user_id,
+        r.token_hash,
+        NOW() + make_interval(secs => r.ttl_seconds)
+    )
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at, u.role
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+-- ROTATE (exchanges a live refresh token for a new one in the same family)
+-- Returns no rows when the token is unknown, expired, revoked or already rotated.
+-- A rotated token being presented again means it leaked, so its family is revoked.
+CREATE OR REPLACE FUNCTION auth.rotate(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_family_id UUID;
+    v_user_id   INTEGER;
+    v_id        INTEGER;
+BEGIN
+    UPDATE auth.sessions
+    SET rotated_at = NOW()
+    WHERE token_hash = r.token_hash
+      AND rotated_at IS NULL
+      AND revoked_at IS NULL
+      AND expires_at > NOW()
+    RETURNING family_id, user_id INTO v_family_id, v_user_id;
+
+    IF NOT FOUND THEN
+        UPDATE auth.sessions
+        SET revoked_at = NOW()
+        WHERE revoked_at IS NULL
+          AND family_id = (SELECT family_id FROM auth.sessions WHERE token_hash = r.token_hash);
+        RETURN;
+    END IF;
+
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (v_family_id, v_user_id, r.new_token_hash, NOW() + make_interval(secs => r.ttl_seconds))
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at, u.role
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
@@ -0,0 +1,116 @@
+-- Revert 017_todo_shares.sql
+-- Restores the owner-only todo functions from 011, 013 and 014.
+
+DROP FUNCTION IF EXISTS todos.list_shared(todos.share_request);
+DROP FUNCTION IF EXISTS todos.list_shares(todos.share_request);
+DROP FUNCTION IF EXISTS todos.unshare(todos.share_request);
+DROP FUNCTION IF EXISTS todos.share(todos.share_request);
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT id, user_id, title, description, completed, created_at, updated_at, deleted_at, version
+    FROM public.todos
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL AND EXISTS (
+        SELECT 1 FROM public.todos
+        WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL AND EXISTS (
+        SELECT 1 FROM public.todos
+        WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates)
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL AND EXISTS (
+        SELECT 1 FROM public.todos
+        WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL
+    ) THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- DELETE (move to trash)
+CREATE OR REPLACE FUNCTION todos.delete(r todos.todo_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    UPDATE public.todos
+    SET deleted_at = NOW()
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NULL;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP FUNCTION IF EXISTS todos.authorize(INTEGER, INTEGER, TEXT);
+DROP FUNCTION IF EXISTS todos.permission(public.todos, INTEGER);
+
+DROP TYPE IF EXISTS todos.shared_todo_response;
+DROP TYPE IF EXISTS todos.share_response;
+DROP TYPE IF EXISTS todos.share_request;
+
+DROP TABLE IF EXISTS todo_shares;
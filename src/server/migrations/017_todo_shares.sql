@@ -0,0 +1,315 @@
+-- Todo sharing
+-- An owner shares one todo, or their whole list (todo_id NULL), with another
+-- user as viewer or editor. todos.permission resolves what a user may do with
+-- a todo and replaces the user_id = r.user_id filter in get, update, patch,
+-- toggle and delete:
+--   viewer  get
+--   editor  get, update, patch, toggle
+--   owner   everything, including delete, restore, purge and sharing
+-- A todo the user cannot see raises no_data_found; a todo they can see but not
+-- change raises insufficient_privilege. Listing, trash and create stay
+-- scoped to the owner; todos.list_shared lists what others shared.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS todo_shares (
+    id         SERIAL PRIMARY KEY,
+    owner_id   INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    todo_id    INTEGER REFERENCES todos(id) ON DELETE CASCADE,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    permission TEXT NOT NULL CONSTRAINT todo_shares_permission_check CHECK (permission IN ('viewer', 'editor')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CONSTRAINT todo_shares_not_self CHECK (owner_id <> user_id)
+);
+
+-- One share per owner, todo (or whole list) and collaborator
+CREATE UNIQUE INDEX IF NOT EXISTS idx_todo_shares_scope
+    ON todo_shares (owner_id, (COALESCE(todo_id, 0)), user_id);
+
+-- Index for "shared with me"
+CREATE INDEX IF NOT EXISTS idx_todo_shares_user_id ON todo_shares(user_id);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all share parameters
+-- user_id is the collaborator; sharing looks them up by email instead.
+CREATE TYPE todos.share_request AS (
+    owner_id   INTEGER,
+    todo_id    INTEGER,
+    user_id    INTEGER,
+    email      TEXT,
+    permission TEXT,
+    limit_val  INTEGER,
+    offset_val INTEGER
+);
+
+-- OUTPUT: A collaborator; todo_id is NULL for whole-list shares
+CREATE TYPE todos.share_response AS (
+    todo_id    INTEGER,
+    user_id    INTEGER,
+    name       TEXT,
+    email      TEXT,
+    permission TEXT,
+    created_at TIMESTAMPTZ
+);
+
+-- OUTPUT: A todo someone else owns, with the caller's permission on it
+CREATE TYPE todos.shared_todo_response AS (
+    id          INTEGER,
+    user_id     INTEGER,
+    title       VARCHAR(500),
+    description TEXT,
+    completed   BOOLEAN,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+    version     INTEGER,
+    permission  TEXT,
+    owner_name  TEXT
+);
+
+-- =============================================================================
+-- INTERNAL HELPERS
+-- =============================================================================
+
+-- owner, editor, viewer, or NULL when the user has no access.
+-- A todo-level and a list-level share may both apply; the stronger one wins.
+CREATE OR REPLACE FUNCTION todos.permission(t public.todos, p_user_id INTEGER)
+RETURNS TEXT AS $$
+    SELECT CASE
+        WHEN t.user_id = p_user_id THEN 'owner'
+        ELSE (
+            SELECT s.permission
+            FROM public.todo_shares s
+            WHERE s.user_id = p_user_id
+              AND s.owner_id = t.user_id
+              AND (s.todo_id = t.id OR s.todo_id IS NULL)
+            ORDER BY s.permission = 'editor' DESC
+            LIMIT 1
+        )
+    END;
+$$ LANGUAGE sql STABLE;
+
+-- Raises unless p_user_id has at least p_need on the live todo p_todo_id
+CREATE OR REPLACE FUNCTION todos.authorize(p_todo_id INTEGER, p_user_id INTEGER, p_need TEXT)
+RETURNS VOID AS $$
+DECLARE
+    v_levels CONSTANT TEXT[] := ARRAY['viewer', 'editor', 'owner'];
+    v_have   TEXT;
+BEGIN
+    SELECT todos.permission(t, p_user_id) INTO v_have
+    FROM public.todos t
+    WHERE t.id = p_todo_id AND t.deleted_at IS NULL;
+
+    IF v_have IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    IF array_position(v_levels, v_have) < array_position(v_levels, p_need) THEN
+        RAISE EXCEPTION 'this todo is shared with you as %; % access is required', v_have, p_need
+            USING ERRCODE = 'insufficient_privilege';
+    END IF;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =============================================================================
+-- TODO FUNCTIONS
+-- =============================================================================
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates)
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- DELETE (moves the todo to the owner's trash)
+CREATE OR REPLACE FUNCTION todos.delete(r todos.todo_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'owner');
+
+    UPDATE public.todos
+    SET deleted_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =============================================================================
+-- SHARE FUNCTIONS
+-- =============================================================================
+
+-- SHARE: grants r.email access to r.todo_id, or to the whole list when it is
+-- NULL. Sharing again changes the permission.
+CREATE OR REPLACE FUNCTION todos.share(r todos.share_request)
+RETURNS SETOF todos.share_response AS $$
+DECLARE
+    v_user_id INTEGER;
+BEGIN
+    IF r.todo_id IS NOT NULL THEN
+        PERFORM todos.authorize(r.todo_id, r.owner_id, 'owner');
+    END IF;
+
+    IF r.permission IS NULL OR r.permission NOT IN ('viewer', 'editor') THEN
+        RAISE EXCEPTION 'permission must be viewer or editor'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    SELECT id INTO v_user_id
+    FROM public.users
+    WHERE email = users.normalize_email(r.email);
+
+    IF v_user_id IS NULL THEN
+        RAISE EXCEPTION 'user not found' USING ERRCODE = 'no_data_found';
+    END IF;
+    IF v_user_id = r.owner_id THEN
+        RAISE EXCEPTION 'cannot share with yourself' USING ERRCODE = 'check_violation';
+    END IF;
+
+    INSERT INTO public.todo_shares (owner_id, todo_id, user_id, permission)
+    VALUES (r.owner_id, r.todo_id, v_user_id, r.permission)
+    ON CONFLICT (owner_id, (COALESCE(todo_id, 0)), user_id)
+    DO UPDATE SET permission = EXCLUDED.permission;
+
+    RETURN QUERY
+    SELECT s.todo_id, u.id, u.name::TEXT, u.email::TEXT, s.permission, s.created_at
+    FROM public.todo_shares s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.owner_id = r.owner_id
+      AND s.todo_id IS NOT DISTINCT FROM r.todo_id
+      AND s.user_id = v_user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UNSHARE: revokes r.user_id's share of r.todo_id (or of the whole list)
+CREATE OR REPLACE FUNCTION todos.unshare(r todos.share_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM public.todo_shares
+    WHERE owner_id = r.owner_id
+      AND todo_id IS NOT DISTINCT FROM r.todo_id
+      AND user_id = r.user_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST SHARES: collaborators of r.todo_id, or of the whole list when it is NULL
+CREATE OR REPLACE FUNCTION todos.list_shares(r todos.share_request)
+RETURNS SETOF todos.share_response AS $$
+BEGIN
+    IF r.todo_id IS NOT NULL THEN
+        PERFORM todos.authorize(r.todo_id, r.owner_id, 'owner');
+    END IF;
+
+    RETURN QUERY
+    SELECT s.todo_id, u.id, u.name::TEXT, u.email::TEXT, s.permission, s.created_at
+    FROM public.todo_shares s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.owner_id = r.owner_id
+      AND s.todo_id IS NOT DISTINCT FROM r.todo_id
+    ORDER BY s.created_at, s.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
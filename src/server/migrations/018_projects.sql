@@ -0,0 +1,503 @@
+-- Projects
+-- Schema: projects
+-- A project groups a user's todos. Todos without a project live in the inbox.
+-- Deleting a project either moves its todos to the inbox or, in cascade
+-- mode, moves them to the trash; trashed todos come back in the inbox.
+-- Only the todo owner's projects can be assigned, also by shared editors.
+
+CREATE SCHEMA IF NOT EXISTS projects;
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS projects (
+    id          SERIAL PRIMARY KEY,
+    user_id     INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name        VARCHAR(200) NOT NULL CONSTRAINT projects_name_check CHECK (TRIM(name) <> ''),
+    description TEXT,
+    created_at  TIMESTAMPTZ DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Project names are unique per user, ignoring case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_projects_user_name ON projects(user_id, LOWER(name));
+
+DROP TRIGGER IF EXISTS trigger_projects_updated_at ON projects;
+CREATE TRIGGER trigger_projects_updated_at
+    BEFORE UPDATE ON projects
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS project_id INTEGER REFERENCES projects(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_project_id ON todos(project_id) WHERE project_id IS NOT NULL;
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all project parameters
+-- delete_mode: inbox | cascade
+CREATE TYPE projects.project_request AS (
+    id          INTEGER,
+    user_id     INTEGER,
+    name        TEXT,
+    description TEXT,
+    limit_val   INTEGER,
+    offset_val  INTEGER,
+    delete_mode TEXT
+);
+
+-- OUTPUT: A project with the number of live todos in it
+CREATE TYPE projects.project_response AS (
+    id          INTEGER,
+    user_id     INTEGER,
+    name        VARCHAR(200),
+    description TEXT,
+    todo_count  INTEGER,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+-- project_id is both the project to put a todo in and a list filter;
+-- inbox lists only todos without a project
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE project_id INTEGER,
+    ADD ATTRIBUTE inbox      BOOLEAN;
+
+-- Functions returning these types are recreated below with the new column
+ALTER TYPE todos.todo_response
+    ADD ATTRIBUTE project_id INTEGER;
+
+ALTER TYPE todos.batch_result
+    ADD ATTRIBUTE project_id INTEGER;
+
+ALTER TYPE todos.shared_todo_response
+    ADD ATTRIBUTE project_id INTEGER;
+
+-- =============================================================================
+-- INTERNAL HELPERS
+-- =============================================================================
+
+CREATE OR REPLACE FUNCTION projects.todo_count(p_project_id INTEGER)
+RETURNS INTEGER AS $$
+    SELECT COUNT(*)::INTEGER
+    FROM public.todos
+    WHERE project_id = p_project_id AND deleted_at IS NULL;
+$$ LANGUAGE sql STABLE;
+
+-- Raises unless p_project_id is NULL or belongs to p_owner_id
+CREATE OR REPLACE FUNCTION projects.check_owner(p_project_id INTEGER, p_owner_id INTEGER)
+RETURNS VOID AS $$
+BEGIN
+    IF p_project_id IS NOT NULL AND NOT EXISTS (
+        SELECT 1 FROM public.projects WHERE id = p_project_id AND user_id = p_owner_id
+    ) THEN
+        RAISE EXCEPTION 'project not found' USING ERRCODE = 'no_data_found';
+    END IF;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- =============================================================================
+-- PROJECT FUNCTIONS
+-- =============================================================================
+
+-- CREATE
+CREATE OR REPLACE FUNCTION projects.create(r projects.project_request)
+RETURNS SETOF projects.project_response AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM public.projects
+        WHERE user_id = r.user_id AND LOWER(name) = LOWER(TRIM(r.name))
+    ) THEN
+        RAISE EXCEPTION 'a project with this name already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO public.projects (user_id, name, description)
+    VALUES (r.user_id, TRIM(r.name), r.description)
+    RETURNING id, user_id, name, description, 0, created_at, updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST (by name)
+CREATE OR REPLACE FUNCTION projects.list(r projects.project_request)
+RETURNS SETOF projects.project_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT p.id, p.user_id, p.name, p.description, projects.todo_count(p.id), p.created_at, p.updated_at
+    FROM public.projects p
+    WHERE p.user_id = r.user_id
+    ORDER BY LOWER(p.name), p.id
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- GET
+CREATE OR REPLACE FUNCTION projects.get(r projects.project_request)
+RETURNS SETOF projects.project_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT p.id, p.user_id, p.name, p.description, projects.todo_count(p.id), p.created_at, p.updated_at
+    FROM public.projects p
+    WHERE p.id = r.id AND p.user_id = r.user_id;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- UPDATE
+CREATE OR REPLACE FUNCTION projects.update(r projects.project_request)
+RETURNS SETOF projects.project_response AS $$
+BEGIN
+    IF r.name IS NOT NULL AND EXISTS (
+        SELECT 1 FROM public.projects
+        WHERE user_id = r.user_id AND id <> r.id AND LOWER(name) = LOWER(TRIM(r.name))
+    ) THEN
+        RAISE EXCEPTION 'a project with this name already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.projects p
+    SET
+        name = COALESCE(TRIM(r.name), p.name),
+        description = COALESCE(r.description, p.description)
+    WHERE p.id = r.id AND p.user_id = r.user_id
+    RETURNING p.id, p.user_id, p.name, p.description, projects.todo_count(p.id), p.created_at, p.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- DELETE: returns how many live todos were moved to the inbox (or the trash
+-- with delete_mode = 'cascade')
+CREATE OR REPLACE FUNCTION projects.delete(r projects.project_request)
+RETURNS INTEGER AS $$
+DECLARE
+    v_mode  TEXT := COALESCE(r.delete_mode, 'inbox');
+    v_moved INTEGER;
+BEGIN
+    IF v_mode NOT IN ('inbox', 'cascade') THEN
+        RAISE EXCEPTION 'delete mode must be inbox or cascade' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM projects.check_owner(r.id, r.user_id);
+
+    v_moved := projects.todo_count(r.id);
+
+    -- Trashed todos are detached too, so restoring them lands in the inbox
+    UPDATE public.todos
+    SET project_id = NULL,
+        deleted_at = CASE WHEN v_mode = 'cascade' THEN COALESCE(deleted_at, NOW()) ELSE deleted_at END
+    WHERE project_id = r.id;
+
+    DELETE FROM public.projects WHERE id = r.id;
+    RETURN v_moved;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =============================================================================
+-- TODO FUNCTIONS
+-- =============================================================================
+
+-- Live todos by default, the trash when r.trashed is set
+CREATE OR REPLACE FUNCTION todos.matches(t public.todos, r todos.todo_request)
+RETURNS BOOLEAN AS $$
+    SELECT t.user_id = r.user_id
+       AND (t.deleted_at IS NOT NULL) = COALESCE(r.trashed, FALSE)
+       AND (r.completed IS NULL OR t.completed = r.completed)
+       AND (r.created_after IS NULL OR t.created_at >= r.created_after)
+       AND (r.created_before IS NULL OR t.created_at < r.created_before)
+       AND (r.updated_after IS NULL OR t.updated_at >= r.updated_after)
+       AND (r.updated_before IS NULL OR t.updated_at < r.updated_before)
+       AND (r.project_id IS NULL OR t.project_id = r.project_id)
+       AND (NOT COALESCE(r.inbox, FALSE) OR t.project_id IS NULL)
+       AND (NULLIF(TRIM(r.search), '') IS NULL
+            OR t.search_vector @@ websearch_to_tsquery('english', r.search));
+$$ LANGUAGE sql STABLE;
+
+-- CREATE
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM projects.check_owner(r.project_id, r.user_id);
+
+    RETURN QUERY
+    INSERT INTO public.todos (user_id, title, description, project_id)
+    VALUES (r.user_id, r.title, r.description, r.project_id)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at, p.version, p.project_id
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates; r.project_id must
+-- belong to the todo's owner)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        project_id = COALESCE(r.project_id, project_id),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update;
+-- a NULL project_id moves the todo to the inbox)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed', 'project_id'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    IF 'project_id' = ANY(v_set) THEN
+        PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        project_id = CASE WHEN 'project_id' = ANY(v_set) THEN r.project_id ELSE project_id END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates)
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT t.id, t.user_id, t.title, t.description, t.completed,
+                       t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id
+                INTO v_todo
+                FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at, v_todo.version,
+                    v_todo.project_id)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT, t.project_id
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,515 @@
+-- Recurring todos
+-- A series repeats a todo by an RFC 5545 RRULE, expanded in an IANA time
+-- zone from dtstart, its first occurrence. The series keeps the template new
+-- occurrences copy; each todo of a series records its slot in occurrence_at.
+-- The rule is expanded by the server: completing an occurrence with
+-- todos.toggle adds the next one at r.next_occurrence_at, in the same
+-- statement. todos.update changes one occurrence; todos.update_future also
+-- changes the template, and with a new rule, zone or due_at restarts the
+-- series at this occurrence. Deleting a series keeps its todos.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS todo_series (
+    id            SERIAL PRIMARY KEY,
+    user_id       INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rrule         TEXT NOT NULL,
+    timezone      TEXT NOT NULL DEFAULT 'UTC',
+    dtstart       TIMESTAMPTZ NOT NULL,
+    title         VARCHAR(500) NOT NULL,
+    description   TEXT,
+    priority      TEXT NOT NULL DEFAULT 'medium'
+        CONSTRAINT todo_series_priority_check CHECK (priority IN ('low', 'medium', 'high', 'urgent')),
+    project_id    INTEGER REFERENCES projects(id) ON DELETE SET NULL,
+    -- How long before due_at new occurrences remind; NULL for no reminder
+    remind_offset INTERVAL,
+    created_at    TIMESTAMPTZ DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_series_user_id ON todo_series(user_id);
+
+DROP TRIGGER IF EXISTS trigger_todo_series_updated_at ON todo_series;
+CREATE TRIGGER trigger_todo_series_updated_at
+    BEFORE UPDATE ON todo_series
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS series_id     INTEGER REFERENCES todo_series(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS occurrence_at TIMESTAMPTZ;
+
+-- One todo per slot, so adding an occurrence twice is a no-op
+CREATE UNIQUE INDEX IF NOT EXISTS idx_todos_series_occurrence ON todos(series_id, occurrence_at);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- rrule and timezone start a series on create and change it in update_future;
+-- series_id names a series and filters lists; next_occurrence_at is where
+-- todos.toggle adds the next occurrence
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE rrule              TEXT,
+    ADD ATTRIBUTE timezone           TEXT,
+    ADD ATTRIBUTE series_id          INTEGER,
+    ADD ATTRIBUTE next_occurrence_at TIMESTAMPTZ;
+
+-- Functions returning these types are recreated below with the new columns
+ALTER TYPE todos.todo_response
+    ADD ATTRIBUTE series_id     INTEGER,
+    ADD ATTRIBUTE occurrence_at TIMESTAMPTZ;
+
+ALTER TYPE todos.batch_result
+    ADD ATTRIBUTE series_id     INTEGER,
+    ADD ATTRIBUTE occurrence_at TIMESTAMPTZ;
+
+ALTER TYPE todos.shared_todo_response
+    ADD ATTRIBUTE series_id     INTEGER,
+    ADD ATTRIBUTE occurrence_at TIMESTAMPTZ;
+
+-- OUTPUT: A series with its rule and template
+CREATE TYPE todos.series_response AS (
+    id          INTEGER,
+    user_id     INTEGER,
+    rrule       TEXT,
+    timezone    TEXT,
+    dtstart     TIMESTAMPTZ,
+    title       VARCHAR(500),
+    description TEXT,
+    priority    TEXT,
+    project_id  INTEGER,
+    created_at  TIMESTAMPTZ,
+    updated_at  TIMESTAMPTZ
+);
+
+-- =============================================================================
+-- HELPERS
+-- =============================================================================
+
+-- Adds the occurrence of a series at p_at from its template, unless it exists
+CREATE OR REPLACE FUNCTION todos.add_occurrence(p_series_id INTEGER, p_at TIMESTAMPTZ)
+RETURNS VOID AS $$
+    INSERT INTO public.todos (user_id, title, description, project_id, due_at, priority, remind_at, series_id, occurrence_at)
+    SELECT s.user_id, s.title, s.description, s.project_id, p_at, s.priority, p_at - s.remind_offset, s.id, p_at
+    FROM public.todo_series s
+    WHERE s.id = p_series_id
+    ON CONFLICT (series_id, occurrence_at) DO NOTHING;
+$$ LANGUAGE sql;
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- Live todos by default, the trash when r.trashed is set
+CREATE OR REPLACE FUNCTION todos.matches(t public.todos, r todos.todo_request)
+RETURNS BOOLEAN AS $$
+    SELECT t.user_id = r.user_id
+       AND (t.deleted_at IS NOT NULL) = COALESCE(r.trashed, FALSE)
+       AND (r.completed IS NULL OR t.completed = r.completed)
+       AND (r.created_after IS NULL OR t.created_at >= r.created_after)
+       AND (r.created_before IS NULL OR t.created_at < r.created_before)
+       AND (r.updated_after IS NULL OR t.updated_at >= r.updated_after)
+       AND (r.updated_before IS NULL OR t.updated_at < r.updated_before)
+       AND (r.project_id IS NULL OR t.project_id = r.project_id)
+       AND (NOT COALESCE(r.inbox, FALSE) OR t.project_id IS NULL)
+       AND (r.series_id IS NULL OR t.series_id = r.series_id)
+       AND (r.priority IS NULL OR t.priority = r.priority)
+       AND (r.due_after IS NULL OR t.due_at >= r.due_after)
+       AND (r.due_before IS NULL OR t.due_at < r.due_before)
+       AND (NOT COALESCE(r.overdue, FALSE) OR (t.due_at < NOW() AND t.completed IS NOT TRUE))
+       AND (NULLIF(TRIM(r.search), '') IS NULL
+            OR t.search_vector @@ websearch_to_tsquery('english', r.search));
+$$ LANGUAGE sql STABLE;
+
+-- CREATE (a non-NULL r.rrule starts a series whose first occurrence is this
+-- todo, at r.due_at)
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_series_id INTEGER;
+BEGIN
+    PERFORM projects.check_owner(r.project_id, r.user_id);
+
+    IF r.rrule IS NOT NULL THEN
+        INSERT INTO public.todo_series (user_id, rrule, timezone, dtstart, title, description, priority, project_id, remind_offset)
+        VALUES (r.user_id, r.rrule, COALESCE(r.timezone, 'UTC'), r.due_at, r.title, r.description,
+                COALESCE(r.priority, 'medium'), r.project_id, r.due_at - r.remind_at)
+        RETURNING id INTO v_series_id;
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO public.todos (user_id, title, description, project_id, due_at, priority, remind_at, series_id, occurrence_at)
+    VALUES (r.user_id, r.title, r.description, r.project_id, r.due_at, COALESCE(r.priority, 'medium'), r.remind_at,
+            v_series_id, CASE WHEN v_series_id IS NOT NULL THEN r.due_at END)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at, p.version, p.project_id, p.due_at, p.priority, p.remind_at, p.series_id, p.occurrence_at
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates; r.project_id must
+-- belong to the todo's owner)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        project_id = COALESCE(r.project_id, project_id),
+        due_at = COALESCE(r.due_at, due_at),
+        priority = COALESCE(r.priority, priority),
+        remind_at = COALESCE(r.remind_at, remind_at),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update;
+-- a NULL project_id moves the todo to the inbox, due_at and remind_at can be
+-- cleared, priority cannot)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed', 'project_id', 'due_at', 'priority', 'remind_at'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    IF 'project_id' = ANY(v_set) THEN
+        PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        project_id = CASE WHEN 'project_id' = ANY(v_set) THEN r.project_id ELSE project_id END,
+        due_at = CASE WHEN 'due_at' = ANY(v_set) THEN r.due_at ELSE due_at END,
+        priority = CASE WHEN 'priority' = ANY(v_set) THEN r.priority ELSE priority END,
+        remind_at = CASE WHEN 'remind_at' = ANY(v_set) THEN r.remind_at ELSE remind_at END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates). Completing an
+-- occurrence adds the next one at r.next_occurrence_at; NULL when the series
+-- has ended or the todo does not repeat.
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at
+    INTO v_todo;
+
+    IF NOT FOUND THEN
+        IF r.expected_version IS NOT NULL THEN
+            RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+                USING ERRCODE = 'VC001';
+        END IF;
+        RETURN;
+    END IF;
+
+    IF v_todo.completed AND v_todo.series_id IS NOT NULL AND r.next_occurrence_at IS NOT NULL THEN
+        PERFORM todos.add_occurrence(v_todo.series_id, r.next_occurrence_at);
+    END IF;
+
+    RETURN NEXT v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT t.id, t.user_id, t.title, t.description, t.completed,
+                       t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+                INTO v_todo
+                FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at, v_todo.version,
+                    v_todo.project_id, v_todo.due_at, v_todo.priority, v_todo.remind_at, v_todo.series_id, v_todo.occurrence_at)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE THIS AND FUTURE OCCURRENCES: todos.update on this occurrence, plus
+-- the series template and, from here on, open later occurrences. A new rule,
+-- zone or due_at restarts the series at this occurrence. The statement fails
+-- as a whole on a stale r.expected_version.
+CREATE OR REPLACE FUNCTION todos.update_future(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_series_id  INTEGER;
+    v_occurrence TIMESTAMPTZ;
+    v_due        TIMESTAMPTZ;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    SELECT t.series_id, t.occurrence_at, t.due_at INTO v_series_id, v_occurrence, v_due
+    FROM public.todos t
+    WHERE t.id = r.id;
+
+    IF v_series_id IS NULL THEN
+        RAISE EXCEPTION 'todo is not part of a series' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todo_series WHERE id = v_series_id));
+
+    UPDATE public.todo_series
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        priority = COALESCE(r.priority, priority),
+        project_id = COALESCE(r.project_id, project_id),
+        remind_offset = CASE WHEN r.remind_at IS NOT NULL THEN COALESCE(r.due_at, v_due) - r.remind_at ELSE remind_offset END,
+        rrule = COALESCE(r.rrule, rrule),
+        timezone = COALESCE(r.timezone, timezone),
+        dtstart = CASE WHEN r.rrule IS NOT NULL OR r.timezone IS NOT NULL OR r.due_at IS NOT NULL
+                       THEN COALESCE(r.due_at, v_due, v_occurrence) ELSE dtstart END
+    WHERE id = v_series_id;
+
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        priority = COALESCE(r.priority, priority),
+        project_id = COALESCE(r.project_id, project_id),
+        updated_at = NOW()
+    WHERE series_id = v_series_id AND id <> r.id
+      AND occurrence_at > v_occurrence
+      AND deleted_at IS NULL AND completed IS NOT TRUE;
+
+    RETURN QUERY SELECT * FROM todos.update(r);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SERIES: one of r.user_id's series
+CREATE OR REPLACE FUNCTION todos.series_get(r todos.todo_request)
+RETURNS SETOF todos.series_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT s.id, s.user_id, s.rrule, s.timezone, s.dtstart, s.title, s.description, s.priority, s.project_id, s.created_at, s.updated_at
+    FROM public.todo_series s
+    WHERE s.id = r.series_id AND s.user_id = r.user_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- STOP A SERIES: its todos stay, no longer linked
+CREATE OR REPLACE FUNCTION todos.series_delete(r todos.todo_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM public.todo_series
+    WHERE id = r.series_id AND user_id = r.user_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
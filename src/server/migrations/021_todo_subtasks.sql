@@ -0,0 +1,535 @@
+-- Subtasks
+-- A todo can carry a checklist of subtasks, ordered by position. The todo
+-- keeps subtasks_total and subtasks_done, so listings show progress without
+-- touching todo_subtasks; todos.subtask_list fetches the checklists of many
+-- todos at once. Changing a checklist needs editor access to the todo and
+-- bumps its version, as its progress is part of it. With r.complete_parent,
+-- completing the last open subtask completes the todo in the same statement.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS todo_subtasks (
+    id         SERIAL PRIMARY KEY,
+    todo_id    INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    title      VARCHAR(500) NOT NULL,
+    completed  BOOLEAN NOT NULL DEFAULT FALSE,
+    position   INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_subtasks_todo_id ON todo_subtasks(todo_id, position);
+
+DROP TRIGGER IF EXISTS trigger_todo_subtasks_updated_at ON todo_subtasks;
+CREATE TRIGGER trigger_todo_subtasks_updated_at
+    BEFORE UPDATE ON todo_subtasks
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+ALTER TABLE todos
+    ADD COLUMN IF NOT EXISTS subtasks_total INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS subtasks_done  INTEGER NOT NULL DEFAULT 0;
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: todo_id names the todo; ids is the new order for subtask_reorder,
+-- todo_ids the todos subtask_list reads
+CREATE TYPE todos.subtask_request AS (
+    id              INTEGER,
+    todo_id         INTEGER,
+    user_id         INTEGER,
+    title           VARCHAR(500),
+    completed       BOOLEAN,
+    complete_parent BOOLEAN,
+    ids             INTEGER[],
+    todo_ids        INTEGER[]
+);
+
+-- OUTPUT: One subtask
+CREATE TYPE todos.subtask_response AS (
+    id         INTEGER,
+    todo_id    INTEGER,
+    title      VARCHAR(500),
+    completed  BOOLEAN,
+    position   INTEGER,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+-- Functions returning these types are recreated below with the new columns
+ALTER TYPE todos.todo_response
+    ADD ATTRIBUTE subtasks_total INTEGER,
+    ADD ATTRIBUTE subtasks_done  INTEGER;
+
+ALTER TYPE todos.batch_result
+    ADD ATTRIBUTE subtasks_total INTEGER,
+    ADD ATTRIBUTE subtasks_done  INTEGER;
+
+ALTER TYPE todos.shared_todo_response
+    ADD ATTRIBUTE subtasks_total INTEGER,
+    ADD ATTRIBUTE subtasks_done  INTEGER;
+
+-- =============================================================================
+-- HELPERS
+-- =============================================================================
+
+-- Raises unless p_user_id may edit the live todo p_todo_id, then locks it so
+-- changes to one checklist run one at a time
+CREATE OR REPLACE FUNCTION todos.lock_subtasks(p_todo_id INTEGER, p_user_id INTEGER)
+RETURNS VOID AS $$
+BEGIN
+    PERFORM todos.authorize(p_todo_id, p_user_id, 'editor');
+    PERFORM 1 FROM public.todos WHERE id = p_todo_id FOR UPDATE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Recounts the subtasks of p_todo_id; with p_complete_parent a finished
+-- checklist completes the todo
+CREATE OR REPLACE FUNCTION todos.refresh_progress(p_todo_id INTEGER, p_complete_parent BOOLEAN)
+RETURNS VOID AS $$
+    UPDATE public.todos t
+    SET subtasks_total = c.total,
+        subtasks_done = c.done,
+        completed = CASE WHEN p_complete_parent AND c.total > 0 AND c.done = c.total THEN TRUE ELSE t.completed END,
+        updated_at = NOW()
+    FROM (
+        SELECT COUNT(*)::INTEGER AS total, (COUNT(*) FILTER (WHERE s.completed))::INTEGER AS done
+        FROM public.todo_subtasks s
+        WHERE s.todo_id = p_todo_id
+    ) c
+    WHERE t.id = p_todo_id;
+$$ LANGUAGE sql;
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- CREATE (a non-NULL r.rrule starts a series whose first occurrence is this
+-- todo, at r.due_at)
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_series_id INTEGER;
+BEGIN
+    PERFORM projects.check_owner(r.project_id, r.user_id);
+
+    IF r.rrule IS NOT NULL THEN
+        INSERT INTO public.todo_series (user_id, rrule, timezone, dtstart, title, description, priority, project_id, remind_offset)
+        VALUES (r.user_id, r.rrule, COALESCE(r.timezone, 'UTC'), r.due_at, r.title, r.description,
+                COALESCE(r.priority, 'medium'), r.project_id, r.due_at - r.remind_at)
+        RETURNING id INTO v_series_id;
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO public.todos (user_id, title, description, project_id, due_at, priority, remind_at, series_id, occurrence_at)
+    VALUES (r.user_id, r.title, r.description, r.project_id, r.due_at, COALESCE(r.priority, 'medium'), r.remind_at,
+            v_series_id, CASE WHEN v_series_id IS NOT NULL THEN r.due_at END)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at, p.version, p.project_id, p.due_at, p.priority, p.remind_at, p.series_id, p.occurrence_at, p.subtasks_total, p.subtasks_done
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates; r.project_id must
+-- belong to the todo's owner)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        project_id = COALESCE(r.project_id, project_id),
+        due_at = COALESCE(r.due_at, due_at),
+        priority = COALESCE(r.priority, priority),
+        remind_at = COALESCE(r.remind_at, remind_at),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update;
+-- a NULL project_id moves the todo to the inbox, due_at and remind_at can be
+-- cleared, priority cannot)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed', 'project_id', 'due_at', 'priority', 'remind_at'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    IF 'project_id' = ANY(v_set) THEN
+        PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        project_id = CASE WHEN 'project_id' = ANY(v_set) THEN r.project_id ELSE project_id END,
+        due_at = CASE WHEN 'due_at' = ANY(v_set) THEN r.due_at ELSE due_at END,
+        priority = CASE WHEN 'priority' = ANY(v_set) THEN r.priority ELSE priority END,
+        remind_at = CASE WHEN 'remind_at' = ANY(v_set) THEN r.remind_at ELSE remind_at END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates). Completing an
+-- occurrence adds the next one at r.next_occurrence_at; NULL when the series
+-- has ended or the todo does not repeat.
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done
+    INTO v_todo;
+
+    IF NOT FOUND THEN
+        IF r.expected_version IS NOT NULL THEN
+            RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+                USING ERRCODE = 'VC001';
+        END IF;
+        RETURN;
+    END IF;
+
+    IF v_todo.completed AND v_todo.series_id IS NOT NULL AND r.next_occurrence_at IS NOT NULL THEN
+        PERFORM todos.add_occurrence(v_todo.series_id, r.next_occurrence_at);
+    END IF;
+
+    RETURN NEXT v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done;
+END;
+$$ LANGUAGE plpgsql;
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT t.id, t.user_id, t.title, t.description, t.completed,
+                       t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+                INTO v_todo
+                FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at, v_todo.version,
+                    v_todo.project_id, v_todo.due_at, v_todo.priority, v_todo.remind_at, v_todo.series_id, v_todo.occurrence_at,
+                    v_todo.subtasks_total, v_todo.subtasks_done)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SUBTASKS OF MANY TODOS: those of r.todo_ids that r.user_id can see, in
+-- checklist order
+CREATE OR REPLACE FUNCTION todos.subtask_list(r todos.subtask_request)
+RETURNS SETOF todos.subtask_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT s.id, s.todo_id, s.title, s.completed, s.position, s.created_at, s.updated_at
+    FROM public.todo_subtasks s
+    JOIN public.todos t ON t.id = s.todo_id
+    WHERE s.todo_id = ANY(r.todo_ids)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY s.todo_id, s.position, s.id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ADD: appends a subtask to the checklist of r.todo_id
+CREATE OR REPLACE FUNCTION todos.subtask_add(r todos.subtask_request)
+RETURNS SETOF todos.subtask_response AS $$
+DECLARE
+    v_subtask todos.subtask_response;
+BEGIN
+    PERFORM todos.lock_subtasks(r.todo_id, r.user_id);
+
+    INSERT INTO public.todo_subtasks (todo_id, title, position)
+    SELECT r.todo_id, r.title, COALESCE(MAX(s.position), 0) + 1
+    FROM public.todo_subtasks s
+    WHERE s.todo_id = r.todo_id
+    RETURNING id, todo_id, title, completed, position, created_at, updated_at
+    INTO v_subtask;
+
+    PERFORM todos.refresh_progress(r.todo_id, FALSE);
+    RETURN NEXT v_subtask;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE: renames, completes or reopens a subtask. r.complete_parent with
+-- r.completed completes the todo once no subtask is open.
+CREATE OR REPLACE FUNCTION todos.subtask_update(r todos.subtask_request)
+RETURNS SETOF todos.subtask_response AS $$
+DECLARE
+    v_subtask todos.subtask_response;
+BEGIN
+    PERFORM todos.lock_subtasks(r.todo_id, r.user_id);
+
+    UPDATE public.todo_subtasks
+    SET
+        title = COALESCE(r.title, title),
+        completed = COALESCE(r.completed, completed)
+    WHERE id = r.id AND todo_id = r.todo_id
+    RETURNING id, todo_id, title, completed, position, created_at, updated_at
+    INTO v_subtask;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'subtask not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    PERFORM todos.refresh_progress(r.todo_id, COALESCE(r.complete_parent, FALSE) AND COALESCE(r.completed, FALSE));
+    RETURN NEXT v_subtask;
+END;
+$$ LANGUAGE plpgsql;
+
+-- DELETE
+CREATE OR REPLACE FUNCTION todos.subtask_delete(r todos.subtask_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    PERFORM todos.lock_subtasks(r.todo_id, r.user_id);
+
+    DELETE FROM public.todo_subtasks
+    WHERE id = r.id AND todo_id = r.todo_id;
+
+    IF NOT FOUND THEN
+        RETURN FALSE;
+    END IF;
+
+    PERFORM todos.refresh_progress(r.todo_id, FALSE);
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- REORDER: r.ids lists every subtask of r.todo_id once, in the new order
+CREATE OR REPLACE FUNCTION todos.subtask_reorder(r todos.subtask_request)
+RETURNS SETOF todos.subtask_response AS $$
+BEGIN
+    PERFORM todos.lock_subtasks(r.todo_id, r.user_id);
+
+    IF (SELECT COALESCE(array_agg(s.id ORDER BY s.id), '{}') FROM public.todo_subtasks s WHERE s.todo_id = r.todo_id)
+       IS DISTINCT FROM (SELECT COALESCE(array_agg(x ORDER BY x), '{}') FROM unnest(r.ids) x) THEN
+        RAISE EXCEPTION 'ids must list every subtask of the todo exactly once'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    UPDATE public.todo_subtasks s
+    SET position = o.ord
+    FROM unnest(r.ids) WITH ORDINALITY AS o(id, ord)
+    WHERE s.id = o.id AND s.position <> o.ord;
+
+    PERFORM todos.refresh_progress(r.todo_id, FALSE);
+
+    RETURN QUERY
+    SELECT s.id, s.todo_id, s.title, s.completed, s.position, s.created_at, s.updated_at
+    FROM public.todo_subtasks s
+    WHERE s.todo_id = r.todo_id
+    ORDER BY s.position;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,594 @@
+-- Tags
+-- Schema: tags
+-- A tag labels todos; each user has their own, unique ignoring case. Todos
+-- take tags by name through r.tags on create, update and patch, and tags
+-- missing from the owner's set are created on the way; todo responses carry
+-- the names. Lists filter by r.tags: todos with any of them by default, with
+-- all of them when r.tag_mode = 'all'. Renaming or deleting a tag bumps the
+-- version of the todos it labels, as their tags are part of them.
+
+CREATE SCHEMA IF NOT EXISTS tags;
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS tags (
+    id         SERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name       VARCHAR(50) NOT NULL CONSTRAINT tags_name_check CHECK (TRIM(name) <> ''),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Tag names are unique per user, ignoring case
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags(user_id, LOWER(name));
+
+DROP TRIGGER IF EXISTS trigger_tags_updated_at ON tags;
+CREATE TRIGGER trigger_tags_updated_at
+    BEFORE UPDATE ON tags
+    FOR EACH ROW
+    EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+    todo_id INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    tag_id  INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (todo_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_tags_tag_id ON todo_tags(tag_id);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all tag parameters
+CREATE TYPE tags.tag_request AS (
+    id         INTEGER,
+    user_id    INTEGER,
+    name       TEXT,
+    limit_val  INTEGER,
+    offset_val INTEGER
+);
+
+-- OUTPUT: A tag with the number of live todos it labels
+CREATE TYPE tags.tag_response AS (
+    id         INTEGER,
+    user_id    INTEGER,
+    name       VARCHAR(50),
+    todo_count INTEGER,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ
+);
+
+-- tags are both the names to give a todo and a list filter; tag_mode is
+-- any | all
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE tags     TEXT[],
+    ADD ATTRIBUTE tag_mode TEXT;
+
+-- Functions returning these types are recreated below with the new column
+ALTER TYPE todos.todo_response
+    ADD ATTRIBUTE tags TEXT[];
+
+ALTER TYPE todos.batch_result
+    ADD ATTRIBUTE tags TEXT[];
+
+ALTER TYPE todos.shared_todo_response
+    ADD ATTRIBUTE tags TEXT[];
+
+-- =============================================================================
+-- INTERNAL HELPERS
+-- =============================================================================
+
+CREATE OR REPLACE FUNCTION tags.todo_count(p_tag_id INTEGER)
+RETURNS INTEGER AS $$
+    SELECT COUNT(*)::INTEGER
+    FROM public.todo_tags tt
+    JOIN public.todos t ON t.id = tt.todo_id
+    WHERE tt.tag_id = p_tag_id AND t.deleted_at IS NULL;
+$$ LANGUAGE sql STABLE;
+
+-- Bumps the version of the todos p_tag_id labels
+CREATE OR REPLACE FUNCTION tags.touch_todos(p_tag_id INTEGER)
+RETURNS VOID AS $$
+    UPDATE public.todos
+    SET updated_at = NOW()
+    WHERE id IN (SELECT todo_id FROM public.todo_tags WHERE tag_id = p_tag_id);
+$$ LANGUAGE sql;
+
+-- The tag names of a todo, by name
+CREATE OR REPLACE FUNCTION todos.tag_names(p_todo_id INTEGER)
+RETURNS TEXT[] AS $$
+    SELECT ARRAY(
+        SELECT g.name::TEXT
+        FROM public.todo_tags tt
+        JOIN public.tags g ON g.id = tt.tag_id
+        WHERE tt.todo_id = p_todo_id
+        ORDER BY LOWER(g.name)
+    );
+$$ LANGUAGE sql STABLE;
+
+-- How many of p_names, lowercase and distinct, label p_todo_id
+CREATE OR REPLACE FUNCTION todos.tag_matches(p_todo_id INTEGER, p_names TEXT[])
+RETURNS INTEGER AS $$
+    SELECT COUNT(*)::INTEGER
+    FROM public.todo_tags tt
+    JOIN public.tags g ON g.id = tt.tag_id
+    WHERE tt.todo_id = p_todo_id AND LOWER(g.name) = ANY(p_names);
+$$ LANGUAGE sql STABLE;
+
+-- Replaces the tags of p_todo_id with p_names, creating the owner's missing
+-- tags; NULL leaves them alone
+CREATE OR REPLACE FUNCTION todos.set_tags(p_todo_id INTEGER, p_names TEXT[])
+RETURNS VOID AS $$
+DECLARE
+    v_owner INTEGER;
+    v_lower TEXT[];
+BEGIN
+    IF p_names IS NULL THEN
+        RETURN;
+    END IF;
+
+    SELECT user_id INTO v_owner FROM public.todos WHERE id = p_todo_id;
+    SELECT COALESCE(array_agg(LOWER(TRIM(n))), '{}') INTO v_lower FROM unnest(p_names) n;
+
+    INSERT INTO public.tags (user_id, name)
+    SELECT v_owner, TRIM(n) FROM unnest(p_names) n
+    ON CONFLICT (user_id, (LOWER(name))) DO NOTHING;
+
+    DELETE FROM public.todo_tags tt
+    USING public.tags g
+    WHERE tt.todo_id = p_todo_id AND g.id = tt.tag_id
+      AND NOT LOWER(g.name) = ANY(v_lower);
+
+    INSERT INTO public.todo_tags (todo_id, tag_id)
+    SELECT p_todo_id, g.id
+    FROM public.tags g
+    WHERE g.user_id = v_owner AND LOWER(g.name) = ANY(v_lower)
+    ON CONFLICT DO NOTHING;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =============================================================================
+-- TAG FUNCTIONS
+-- =============================================================================
+
+-- CREATE
+CREATE OR REPLACE FUNCTION tags.create(r tags.tag_request)
+RETURNS SETOF tags.tag_response AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM public.tags
+        WHERE user_id = r.user_id AND LOWER(name) = LOWER(TRIM(r.name))
+    ) THEN
+        RAISE EXCEPTION 'a tag with this name already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    RETURN QUERY
+    INSERT INTO public.tags (user_id, name)
+    VALUES (r.user_id, TRIM(r.name))
+    RETURNING id, user_id, name, 0, created_at, updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST (by name)
+CREATE OR REPLACE FUNCTION tags.list(r tags.tag_request)
+RETURNS SETOF tags.tag_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT g.id, g.user_id, g.name, tags.todo_count(g.id), g.created_at, g.updated_at
+    FROM public.tags g
+    WHERE g.user_id = r.user_id
+    ORDER BY LOWER(g.name), g.id
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- GET
+CREATE OR REPLACE FUNCTION tags.get(r tags.tag_request)
+RETURNS SETOF tags.tag_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT g.id, g.user_id, g.name, tags.todo_count(g.id), g.created_at, g.updated_at
+    FROM public.tags g
+    WHERE g.id = r.id AND g.user_id = r.user_id;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- UPDATE (rename)
+CREATE OR REPLACE FUNCTION tags.update(r tags.tag_request)
+RETURNS SETOF tags.tag_response AS $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM public.tags
+        WHERE user_id = r.user_id AND id <> r.id AND LOWER(name) = LOWER(TRIM(r.name))
+    ) THEN
+        RAISE EXCEPTION 'a tag with this name already exists' USING ERRCODE = 'unique_violation';
+    END IF;
+
+    PERFORM tags.touch_todos(g.id) FROM public.tags g WHERE g.id = r.id AND g.user_id = r.user_id;
+
+    RETURN QUERY
+    UPDATE public.tags g
+    SET name = TRIM(r.name)
+    WHERE g.id = r.id AND g.user_id = r.user_id
+    RETURNING g.id, g.user_id, g.name, tags.todo_count(g.id), g.created_at, g.updated_at;
+END;
+$$ LANGUAGE plpgsql;
+
+-- DELETE: untags its todos
+CREATE OR REPLACE FUNCTION tags.delete(r tags.tag_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    PERFORM tags.touch_todos(g.id) FROM public.tags g WHERE g.id = r.id AND g.user_id = r.user_id;
+
+    DELETE FROM public.tags WHERE id = r.id AND user_id = r.user_id;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
+
+-- =============================================================================
+-- TODO FUNCTIONS
+-- =============================================================================
+
+-- Live todos by default, the trash when r.trashed is set
+CREATE OR REPLACE FUNCTION todos.matches(t public.todos, r todos.todo_request)
+RETURNS BOOLEAN AS $$
+    SELECT t.user_id = r.user_id
+       AND (t.deleted_at IS NOT NULL) = COALESCE(r.trashed, FALSE)
+       AND (r.completed IS NULL OR t.completed = r.completed)
+       AND (r.created_after IS NULL OR t.created_at >= r.created_after)
+       AND (r.created_before IS NULL OR t.created_at < r.created_before)
+       AND (r.updated_after IS NULL OR t.updated_at >= r.updated_after)
+       AND (r.updated_before IS NULL OR t.updated_at < r.updated_before)
+       AND (r.project_id IS NULL OR t.project_id = r.project_id)
+       AND (NOT COALESCE(r.inbox, FALSE) OR t.project_id IS NULL)
+       AND (r.series_id IS NULL OR t.series_id = r.series_id)
+       AND (r.tags IS NULL OR todos.tag_matches(t.id, r.tags) >= CASE WHEN r.tag_mode = 'all' THEN cardinality(r.tags) ELSE 1 END)
+       AND (r.priority IS NULL OR t.priority = r.priority)
+       AND (r.due_after IS NULL OR t.due_at >= r.due_after)
+       AND (r.due_before IS NULL OR t.due_at < r.due_before)
+       AND (NOT COALESCE(r.overdue, FALSE) OR (t.due_at < NOW() AND t.completed IS NOT TRUE))
+       AND (NULLIF(TRIM(r.search), '') IS NULL
+            OR t.search_vector @@ websearch_to_tsquery('english', r.search));
+$$ LANGUAGE sql STABLE;
+
+-- CREATE (a non-NULL r.rrule starts a series whose first occurrence is this
+-- todo, at r.due_at; r.tags are created as needed)
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_series_id INTEGER;
+    v_id        INTEGER;
+BEGIN
+    PERFORM projects.check_owner(r.project_id, r.user_id);
+
+    IF r.rrule IS NOT NULL THEN
+        INSERT INTO public.todo_series (user_id, rrule, timezone, dtstart, title, description, priority, project_id, remind_offset)
+        VALUES (r.user_id, r.rrule, COALESCE(r.timezone, 'UTC'), r.due_at, r.title, r.description,
+                COALESCE(r.priority, 'medium'), r.project_id, r.due_at - r.remind_at)
+        RETURNING id INTO v_series_id;
+    END IF;
+
+    INSERT INTO public.todos (user_id, title, description, project_id, due_at, priority, remind_at, series_id, occurrence_at)
+    VALUES (r.user_id, r.title, r.description, r.project_id, r.due_at, COALESCE(r.priority, 'medium'), r.remind_at,
+            v_series_id, CASE WHEN v_series_id IS NOT NULL THEN r.due_at END)
+    RETURNING id INTO v_id;
+
+    PERFORM todos.set_tags(v_id, r.tags);
+
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+    FROM public.todos t
+    WHERE t.id = v_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at, p.version, p.project_id, p.due_at, p.priority, p.remind_at, p.series_id, p.occurrence_at, p.subtasks_total, p.subtasks_done, todos.tag_names(p.id)
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates; r.project_id must
+-- belong to the todo's owner; non-NULL r.tags replace the todo's tags)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    PERFORM todos.set_tags(r.id, r.tags);
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        project_id = COALESCE(r.project_id, project_id),
+        due_at = COALESCE(r.due_at, due_at),
+        priority = COALESCE(r.priority, priority),
+        remind_at = COALESCE(r.remind_at, remind_at),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id);
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update;
+-- a NULL project_id moves the todo to the inbox, due_at and remind_at can be
+-- cleared, priority cannot; NULL tags remove every tag)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed', 'project_id', 'due_at', 'priority', 'remind_at', 'tags'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    IF 'project_id' = ANY(v_set) THEN
+        PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    END IF;
+    IF 'tags' = ANY(v_set) THEN
+        PERFORM todos.set_tags(r.id, COALESCE(r.tags, '{}'));
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        project_id = CASE WHEN 'project_id' = ANY(v_set) THEN r.project_id ELSE project_id END,
+        due_at = CASE WHEN 'due_at' = ANY(v_set) THEN r.due_at ELSE due_at END,
+        priority = CASE WHEN 'priority' = ANY(v_set) THEN r.priority ELSE priority END,
+        remind_at = CASE WHEN 'remind_at' = ANY(v_set) THEN r.remind_at ELSE remind_at END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id);
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates). Completing an
+-- occurrence adds the next one at r.next_occurrence_at, with the same tags;
+-- NULL when the series has ended or the todo does not repeat.
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id)
+    INTO v_todo;
+
+    IF NOT FOUND THEN
+        IF r.expected_version IS NOT NULL THEN
+            RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+                USING ERRCODE = 'VC001';
+        END IF;
+        RETURN;
+    END IF;
+
+    IF v_todo.completed AND v_todo.series_id IS NOT NULL AND r.next_occurrence_at IS NOT NULL THEN
+        PERFORM todos.add_occurrence(v_todo.series_id, r.next_occurrence_at);
+
+        INSERT INTO public.todo_tags (todo_id, tag_id)
+        SELECT n.id, tt.tag_id
+        FROM public.todos n
+        JOIN public.todo_tags tt ON tt.todo_id = v_todo.id
+        WHERE n.series_id = v_todo.series_id AND n.occurrence_at = r.next_occurrence_at
+        ON CONFLICT DO NOTHING;
+    END IF;
+
+    RETURN NEXT v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id);
+END;
+$$ LANGUAGE plpgsql;
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT t.id, t.user_id, t.title, t.description, t.completed,
+                       t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+                INTO v_todo
+                FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at, v_todo.version,
+                    v_todo.project_id, v_todo.due_at, v_todo.priority, v_todo.remind_at, v_todo.series_id, v_todo.occurrence_at,
+                    v_todo.subtasks_total, v_todo.subtasks_done, v_todo.tags)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id)
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,581 @@
+-- Manual ordering
+-- Each todo has a position in its owner's list: a rank, a string of base-36
+-- digits compared bytewise. Moving a todo gives it a rank between its new
+-- neighbours, so a move writes one row; new todos go to the top. Ranks grow
+-- when todos keep landing in the same gap, and once one would pass 50
+-- characters the owner's ranks are respaced in the same transaction.
+-- Positions belong to the list rather than the todo: changing only a
+-- position bumps neither version nor updated_at, so a drag does not
+-- invalidate the ETag of an edit in progress.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+ALTER TABLE todos ADD COLUMN IF NOT EXISTS position TEXT COLLATE "C";
+
+-- =============================================================================
+-- INTERNAL HELPERS
+-- =============================================================================
+
+-- A rank strictly between p_lo and p_hi; NULL is the open end. No rank ends
+-- in '0', which keeps room below every rank.
+CREATE OR REPLACE FUNCTION todos.rank_between(p_lo TEXT, p_hi TEXT)
+RETURNS TEXT AS $$
+DECLARE
+    c_digits CONSTANT TEXT := '0123456789abcdefghijklmnopqrstuvwxyz';
+    v_lo TEXT := COALESCE(p_lo, '');
+    v_n  INTEGER := 0;
+    v_a  INTEGER;
+    v_b  INTEGER;
+BEGIN
+    IF p_hi IS NOT NULL THEN
+        IF v_lo COLLATE "C" >= p_hi COLLATE "C" THEN
+            RAISE EXCEPTION 'rank % does not sort before %', v_lo, p_hi
+                USING ERRCODE = 'invalid_parameter_value';
+        END IF;
+
+        -- Keep the common prefix, reading p_lo as padded with '0'
+        WHILE v_n < length(p_hi)
+          AND (CASE WHEN v_n < length(v_lo) THEN substr(v_lo, v_n + 1, 1) ELSE '0' END) = substr(p_hi, v_n + 1, 1) LOOP
+            v_n := v_n + 1;
+        END LOOP;
+        IF v_n > 0 THEN
+            RETURN substr(p_hi, 1, v_n) || todos.rank_between(substr(v_lo, v_n + 1), substr(p_hi, v_n + 1));
+        END IF;
+    END IF;
+
+    v_a := CASE WHEN v_lo = '' THEN 0 ELSE strpos(c_digits, substr(v_lo, 1, 1)) - 1 END;
+    v_b := CASE WHEN p_hi IS NULL THEN 36 ELSE strpos(c_digits, substr(p_hi, 1, 1)) - 1 END;
+
+    IF v_b - v_a > 1 THEN
+        RETURN substr(c_digits, (v_a + v_b) / 2 + 1, 1);
+    END IF;
+    -- Adjacent digits: p_hi's first digit sorts between when more follow it,
+    -- otherwise keep p_lo's and go one digit deeper
+    IF p_hi IS NOT NULL AND length(p_hi) > 1 THEN
+        RETURN substr(p_hi, 1, 1);
+    END IF;
+    RETURN substr(c_digits, v_a + 1, 1) || todos.rank_between(substr(v_lo, 2), NULL);
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- The p_i-th of p_n evenly spaced ranks, all of the same length
+CREATE OR REPLACE FUNCTION todos.rank_at(p_i BIGINT, p_n BIGINT)
+RETURNS TEXT AS $$
+DECLARE
+    c_digits CONSTANT TEXT := '0123456789abcdefghijklmnopqrstuvwxyz';
+    v_rank  TEXT := '';
+    v_i     BIGINT := p_i;
+    v_width INTEGER := 1;
+    v_max   BIGINT := 36;
+BEGIN
+    WHILE v_max <= p_n LOOP
+        v_width := v_width + 1;
+        v_max := v_max * 36;
+    END LOOP;
+    WHILE v_i > 0 LOOP
+        v_rank := substr(c_digits, (v_i % 36)::INTEGER + 1, 1) || v_rank;
+        v_i := v_i / 36;
+    END LOOP;
+    RETURN lpad(v_rank, v_width, '0') || 'i';
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Serializes changes to p_owner's order until the transaction ends
+CREATE OR REPLACE FUNCTION todos.lock_positions(p_owner INTEGER)
+RETURNS VOID AS $$
+    SELECT pg_advisory_xact_lock(hashtext('todos.position'), p_owner);
+$$ LANGUAGE sql;
+
+-- Respaces p_owner's ranks, keeping their order
+CREATE OR REPLACE FUNCTION todos.rebalance(p_owner INTEGER)
+RETURNS VOID AS $$
+    UPDATE public.todos t
+    SET position = todos.rank_at(o.n, o.total)
+    FROM (
+        SELECT id, row_number() OVER (ORDER BY position, id) AS n, COUNT(*) OVER () AS total
+        FROM public.todos
+        WHERE user_id = p_owner
+    ) o
+    WHERE t.id = o.id;
+$$ LANGUAGE sql;
+
+-- New todos go to the top of their owner's list
+CREATE OR REPLACE FUNCTION todos.assign_position()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.position IS NULL THEN
+        PERFORM todos.lock_positions(NEW.user_id);
+        NEW.position := todos.rank_between(NULL, (SELECT MIN(position) FROM public.todos WHERE user_id = NEW.user_id));
+        IF length(NEW.position) > 50 THEN
+            PERFORM todos.rebalance(NEW.user_id);
+            NEW.position := todos.rank_between(NULL, (SELECT MIN(position) FROM public.todos WHERE user_id = NEW.user_id));
+        END IF;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_todos_position ON todos;
+CREATE TRIGGER trigger_todos_position
+    BEFORE INSERT ON todos
+    FOR EACH ROW
+    EXECUTE FUNCTION todos.assign_position();
+
+-- A change of position alone is not a change to the todo. trigger_todos_updated_at
+-- runs first, so its timestamp is put back here.
+CREATE OR REPLACE FUNCTION todos.bump_version()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.position IS DISTINCT FROM OLD.position
+       AND to_jsonb(NEW) - 'position' - 'updated_at' = to_jsonb(OLD) - 'position' - 'updated_at' THEN
+        NEW.updated_at = OLD.updated_at;
+        RETURN NEW;
+    END IF;
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Existing todos keep the default order, newest first
+UPDATE todos t
+SET position = todos.rank_at(o.n, o.total)
+FROM (
+    SELECT id,
+           row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC, id DESC) AS n,
+           COUNT(*) OVER (PARTITION BY user_id) AS total
+    FROM todos
+) o
+WHERE t.id = o.id;
+
+ALTER TABLE todos ALTER COLUMN position SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_todos_user_position ON todos(user_id, position);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- before_id and after_id are the neighbours of todos.move
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE before_id INTEGER,
+    ADD ATTRIBUTE after_id  INTEGER;
+
+-- Functions returning these types are recreated below with the new column
+ALTER TYPE todos.todo_response
+    ADD ATTRIBUTE position TEXT;
+
+ALTER TYPE todos.batch_result
+    ADD ATTRIBUTE position TEXT;
+
+ALTER TYPE todos.shared_todo_response
+    ADD ATTRIBUTE position TEXT;
+
+-- =============================================================================
+-- TODO FUNCTIONS
+-- =============================================================================
+
+-- MOVE: puts r.id right after r.after_id or right before r.before_id in its
+-- owner's list; with both, between them. Only the owner orders their todos.
+-- Ties and overlong ranks respace the list first.
+CREATE OR REPLACE FUNCTION todos.move(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_lo   TEXT;
+    v_hi   TEXT;
+    v_rank TEXT;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'owner');
+
+    IF r.before_id IS NULL AND r.after_id IS NULL THEN
+        RAISE EXCEPTION 'before_id or after_id is required' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+    IF r.id = r.before_id OR r.id = r.after_id OR r.before_id = r.after_id THEN
+        RAISE EXCEPTION 'before_id and after_id must be two other todos' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.lock_positions(r.user_id);
+
+    FOR attempt IN 1..2 LOOP
+        IF r.after_id IS NOT NULL THEN
+            SELECT position INTO v_lo FROM public.todos
+            WHERE id = r.after_id AND user_id = r.user_id AND deleted_at IS NULL;
+            IF NOT FOUND THEN
+                RAISE EXCEPTION 'todo % not found', r.after_id USING ERRCODE = 'no_data_found';
+            END IF;
+        END IF;
+        IF r.before_id IS NOT NULL THEN
+            SELECT position INTO v_hi FROM public.todos
+            WHERE id = r.before_id AND user_id = r.user_id AND deleted_at IS NULL;
+            IF NOT FOUND THEN
+                RAISE EXCEPTION 'todo % not found', r.before_id USING ERRCODE = 'no_data_found';
+            END IF;
+        END IF;
+
+        -- With one neighbour given, the other is the todo next to it
+        IF r.before_id IS NULL THEN
+            SELECT MIN(position) INTO v_hi FROM public.todos
+            WHERE user_id = r.user_id AND id <> r.id AND position > v_lo;
+        ELSIF r.after_id IS NULL THEN
+            SELECT MAX(position) INTO v_lo FROM public.todos
+            WHERE user_id = r.user_id AND id <> r.id AND position < v_hi;
+        ELSIF v_lo > v_hi THEN
+            RAISE EXCEPTION 'todo % comes after todo %', r.after_id, r.before_id
+                USING ERRCODE = 'invalid_parameter_value';
+        END IF;
+
+        IF v_lo IS DISTINCT FROM v_hi THEN
+            v_rank := todos.rank_between(v_lo, v_hi);
+            EXIT WHEN length(v_rank) <= 50;
+        END IF;
+        PERFORM todos.rebalance(r.user_id);
+    END LOOP;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET position = v_rank
+    WHERE id = r.id
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+END;
+$$ LANGUAGE plpgsql;
+
+-- CREATE (a non-NULL r.rrule starts a series whose first occurrence is this
+-- todo, at r.due_at; r.tags are created as needed)
+CREATE OR REPLACE FUNCTION todos.create(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_series_id INTEGER;
+    v_id        INTEGER;
+BEGIN
+    PERFORM projects.check_owner(r.project_id, r.user_id);
+
+    IF r.rrule IS NOT NULL THEN
+        INSERT INTO public.todo_series (user_id, rrule, timezone, dtstart, title, description, priority, project_id, remind_offset)
+        VALUES (r.user_id, r.rrule, COALESCE(r.timezone, 'UTC'), r.due_at, r.title, r.description,
+                COALESCE(r.priority, 'medium'), r.project_id, r.due_at - r.remind_at)
+        RETURNING id INTO v_series_id;
+    END IF;
+
+    INSERT INTO public.todos (user_id, title, description, project_id, due_at, priority, remind_at, series_id, occurrence_at)
+    VALUES (r.user_id, r.title, r.description, r.project_id, r.due_at, COALESCE(r.priority, 'medium'), r.remind_at,
+            v_series_id, CASE WHEN v_series_id IS NOT NULL THEN r.due_at END)
+    RETURNING id INTO v_id;
+
+    PERFORM todos.set_tags(v_id, r.tags);
+
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+    FROM public.todos t
+    WHERE t.id = v_id;
+END;
+$$ LANGUAGE plpgsql;
+
+-- LIST (sort_by position is the owner's manual order)
+CREATE OR REPLACE FUNCTION todos.list(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_sort TEXT    := COALESCE(r.sort_by, 'created_at');
+    v_desc BOOLEAN := COALESCE(r.sort_desc, TRUE);
+BEGIN
+    IF v_sort NOT IN ('title', 'created_at', 'updated_at', 'due_at', 'position') THEN
+        RAISE EXCEPTION 'invalid sort field: %', v_sort
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NOT NULL AND (v_sort <> 'created_at' OR NOT v_desc) THEN
+        RAISE EXCEPTION 'cursor pagination requires the default created_at descending sort'
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    IF r.cursor_id IS NULL THEN
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+        ORDER BY
+            CASE WHEN v_sort = 'title'      AND NOT v_desc THEN t.title END ASC,
+            CASE WHEN v_sort = 'title'      AND v_desc     THEN t.title END DESC,
+            CASE WHEN v_sort = 'updated_at' AND NOT v_desc THEN t.updated_at END ASC,
+            CASE WHEN v_sort = 'updated_at' AND v_desc     THEN t.updated_at END DESC,
+            CASE WHEN v_sort = 'due_at'     AND NOT v_desc THEN t.due_at END ASC NULLS LAST,
+            CASE WHEN v_sort = 'due_at'     AND v_desc     THEN t.due_at END DESC NULLS LAST,
+            CASE WHEN v_sort = 'position'   AND NOT v_desc THEN t.position END ASC,
+            CASE WHEN v_sort = 'position'   AND v_desc     THEN t.position END DESC,
+            CASE WHEN NOT v_desc THEN t.created_at END ASC,
+            CASE WHEN v_desc     THEN t.created_at END DESC,
+            CASE WHEN NOT v_desc THEN t.id END ASC,
+            CASE WHEN v_desc     THEN t.id END DESC
+        LIMIT COALESCE(r.limit_val, 100)
+        OFFSET COALESCE(r.offset_val, 0);
+    ELSIF r.cursor_dir = 'prev' THEN
+        RETURN QUERY
+        SELECT p.id, p.user_id, p.title, p.description, p.completed, p.created_at, p.updated_at, p.deleted_at, p.version, p.project_id, p.due_at, p.priority, p.remind_at, p.series_id, p.occurrence_at, p.subtasks_total, p.subtasks_done, todos.tag_names(p.id), p.position
+        FROM (
+            SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, t.position
+            FROM public.todos t
+            WHERE todos.matches(t, r)
+              AND (t.created_at, t.id) > (r.cursor_created_at, r.cursor_id)
+            ORDER BY t.created_at ASC, t.id ASC
+            LIMIT COALESCE(r.limit_val, 100)
+        ) p
+        ORDER BY p.created_at DESC, p.id DESC;
+    ELSE
+        RETURN QUERY
+        SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+        FROM public.todos t
+        WHERE todos.matches(t, r)
+          AND (t.created_at, t.id) < (r.cursor_created_at, r.cursor_id)
+        ORDER BY t.created_at DESC, t.id DESC
+        LIMIT COALESCE(r.limit_val, 100);
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION todos.get(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+    FROM public.todos t
+    WHERE t.id = r.id AND t.deleted_at IS NULL
+      AND todos.permission(t, r.user_id) IS NOT NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE (r.expected_version guards against lost updates; r.project_id must
+-- belong to the todo's owner; non-NULL r.tags replace the todo's tags)
+CREATE OR REPLACE FUNCTION todos.update(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    PERFORM todos.set_tags(r.id, r.tags);
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = COALESCE(r.title, title),
+        description = COALESCE(r.description, description),
+        completed = COALESCE(r.completed, completed),
+        project_id = COALESCE(r.project_id, project_id),
+        due_at = COALESCE(r.due_at, due_at),
+        priority = COALESCE(r.priority, priority),
+        remind_at = COALESCE(r.remind_at, remind_at),
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- PATCH (r.expected_version guards against lost updates, as in todos.update;
+-- a NULL project_id moves the todo to the inbox, due_at and remind_at can be
+-- cleared, priority cannot; NULL tags remove every tag)
+CREATE OR REPLACE FUNCTION todos.patch(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_set TEXT[] := COALESCE(r.set_fields, '{}');
+BEGIN
+    IF NOT v_set <@ ARRAY['title', 'description', 'completed', 'project_id', 'due_at', 'priority', 'remind_at', 'tags'] THEN
+        RAISE EXCEPTION 'cannot patch fields: %', array_to_string(v_set, ', ')
+            USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+    IF 'project_id' = ANY(v_set) THEN
+        PERFORM projects.check_owner(r.project_id, (SELECT user_id FROM public.todos WHERE id = r.id));
+    END IF;
+    IF 'tags' = ANY(v_set) THEN
+        PERFORM todos.set_tags(r.id, COALESCE(r.tags, '{}'));
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = CASE WHEN 'title' = ANY(v_set) THEN r.title ELSE title END,
+        description = CASE WHEN 'description' = ANY(v_set) THEN r.description ELSE description END,
+        completed = CASE WHEN 'completed' = ANY(v_set) THEN r.completed ELSE completed END,
+        project_id = CASE WHEN 'project_id' = ANY(v_set) THEN r.project_id ELSE project_id END,
+        due_at = CASE WHEN 'due_at' = ANY(v_set) THEN r.due_at ELSE due_at END,
+        priority = CASE WHEN 'priority' = ANY(v_set) THEN r.priority ELSE priority END,
+        remind_at = CASE WHEN 'remind_at' = ANY(v_set) THEN r.remind_at ELSE remind_at END,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- TOGGLE (r.expected_version guards against lost updates). Completing an
+-- occurrence adds the next one at r.next_occurrence_at, with the same tags;
+-- NULL when the series has ended or the todo does not repeat.
+CREATE OR REPLACE FUNCTION todos.toggle(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    UPDATE public.todos
+    SET completed = NOT completed, updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position
+    INTO v_todo;
+
+    IF NOT FOUND THEN
+        IF r.expected_version IS NOT NULL THEN
+            RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+                USING ERRCODE = 'VC001';
+        END IF;
+        RETURN;
+    END IF;
+
+    IF v_todo.completed AND v_todo.series_id IS NOT NULL AND r.next_occurrence_at IS NOT NULL THEN
+        PERFORM todos.add_occurrence(v_todo.series_id, r.next_occurrence_at);
+
+        INSERT INTO public.todo_tags (todo_id, tag_id)
+        SELECT n.id, tt.tag_id
+        FROM public.todos n
+        JOIN public.todo_tags tt ON tt.todo_id = v_todo.id
+        WHERE n.series_id = v_todo.series_id AND n.occurrence_at = r.next_occurrence_at
+        ON CONFLICT DO NOTHING;
+    END IF;
+
+    RETURN NEXT v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+BEGIN
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+END;
+$$ LANGUAGE plpgsql;
+
+-- APPLY: run one batch operation through the regular API functions
+CREATE OR REPLACE FUNCTION todos.apply(r todos.todo_request)
+RETURNS todos.todo_response AS $$
+DECLARE
+    v_todo todos.todo_response;
+BEGIN
+    CASE r.op
+        WHEN 'create' THEN
+            SELECT * INTO v_todo FROM todos.create(r);
+        WHEN 'update' THEN
+            SELECT * INTO v_todo FROM todos.update(r);
+        WHEN 'toggle' THEN
+            SELECT * INTO v_todo FROM todos.toggle(r);
+        WHEN 'delete' THEN
+            IF todos.delete(r) THEN
+                SELECT t.id, t.user_id, t.title, t.description, t.completed,
+                       t.created_at, t.updated_at, t.deleted_at, t.version, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+                INTO v_todo
+                FROM public.todos t
+                WHERE t.id = r.id AND t.user_id = r.user_id;
+            END IF;
+        ELSE
+            RAISE EXCEPTION 'unknown batch operation: %', COALESCE(r.op, 'null')
+                USING ERRCODE = 'invalid_parameter_value';
+    END CASE;
+
+    IF v_todo.id IS NULL THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN v_todo;
+END;
+$$ LANGUAGE plpgsql;
+
+-- BATCH
+CREATE OR REPLACE FUNCTION todos.batch(r todos.batch_request)
+RETURNS SETOF todos.batch_result AS $$
+DECLARE
+    v_atomic  BOOLEAN := COALESCE(r.atomic, TRUE);
+    v_results todos.batch_result[] := '{}';
+    v_op      todos.todo_request;
+    v_todo    todos.todo_response;
+    v_idx     INTEGER := 0;
+    v_failed  BOOLEAN := FALSE;
+    v_state   TEXT;
+    v_message TEXT;
+BEGIN
+    -- Results live in a variable, which survives the rollback of the block below
+    BEGIN
+        FOREACH v_op IN ARRAY COALESCE(r.ops, '{}') LOOP
+            IF v_atomic AND v_failed THEN
+                v_results := v_results || ROW(v_idx, v_op.op, 'skipped', NULL, NULL,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+                v_idx := v_idx + 1;
+                CONTINUE;
+            END IF;
+
+            BEGIN
+                v_todo := todos.apply(v_op);
+                v_results := v_results || ROW(v_idx, v_op.op, 'ok', NULL, NULL,
+                    v_todo.id, v_todo.user_id, v_todo.title, v_todo.description, v_todo.completed,
+                    v_todo.created_at, v_todo.updated_at, v_todo.deleted_at, v_todo.version,
+                    v_todo.project_id, v_todo.due_at, v_todo.priority, v_todo.remind_at, v_todo.series_id, v_todo.occurrence_at,
+                    v_todo.subtasks_total, v_todo.subtasks_done, v_todo.tags, v_todo.position)::todos.batch_result;
+            EXCEPTION WHEN OTHERS THEN
+                GET STACKED DIAGNOSTICS v_state = RETURNED_SQLSTATE, v_message = MESSAGE_TEXT;
+                v_failed := TRUE;
+                v_results := v_results || ROW(v_idx, v_op.op, 'error', v_state, v_message,
+                    NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result;
+            END;
+            v_idx := v_idx + 1;
+        END LOOP;
+
+        IF v_atomic AND v_failed THEN
+            RAISE EXCEPTION 'batch rolled back' USING ERRCODE = 'transaction_rollback';
+        END IF;
+    EXCEPTION WHEN transaction_rollback THEN
+        -- Everything applied before the failure was undone
+        SELECT array_agg(
+            CASE WHEN res.status = 'ok'
+                THEN ROW(res.idx, res.op, 'rolled_back', NULL, NULL,
+                         NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL, NULL)::todos.batch_result
+                ELSE res
+            END ORDER BY res.idx)
+        INTO v_results
+        FROM unnest(v_results) res;
+    END;
+
+    RETURN QUERY SELECT * FROM unnest(v_results);
+END;
+$$ LANGUAGE plpgsql;
+
+-- SHARED WITH ME: live todos other users shared with r.user_id, newest first
+CREATE OR REPLACE FUNCTION todos.list_shared(r todos.share_request)
+RETURNS SETOF todos.shared_todo_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT t.id, t.user_id, t.title, t.description, t.completed, t.created_at, t.updated_at, t.deleted_at, t.version,
+           todos.permission(t, r.user_id), u.name::TEXT, t.project_id, t.due_at, t.priority, t.remind_at, t.series_id, t.occurrence_at, t.subtasks_total, t.subtasks_done, todos.tag_names(t.id), t.position
+    FROM public.todos t
+    JOIN public.users u ON u.id = t.user_id
+    WHERE t.deleted_at IS NULL
+      AND t.user_id IN (SELECT s.owner_id FROM public.todo_shares s WHERE s.user_id = r.user_id)
+      AND todos.permission(t, r.user_id) IS NOT NULL
+    ORDER BY t.created_at DESC, t.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql;
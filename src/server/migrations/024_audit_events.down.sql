@@ -0,0 +1,22 @@
+-- Revert 024_audit_events.sql
+
+DROP FUNCTION IF EXISTS audit.todo_history(audit.event_request);
+DROP FUNCTION IF EXISTS audit.list(audit.event_request);
+DROP TYPE IF EXISTS audit.event_response;
+DROP TYPE IF EXISTS audit.event_request;
+
+DROP TRIGGER IF EXISTS trigger_tags_audit ON tags;
+DROP TRIGGER IF EXISTS trigger_projects_audit ON projects;
+DROP TRIGGER IF EXISTS trigger_todo_series_audit ON todo_series;
+DROP TRIGGER IF EXISTS trigger_todo_tags_audit ON todo_tags;
+DROP TRIGGER IF EXISTS trigger_todo_shares_audit ON todo_shares;
+DROP TRIGGER IF EXISTS trigger_todo_subtasks_audit ON todo_subtasks;
+DROP TRIGGER IF EXISTS trigger_todos_audit ON todos;
+DROP TRIGGER IF EXISTS trigger_users_audit ON users;
+DROP FUNCTION IF EXISTS audit.record();
+DROP FUNCTION IF EXISTS audit.redact(JSONB);
+
+DROP TABLE IF EXISTS audit_events;
+DROP FUNCTION IF EXISTS audit.reject_change();
+
+DROP SCHEMA IF EXISTS audit;
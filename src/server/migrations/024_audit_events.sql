@@ -0,0 +1,245 @@
+-- Audit trail
+-- Schema: audit
+-- Every insert, update and delete on the user-facing tables appends an event
+-- to audit_events from a row trigger, so it commits or rolls back with the
+-- change itself. Events of todos and of the rows hanging off them (subtasks,
+-- shares, tags) carry the todo_id. Updates keep only the columns that changed,
+-- as before and after; bookkeeping columns (updated_at, version, the search
+-- vector, subtask counters) are left out, and an update that changed nothing
+-- else records nothing. Trashing and restoring a todo are recorded as such.
+-- The actor, request ID and client IP come from the transaction-local
+-- settings audit.actor_id, audit.request_id and audit.client_ip, which the
+-- repositories set next to each query; changes made without them (migrations,
+-- the reminder scheduler) have no actor. Events cannot be changed or deleted.
+
+CREATE SCHEMA IF NOT EXISTS audit;
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+-- actor_id, entity_id and todo_id have no foreign keys: events outlive the
+-- rows they describe
+CREATE TABLE IF NOT EXISTS audit_events (
+    id          BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id    INTEGER,
+    action      TEXT NOT NULL,
+    entity      TEXT NOT NULL,
+    entity_id   INTEGER,
+    todo_id     INTEGER,
+    before      JSONB,
+    after       JSONB,
+    request_id  TEXT,
+    client_ip   TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_events_todo_id ON audit_events(todo_id, id) WHERE todo_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_audit_events_actor_id ON audit_events(actor_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_entity ON audit_events(entity, entity_id, id);
+CREATE INDEX IF NOT EXISTS idx_audit_events_occurred_at ON audit_events(occurred_at);
+
+-- Append-only: updates and deletes are rejected
+CREATE OR REPLACE FUNCTION audit.reject_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only' USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_audit_events_append_only ON audit_events;
+CREATE TRIGGER trigger_audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW
+    EXECUTE FUNCTION audit.reject_change();
+
+-- =============================================================================
+-- RECORDING
+-- =============================================================================
+
+-- Columns never recorded: secrets and values derived from the rest of the row
+CREATE OR REPLACE FUNCTION audit.redact(p_row JSONB)
+RETURNS JSONB AS $$
+    SELECT p_row - ARRAY['password_hash', 'updated_at', 'version', 'search_vector',
+                         'subtasks_total', 'subtasks_done'];
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Row trigger: TG_ARGV[0] names the entity, TG_ARGV[1] the column holding its
+-- id (default id)
+CREATE OR REPLACE FUNCTION audit.record()
+RETURNS TRIGGER AS $$
+DECLARE
+    v_old    JSONB;
+    v_new    JSONB;
+    v_before JSONB;
+    v_after  JSONB;
+    v_row    JSONB;
+    v_action TEXT := LOWER(TG_OP);
+    v_entity TEXT := TG_ARGV[0];
+    v_id_col TEXT := COALESCE(TG_ARGV[1], 'id');
+BEGIN
+    IF TG_OP <> 'INSERT' THEN v_old := audit.redact(to_jsonb(OLD)); END IF;
+    IF TG_OP <> 'DELETE' THEN v_new := audit.redact(to_jsonb(NEW)); END IF;
+
+    IF TG_OP = 'INSERT' THEN
+        v_action := 'create';
+        v_after := v_new;
+    ELSIF TG_OP = 'DELETE' THEN
+        v_before := v_old;
+    ELSE
+        SELECT jsonb_object_agg(o.key, o.value), jsonb_object_agg(o.key, v_new -> o.key)
+        INTO v_before, v_after
+        FROM jsonb_each(v_old) o
+        WHERE o.value IS DISTINCT FROM v_new -> o.key;
+
+        IF v_before IS NULL THEN
+            RETURN NULL;
+        END IF;
+
+        IF v_entity = 'todo' AND v_before ? 'deleted_at' THEN
+            v_action := CASE WHEN v_new ->> 'deleted_at' IS NULL THEN 'restore' ELSE 'trash' END;
+        END IF;
+    END IF;
+
+    v_row := COALESCE(v_new, v_old);
+
+    INSERT INTO public.audit_events (actor_id, action, entity, entity_id, todo_id, before, after, request_id, client_ip)
+    VALUES (
+        NULLIF(current_setting('audit.actor_id', true), '')::INTEGER,
+        v_action,
+        v_entity,
+        (v_row ->> v_id_col)::INTEGER,
+        CASE WHEN v_entity = 'todo' THEN (v_row ->> 'id')::INTEGER ELSE (v_row ->> 'todo_id')::INTEGER END,
+        v_before,
+        v_after,
+        NULLIF(current_setting('audit.request_id', true), ''),
+        NULLIF(current_setting('audit.client_ip', true), '')
+    );
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
+
+DROP TRIGGER IF EXISTS trigger_users_audit ON users;
+CREATE TRIGGER trigger_users_audit
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION audit.record('user');
+
+DROP TRIGGER IF EXISTS trigger_todos_audit ON todos;
+CREATE TRIGGER trigger_todos_audit
+    AFTER INSERT OR UPDATE OR DELETE ON todos
+    FOR EACH ROW EXECUTE FUNCTION audit.record('todo');
+
+DROP TRIGGER IF EXISTS trigger_todo_subtasks_audit ON todo_subtasks;
+CREATE TRIGGER trigger_todo_subtasks_audit
+    AFTER INSERT OR UPDATE OR DELETE ON todo_subtasks
+    FOR EACH ROW EXECUTE FUNCTION audit.record('subtask');
+
+DROP TRIGGER IF EXISTS trigger_todo_shares_audit ON todo_shares;
+CREATE TRIGGER trigger_todo_shares_audit
+    AFTER INSERT OR UPDATE OR DELETE ON todo_shares
+    FOR EACH ROW EXECUTE FUNCTION audit.record('share');
+
+DROP TRIGGER IF EXISTS trigger_todo_tags_audit ON todo_tags;
+CREATE TRIGGER trigger_todo_tags_audit
+    AFTER INSERT OR DELETE ON todo_tags
+    FOR EACH ROW EXECUTE FUNCTION audit.record('todo_tag', 'tag_id');
+
+DROP TRIGGER IF EXISTS trigger_todo_series_audit ON todo_series;
+CREATE TRIGGER trigger_todo_series_audit
+    AFTER INSERT OR UPDATE OR DELETE ON todo_series
+    FOR EACH ROW EXECUTE FUNCTION audit.record('series');
+
+DROP TRIGGER IF EXISTS trigger_projects_audit ON projects;
+CREATE TRIGGER trigger_projects_audit
+    AFTER INSERT OR UPDATE OR DELETE ON projects
+    FOR EACH ROW EXECUTE FUNCTION audit.record('project');
+
+DROP TRIGGER IF EXISTS trigger_tags_audit ON tags;
+CREATE TRIGGER trigger_tags_audit
+    AFTER INSERT OR UPDATE OR DELETE ON tags
+    FOR EACH ROW EXECUTE FUNCTION audit.record('tag');
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all audit parameters
+-- user_id is the caller of audit.todo_history; the rest filter events
+CREATE TYPE audit.event_request AS (
+    user_id         INTEGER,
+    todo_id         INTEGER,
+    actor_id        INTEGER,
+    action          TEXT,
+    entity          TEXT,
+    entity_id       INTEGER,
+    request_id      TEXT,
+    occurred_after  TIMESTAMPTZ,
+    occurred_before TIMESTAMPTZ,
+    limit_val       INTEGER,
+    offset_val      INTEGER
+);
+
+-- OUTPUT: One event
+CREATE TYPE audit.event_response AS (
+    id          BIGINT,
+    occurred_at TIMESTAMPTZ,
+    actor_id    INTEGER,
+    action      TEXT,
+    entity      TEXT,
+    entity_id   INTEGER,
+    todo_id     INTEGER,
+    before      JSONB,
+    after       JSONB,
+    request_id  TEXT,
+    client_ip   TEXT
+);
+
+-- =============================================================================
+-- FUNCTIONS
+-- =============================================================================
+
+-- LIST (admin): newest first, every filter optional
+CREATE OR REPLACE FUNCTION audit.list(r audit.event_request)
+RETURNS SETOF audit.event_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT e.id, e.occurred_at, e.actor_id, e.action, e.entity, e.entity_id, e.todo_id,
+           e.before, e.after, e.request_id, e.client_ip
+    FROM public.audit_events e
+    WHERE (r.todo_id IS NULL OR e.todo_id = r.todo_id)
+      AND (r.actor_id IS NULL OR e.actor_id = r.actor_id)
+      AND (r.action IS NULL OR e.action = r.action)
+      AND (r.entity IS NULL OR e.entity = r.entity)
+      AND (r.entity_id IS NULL OR e.entity_id = r.entity_id)
+      AND (r.request_id IS NULL OR e.request_id = r.request_id)
+      AND (r.occurred_after IS NULL OR e.occurred_at >= r.occurred_after)
+      AND (r.occurred_before IS NULL OR e.occurred_at < r.occurred_before)
+    ORDER BY e.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
+
+-- TODO HISTORY: the events of one of the caller's todos, trashed or not,
+-- newest first
+CREATE OR REPLACE FUNCTION audit.todo_history(r audit.event_request)
+RETURNS SETOF audit.event_response AS $$
+BEGIN
+    PERFORM 1 FROM public.todos t WHERE t.id = r.todo_id AND t.user_id = r.user_id;
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'todo not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN QUERY
+    SELECT e.id, e.occurred_at, e.actor_id, e.action, e.entity, e.entity_id, e.todo_id,
+           e.before, e.after, e.request_id, e.client_ip
+    FROM public.audit_events e
+    WHERE e.todo_id = r.todo_id
+      AND (r.occurred_after IS NULL OR e.occurred_at >= r.occurred_after)
+      AND (r.occurred_before IS NULL OR e.occurred_at < r.occurred_before)
+    ORDER BY e.id DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER STABLE;
@@ -0,0 +1,14 @@
+-- Revert 025_todo_revisions.sql
+
+DROP FUNCTION IF EXISTS todos.revision_revert(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.revision_get(todos.todo_request);
+DROP FUNCTION IF EXISTS todos.revision_list(todos.todo_request);
+DROP TYPE IF EXISTS todos.revision_response;
+
+ALTER TYPE todos.todo_request
+    DROP ATTRIBUTE revision;
+
+DROP TRIGGER IF EXISTS trigger_todos_revision ON todos;
+DROP FUNCTION IF EXISTS todos.snapshot_revision();
+
+DROP TABLE IF EXISTS todo_revisions;
@@ -0,0 +1,142 @@
+-- Todo revisions
+-- Every change to a todo's content (title, description or completed) first
+-- saves the content it replaces as the todo's next revision, numbered from 1.
+-- The snapshot is taken by a row trigger, so todos.update, patch, toggle and
+-- batch all keep revisions. todos.revision_revert puts a revision's content
+-- back through the same UPDATE, so the content it replaces becomes a new
+-- revision and a revert can itself be undone. Reading revisions needs viewer
+-- access to the todo, reverting editor access; revisions go with the todo
+-- when it is purged.
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+CREATE TABLE IF NOT EXISTS todo_revisions (
+    id          SERIAL PRIMARY KEY,
+    todo_id     INTEGER NOT NULL REFERENCES todos(id) ON DELETE CASCADE,
+    revision    INTEGER NOT NULL,
+    title       VARCHAR(500) NOT NULL,
+    description TEXT,
+    completed   BOOLEAN NOT NULL,
+    -- The todo's version while it had this content
+    version     INTEGER NOT NULL,
+    -- Who replaced this content; NULL outside a user's request
+    actor_id    INTEGER,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT todo_revisions_revision_key UNIQUE (todo_id, revision)
+);
+
+-- Saves OLD's content as the next revision. The UPDATE holds the todo's row
+-- lock, so concurrent changes number their revisions one at a time.
+CREATE OR REPLACE FUNCTION todos.snapshot_revision()
+RETURNS TRIGGER AS $$
+BEGIN
+    INSERT INTO public.todo_revisions (todo_id, revision, title, description, completed, version, actor_id)
+    SELECT OLD.id, COALESCE(MAX(rv.revision), 0) + 1, OLD.title, OLD.description, OLD.completed, OLD.version,
+           NULLIF(current_setting('audit.actor_id', true), '')::INTEGER
+    FROM public.todo_revisions rv
+    WHERE rv.todo_id = OLD.id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_todos_revision ON todos;
+CREATE TRIGGER trigger_todos_revision
+    AFTER UPDATE OF title, description, completed ON todos
+    FOR EACH ROW
+    WHEN ((OLD.title, OLD.description, OLD.completed) IS DISTINCT FROM (NEW.title, NEW.description, NEW.completed))
+    EXECUTE FUNCTION todos.snapshot_revision();
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- revision names one revision of todo r.id
+ALTER TYPE todos.todo_request
+    ADD ATTRIBUTE revision INTEGER;
+
+-- OUTPUT: One revision
+CREATE TYPE todos.revision_response AS (
+    todo_id     INTEGER,
+    revision    INTEGER,
+    title       VARCHAR(500),
+    description TEXT,
+    completed   BOOLEAN,
+    version     INTEGER,
+    actor_id    INTEGER,
+    created_at  TIMESTAMPTZ
+);
+
+-- =============================================================================
+-- API FUNCTIONS
+-- =============================================================================
+
+-- LIST: the revisions of r.id, newest first
+CREATE OR REPLACE FUNCTION todos.revision_list(r todos.todo_request)
+RETURNS SETOF todos.revision_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'viewer');
+
+    RETURN QUERY
+    SELECT rv.todo_id, rv.revision, rv.title, rv.description, rv.completed, rv.version, rv.actor_id, rv.created_at
+    FROM public.todo_revisions rv
+    WHERE rv.todo_id = r.id
+    ORDER BY rv.revision DESC
+    LIMIT COALESCE(r.limit_val, 100)
+    OFFSET COALESCE(r.offset_val, 0);
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- GET: revision r.revision of r.id
+CREATE OR REPLACE FUNCTION todos.revision_get(r todos.todo_request)
+RETURNS SETOF todos.revision_response AS $$
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'viewer');
+
+    RETURN QUERY
+    SELECT rv.todo_id, rv.revision, rv.title, rv.description, rv.completed, rv.version, rv.actor_id, rv.created_at
+    FROM public.todo_revisions rv
+    WHERE rv.todo_id = r.id AND rv.revision = r.revision;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'revision not found' USING ERRCODE = 'no_data_found';
+    END IF;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- REVERT: gives r.id the content of revision r.revision (r.expected_version
+-- guards against lost updates, as in todos.update)
+CREATE OR REPLACE FUNCTION todos.revision_revert(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_rev public.todo_revisions;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'editor');
+
+    SELECT * INTO v_rev
+    FROM public.todo_revisions rv
+    WHERE rv.todo_id = r.id AND rv.revision = r.revision;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'revision not found' USING ERRCODE = 'no_data_found';
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET
+        title = v_rev.title,
+        description = v_rev.description,
+        completed = v_rev.completed,
+        updated_at = NOW()
+    WHERE id = r.id AND deleted_at IS NULL
+      AND (r.expected_version IS NULL OR version = r.expected_version)
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+
+    IF NOT FOUND AND r.expected_version IS NOT NULL THEN
+        RAISE EXCEPTION 'todo has been modified since version %', r.expected_version
+            USING ERRCODE = 'VC001';
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,12 @@
+-- Revert 026_todo_events.sql
+
+DROP FUNCTION IF EXISTS events.prune(events.event_request);
+DROP FUNCTION IF EXISTS events.since(events.event_request);
+DROP FUNCTION IF EXISTS events.get(events.event_request);
+DROP FUNCTION IF EXISTS events.publish(events.event_request);
+DROP TYPE IF EXISTS events.event_response;
+DROP TYPE IF EXISTS events.event_request;
+
+DROP TABLE IF EXISTS todo_events;
+
+DROP SCHEMA IF EXISTS events;
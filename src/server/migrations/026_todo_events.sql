@@ -0,0 +1,115 @@
+-- Todo change events
+-- Schema: events
+-- A log of changes to todos for the streams their owners keep open
+-- (GET /todos/stream). events.publish appends an event and notifies channel
+-- todo_events with its id and user_id, delivered on commit to every server
+-- listening; each fetches the event with events.get only when one of its
+-- streams is the user's. A reconnecting stream reads what it missed with
+-- events.since. Events are kept for a short retention, see events.prune.
+
+CREATE SCHEMA IF NOT EXISTS events;
+
+-- =============================================================================
+-- TABLE
+-- =============================================================================
+
+-- todo is the todo after the change; NULL for deleted
+CREATE TABLE IF NOT EXISTS todo_events (
+    id         BIGSERIAL PRIMARY KEY,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type       TEXT NOT NULL CONSTRAINT todo_events_type_check CHECK (type IN ('created', 'updated', 'deleted', 'toggled')),
+    todo_id    INTEGER NOT NULL,
+    todo       JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_todo_events_user_id ON todo_events(user_id, id);
+CREATE INDEX IF NOT EXISTS idx_todo_events_created_at ON todo_events(created_at);
+
+-- =============================================================================
+-- API CONTRACT TYPES
+-- =============================================================================
+
+-- INPUT: One object for all event parameters
+-- after_id is the last event a stream saw; before is the prune cut-off
+CREATE TYPE events.event_request AS (
+    id         BIGINT,
+    user_id    INTEGER,
+    type       TEXT,
+    todo_id    INTEGER,
+    todo       JSONB,
+    after_id   BIGINT,
+    before     TIMESTAMPTZ,
+    limit_val  INTEGER
+);
+
+-- OUTPUT: One event
+CREATE TYPE events.event_response AS (
+    id         BIGINT,
+    user_id    INTEGER,
+    type       TEXT,
+    todo_id    INTEGER,
+    todo       JSONB,
+    created_at TIMESTAMPTZ
+);
+
+-- =============================================================================
+-- FUNCTIONS
+-- =============================================================================
+
+-- PUBLISH: appends an event and notifies the listening servers. The payload
+-- stays far below the 8000-byte NOTIFY limit whatever the todo holds.
+CREATE OR REPLACE FUNCTION events.publish(r events.event_request)
+RETURNS SETOF events.event_response AS $$
+DECLARE
+    v_event events.event_response;
+BEGIN
+    INSERT INTO public.todo_events (user_id, type, todo_id, todo)
+    VALUES (r.user_id, r.type, r.todo_id, r.todo)
+    RETURNING id, user_id, type, todo_id, todo, created_at INTO v_event;
+
+    PERFORM pg_notify('todo_events', json_build_object('id', v_event.id, 'user_id', v_event.user_id)::TEXT);
+
+    RETURN NEXT v_event;
+END;
+$$ LANGUAGE plpgsql;
+
+-- GET
+CREATE OR REPLACE FUNCTION events.get(r events.event_request)
+RETURNS SETOF events.event_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT e.id, e.user_id, e.type, e.todo_id, e.todo, e.created_at
+    FROM public.todo_events e
+    WHERE e.id = r.id;
+
+    IF NOT FOUND THEN
+        RAISE EXCEPTION 'event not found' USING ERRCODE = 'no_data_found';
+    END IF;
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- SINCE: r.user_id's events after r.after_id, oldest first
+CREATE OR REPLACE FUNCTION events.since(r events.event_request)
+RETURNS SETOF events.event_response AS $$
+BEGIN
+    RETURN QUERY
+    SELECT e.id, e.user_id, e.type, e.todo_id, e.todo, e.created_at
+    FROM public.todo_events e
+    WHERE e.user_id = r.user_id AND e.id > r.after_id
+    ORDER BY e.id
+    LIMIT COALESCE(r.limit_val, 1000);
+END;
+$$ LANGUAGE plpgsql STABLE;
+
+-- PRUNE: deletes events created before r.before; returns how many
+CREATE OR REPLACE FUNCTION events.prune(r events.event_request)
+RETURNS INTEGER AS $$
+DECLARE
+    v_count INTEGER;
+BEGIN
+    DELETE FROM public.todo_events WHERE created_at < r.before;
+    GET DIAGNOSTICS v_count = ROW_COUNT;
+    RETURN v_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,54 @@
+-- Refresh grace window
+-- A refresh token presented again within r.grace_seconds of its rotation is
+-- two tabs refreshing at once, or a client retrying after a lost response,
+-- rather than a leak: it gets a new token of its own in the same family
+-- instead of revoking the family. Only hashes are stored, so the successor
+-- issued moments earlier cannot be handed out again. Reuse after the window
+-- still revokes the family.
+
+ALTER TYPE auth.session_request
+    ADD ATTRIBUTE grace_seconds INTEGER;
+
+CREATE OR REPLACE FUNCTION auth.rotate(r auth.session_request)
+RETURNS SETOF auth.session_response AS $$
+DECLARE
+    v_family_id UUID;
+    v_user_id   INTEGER;
+    v_id        INTEGER;
+BEGIN
+    UPDATE auth.sessions
+    SET rotated_at = NOW()
+    WHERE token_hash = r.token_hash
+      AND rotated_at IS NULL
+      AND revoked_at IS NULL
+      AND expires_at > NOW()
+    RETURNING family_id, user_id INTO v_family_id, v_user_id;
+
+    IF NOT FOUND THEN
+        SELECT family_id, user_id INTO v_family_id, v_user_id
+        FROM auth.sessions
+        WHERE token_hash = r.token_hash
+          AND rotated_at > NOW() - make_interval(secs => COALESCE(r.grace_seconds, 0))
+          AND revoked_at IS NULL
+          AND expires_at > NOW();
+    END IF;
+
+    IF v_family_id IS NULL THEN
+        UPDATE auth.sessions
+        SET revoked_at = NOW()
+        WHERE revoked_at IS NULL
+          AND family_id = (SELECT family_id FROM auth.sessions WHERE token_hash = r.token_hash);
+        RETURN;
+    END IF;
+
+    INSERT INTO auth.sessions (family_id, user_id, token_hash, expires_at)
+    VALUES (v_family_id, v_user_id, r.new_token_hash, NOW() + make_interval(secs => r.ttl_seconds))
+    RETURNING id INTO v_id;
+
+    RETURN QUERY
+    SELECT s.id, s.family_id::TEXT, s.user_id, u.email::TEXT, s.expires_at, s.created_at, u.role
+    FROM auth.sessions s
+    JOIN public.users u ON u.id = s.user_id
+    WHERE s.id = v_id;
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER;
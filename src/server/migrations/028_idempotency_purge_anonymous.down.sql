@@ -0,0 +1,2 @@
+-- Revert 028_idempotency_purge_anonymous.sql
+-- Purged keys cannot be restored; a retried signup simply runs again.
@@ -0,0 +1,8 @@
+-- Purge anonymous idempotency keys
+-- Stored bodies are kept in plaintext, and signup, the only anonymous
+-- idempotent route, used to store its whole response: access and refresh
+-- tokens included. Signup now stores only the status of a success and answers
+-- a retry with 409, so routes returning credentials never leave them here.
+-- Drop the signup responses still waiting to expire.
+
+DELETE FROM idempotency.keys WHERE user_id IS NULL;
@@ -0,0 +1,7 @@
+-- Revert 029_todo_reminder_events.sql
+
+DELETE FROM todo_events WHERE type = 'reminder';
+
+ALTER TABLE todo_events DROP CONSTRAINT todo_events_type_check;
+ALTER TABLE todo_events ADD CONSTRAINT todo_events_type_check
+    CHECK (type IN ('created', 'updated', 'deleted', 'toggled'));
@@ -0,0 +1,8 @@
+-- Reminder events
+-- A reminder that comes due is published to the todo's owner as a
+-- 'reminder' event, so open streams and WebSocket channels deliver it like
+-- any change. It carries the todo_id only; todo is NULL.
+
+ALTER TABLE todo_events DROP CONSTRAINT todo_events_type_check;
+ALTER TABLE todo_events ADD CONSTRAINT todo_events_type_check
+    CHECK (type IN ('created', 'updated', 'deleted', 'toggled', 'reminder'));
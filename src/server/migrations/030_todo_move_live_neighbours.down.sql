@@ -0,0 +1,62 @@
+-- Revert 030_todo_move_live_neighbours.sql
+
+CREATE OR REPLACE FUNCTION todos.move(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_lo   TEXT;
+    v_hi   TEXT;
+    v_rank TEXT;
+BEGIN
+    PERFORM todos.authorize(r.id, r.user_id, 'owner');
+
+    IF r.before_id IS NULL AND r.after_id IS NULL THEN
+        RAISE EXCEPTION 'before_id or after_id is required' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+    IF r.id = r.before_id OR r.id = r.after_id OR r.before_id = r.after_id THEN
+        RAISE EXCEPTION 'before_id and after_id must be two other todos' USING ERRCODE = 'invalid_parameter_value';
+    END IF;
+
+    PERFORM todos.lock_positions(r.user_id);
+
+    FOR attempt IN 1..2 LOOP
+        IF r.after_id IS NOT NULL THEN
+            SELECT position INTO v_lo FROM public.todos
+            WHERE id = r.after_id AND user_id = r.user_id AND deleted_at IS NULL;
+            IF NOT FOUND THEN
+                RAISE EXCEPTION 'todo % not found', r.after_id USING ERRCODE = 'no_data_found';
+            END IF;
+        END IF;
+        IF r.before_id IS NOT NULL THEN
+            SELECT position INTO v_hi FROM public.todos
+            WHERE id = r.before_id AND user_id = r.user_id AND deleted_at IS NULL;
+            IF NOT FOUND THEN
+                RAISE EXCEPTION 'todo % not found', r.before_id USING ERRCODE = 'no_data_found';
+            END IF;
+        END IF;
+
+        -- With one neighbour given, the other is the todo next to it
+        IF r.before_id IS NULL THEN
+            SELECT MIN(position) INTO v_hi FROM public.todos
+            WHERE user_id = r.user_id AND id <> r.id AND position > v_lo;
+        ELSIF r.after_id IS NULL THEN
+            SELECT MAX(position) INTO v_lo FROM public.todos
+            WHERE user_id = r.user_id AND id <> r.id AND position < v_hi;
+        ELSIF v_lo > v_hi THEN
+            RAISE EXCEPTION 'todo % comes after todo %', r.after_id, r.before_id
+                USING ERRCODE = 'invalid_parameter_value';
+        END IF;
+
+        IF v_lo IS DISTINCT FROM v_hi THEN
+            v_rank := todos.rank_between(v_lo, v_hi);
+            EXIT WHEN length(v_rank) <= 50;
+        END IF;
+        PERFORM todos.rebalance(r.user_id);
+    END LOOP;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET position = v_rank
+    WHERE id = r.id
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,16 @@
+-- Revert 031_events_publish_order.sql
+
+CREATE OR REPLACE FUNCTION events.publish(r events.event_request)
+RETURNS SETOF events.event_response AS $$
+DECLARE
+    v_event events.event_response;
+BEGIN
+    INSERT INTO public.todo_events (user_id, type, todo_id, todo)
+    VALUES (r.user_id, r.type, r.todo_id, r.todo)
+    RETURNING id, user_id, type, todo_id, todo, created_at INTO v_event;
+
+    PERFORM pg_notify('todo_events', json_build_object('id', v_event.id, 'user_id', v_event.user_id)::TEXT);
+
+    RETURN NEXT v_event;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,26 @@
+-- Per-user event order
+-- Streams resume after the last event id they saw, and the live loop relays
+-- ids as they are notified, so each user's events have to commit in id order.
+-- Two transactions publishing for the same user could commit out of it, and
+-- the event with the lower id was skipped by a stream that had already seen
+-- the higher one. events.publish now holds a per-user lock until commit, so
+-- the next id is drawn only after the previous event is visible.
+
+-- PUBLISH: appends an event and notifies the listening servers. The payload
+-- stays far below the 8000-byte NOTIFY limit whatever the todo holds.
+CREATE OR REPLACE FUNCTION events.publish(r events.event_request)
+RETURNS SETOF events.event_response AS $$
+DECLARE
+    v_event events.event_response;
+BEGIN
+    PERFORM pg_advisory_xact_lock(hashtext('events.publish'), r.user_id);
+
+    INSERT INTO public.todo_events (user_id, type, todo_id, todo)
+    VALUES (r.user_id, r.type, r.todo_id, r.todo)
+    RETURNING id, user_id, type, todo_id, todo, created_at INTO v_event;
+
+    PERFORM pg_notify('todo_events', json_build_object('id', v_event.id, 'user_id', v_event.user_id)::TEXT);
+
+    RETURN NEXT v_event;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,3 @@
+-- Revert 033_todo_release_reminder.sql
+
+DROP FUNCTION IF EXISTS todos.release_reminder(todos.todo_request);
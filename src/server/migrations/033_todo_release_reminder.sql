@@ -0,0 +1,15 @@
+-- Retry failed reminders
+-- todos.claim_reminders records a reminder as sent before it is delivered,
+-- so one whose delivery failed was never sent again. The scheduler now hands
+-- it back with todos.release_reminder and the next pass claims it again.
+
+-- RELEASE REMINDER: forgets that todo r.id was reminded for r.remind_at; a
+-- remind_at moved since is left alone, as it re-arms the reminder anyway
+CREATE OR REPLACE FUNCTION todos.release_reminder(r todos.todo_request)
+RETURNS BOOLEAN AS $$
+BEGIN
+    DELETE FROM public.todo_reminders
+    WHERE todo_id = r.id AND remind_at = r.remind_at;
+    RETURN FOUND;
+END;
+$$ LANGUAGE plpgsql;
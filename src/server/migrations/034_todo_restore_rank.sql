@@ -0,0 +1,40 @@
+-- Re-rank restored todos
+-- A trashed todo keeps its rank, but since 030 todos.move no longer looks at
+-- trashed todos, so a live todo can be given that same rank. Restoring the
+-- trashed one then left two live todos tied on position. A restored todo
+-- whose rank is taken now moves just above the todo holding it.
+
+-- RESTORE
+CREATE OR REPLACE FUNCTION todos.restore(r todos.todo_request)
+RETURNS SETOF todos.todo_response AS $$
+DECLARE
+    v_pos  TEXT;
+    v_lo   TEXT;
+    v_rank TEXT;
+BEGIN
+    PERFORM todos.lock_positions(r.user_id);
+
+    SELECT position INTO v_pos FROM public.todos
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL;
+
+    IF EXISTS (
+        SELECT 1 FROM public.todos
+        WHERE user_id = r.user_id AND id <> r.id AND position = v_pos AND deleted_at IS NULL
+    ) THEN
+        SELECT MAX(position) INTO v_lo FROM public.todos
+        WHERE user_id = r.user_id AND id <> r.id AND position < v_pos;
+        v_rank := todos.rank_between(v_lo, v_pos);
+        -- Respaced ranks are all distinct, so the todo keeps its new one
+        IF length(v_rank) > 50 THEN
+            PERFORM todos.rebalance(r.user_id);
+            v_rank := NULL;
+        END IF;
+    END IF;
+
+    RETURN QUERY
+    UPDATE public.todos
+    SET deleted_at = NULL, position = COALESCE(v_rank, position)
+    WHERE id = r.id AND user_id = r.user_id AND deleted_at IS NOT NULL
+    RETURNING id, user_id, title, description, completed, created_at, updated_at, deleted_at, version, project_id, due_at, priority, remind_at, series_id, occurrence_at, subtasks_total, subtasks_done, todos.tag_names(id), position;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,10 @@
+// Package migrations embeds the numbered SQL files so they ship inside the binary.
+//
+// Files are named NNN_description.sql. An optional NNN_description.down.sql
+// holds the statements that revert it.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEvent records one change to a row. Creates carry only After, deletes
+// only Before, and updates the changed columns on both sides. ActorID is nil
+// for changes made outside a user's request.
+type AuditEvent struct {
+	ID         int64           `json:"id" db:"id"`
+	OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+	ActorID    *int            `json:"actor_id" db:"actor_id"`
+	Action     string          `json:"action" db:"action"` // create, update, delete; trash and restore for todos
+	Entity     string          `json:"entity" db:"entity"` // user, todo, subtask, share, todo_tag, series, project, tag
+	EntityID   *int            `json:"entity_id" db:"entity_id"`
+	TodoID     *int            `json:"todo_id,omitempty" db:"todo_id"`
+	Before     json.RawMessage `json:"before" db:"before"`
+	After      json.RawMessage `json:"after" db:"after"`
+	RequestID  *string         `json:"request_id,omitempty" db:"request_id"`
+	ClientIP   *string         `json:"client_ip,omitempty" db:"client_ip"`
+}
+
+// AuditFilter narrows an audit listing; the zero value lists every event,
+// newest first.
+type AuditFilter struct {
+	ActorID        *int
+	Action         string
+	Entity         string
+	EntityID       *int
+	TodoID         *int
+	RequestID      string
+	OccurredAfter  *time.Time
+	OccurredBefore *time.Time
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// TodoEventType is what happened to a todo.
+type TodoEventType string
+
+const (
+	TodoCreated  TodoEventType = "created" // also a todo restored from the trash
+	TodoUpdated  TodoEventType = "updated"
+	TodoDeleted  TodoEventType = "deleted" // moved to the trash
+	TodoToggled  TodoEventType = "toggled"
+	TodoReminder TodoEventType = "reminder" // its remind_at passed
+)
+
+// TodoEvent tells a todo's owner about a change to it. IDs increase, so a
+// stream can resume after the last one it saw. Todo is the todo after the
+// change, nil for deleted and reminder.
+type TodoEvent struct {
+	ID        int64         `json:"id" db:"id"`
+	UserID    int           `json:"user_id" db:"user_id"`
+	Type      TodoEventType `json:"type" db:"type"`
+	TodoID    int           `json:"todo_id" db:"todo_id"`
+	Todo      *Todo         `json:"todo,omitempty" db:"todo"`
+	CreatedAt time.Time     `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// IdempotencyKey is what is stored for an Idempotency-Key. Created is true
+// when the lookup reserved the key; StatusCode is nil until the first request
+// has finished and its response was saved.
+type IdempotencyKey struct {
+	Created     bool                `db:"created"`
+	Fingerprint string              `db:"fingerprint"`
+	StatusCode  *int                `db:"status_code"`
+	Headers     map[string][]string `db:"headers"`
+	Body        []byte              `db:"body"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Project groups a user's todos. Todos without a project are in the inbox.
+type Project struct {
+	ID          int       `json:"id" db:"id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	TodoCount   int       `json:"todo_count" db:"todo_count"` // live todos only
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProjectDeleteMode is what happens to a project's todos when it is deleted.
+type ProjectDeleteMode string
+
+const (
+	DeleteToInbox ProjectDeleteMode = "inbox"   // todos stay, without a project
+	DeleteCascade ProjectDeleteMode = "cascade" // todos move to the trash
+)
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Reminder is emitted once when a todo's remind_at passes.
+type Reminder struct {
+	TodoID   int        `json:"todo_id" db:"todo_id"`
+	UserID   int        `json:"user_id" db:"user_id"`
+	Title    string     `json:"title" db:"title"`
+	DueAt    *time.Time `json:"due_at,omitempty" db:"due_at"`
+	RemindAt time.Time  `json:"remind_at" db:"remind_at"`
+}
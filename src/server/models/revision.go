@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TodoRevision is content a todo had before a change replaced it. Revisions
+// are numbered from 1 per todo; Version is the todo's version at the time.
+type TodoRevision struct {
+	TodoID      int       `json:"todo_id" db:"todo_id"`
+	Revision    int       `json:"revision" db:"revision"`
+	Title       string    `json:"title" db:"title"`
+	Description *string   `json:"description,omitempty" db:"description"`
+	Completed   bool      `json:"completed" db:"completed"`
+	Version     int       `json:"version" db:"version"`
+	ActorID     *int      `json:"actor_id" db:"actor_id"` // who replaced this content
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// FieldChange is a field's value in a revision and in the todo now.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// RevisionDiff is a revision with the fields changed since, keyed by field
+// name; empty when the todo has the revision's content again.
+type RevisionDiff struct {
+	Revision TodoRevision           `json:"revision"`
+	Changes  map[string]FieldChange `json:"changes"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Recurrence makes a todo repeat: an RFC 5545 RRULE expanded in an IANA time zone.
+type Recurrence struct {
+	RRule    string
+	Timezone string // UTC when empty
+}
+
+// TodoSeries is a recurring todo: its rule and the template each new
+// occurrence copies. DTStart is the first occurrence.
+type TodoSeries struct {
+	ID          int          `json:"id" db:"id"`
+	UserID      int          `json:"user_id" db:"user_id"`
+	RRule       string       `json:"rrule" db:"rrule"`
+	Timezone    string       `json:"timezone" db:"timezone"`
+	DTStart     time.Time    `json:"dtstart" db:"dtstart"`
+	Title       string       `json:"title" db:"title"`
+	Description *string      `json:"description,omitempty" db:"description"`
+	Priority    TodoPriority `json:"priority" db:"priority"`
+	ProjectID   *int         `json:"project_id,omitempty" db:"project_id"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" db:"updated_at"`
+	// Upcoming are the next occurrences after now, expanded on read
+	Upcoming []time.Time `json:"upcoming" db:"-"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Session is one refresh token row; rows from the same login share a FamilyID.
+type Session struct {
+	ID        int       `json:"id" db:"id"`
+	FamilyID  string    `json:"family_id" db:"family_id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Email     string    `json:"email" db:"email"`
+	ExpiresAt time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	Role      Role      `json:"role" db:"role"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// SharePermission is what a collaborator may do with a shared todo:
+// viewers read it, editors also update and toggle it. Only the owner deletes,
+// restores and shares.
+type SharePermission string
+
+const (
+	PermissionViewer SharePermission = "viewer"
+	PermissionEditor SharePermission = "editor"
+)
+
+// Share gives UserID access to one todo, or to the owner's whole list when
+// TodoID is nil.
+type Share struct {
+	TodoID     *int            `json:"todo_id,omitempty" db:"todo_id"`
+	UserID     int             `json:"user_id" db:"user_id"`
+	Name       string          `json:"name" db:"name"`
+	Email      string          `json:"email" db:"email"`
+	Permission SharePermission `json:"permission" db:"permission"`
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+}
+
+// SharedTodo is a todo another user shared with the caller.
+type SharedTodo struct {
+	Todo
+	Permission SharePermission `json:"permission" db:"permission"`
+	OwnerName  string          `json:"owner_name" db:"owner_name"`
+}
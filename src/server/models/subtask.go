@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Subtask is one checklist item of a todo, ordered by position.
+type Subtask struct {
+	ID        int       `json:"id" db:"id"`
+	TodoID    int       `json:"todo_id" db:"todo_id"`
+	Title     string    `json:"title" db:"title"`
+	Completed bool      `json:"completed" db:"completed"`
+	Position  int       `json:"position" db:"position"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SubtaskUpdate renames, completes or reopens a subtask; nil fields are left
+// alone. CompleteParent completes the todo when this closes its last open subtask.
+type SubtaskUpdate struct {
+	ID             int
+	TodoID         int
+	Title          *string
+	Completed      *bool
+	CompleteParent bool
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Tag labels a user's todos. Names are unique per user, ignoring case.
+type Tag struct {
+	ID        int       `json:"id" db:"id"`
+	UserID    int       `json:"user_id" db:"user_id"`
+	Name      string    `json:"name" db:"name"`
+	TodoCount int       `json:"todo_count" db:"todo_count"` // live todos only
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
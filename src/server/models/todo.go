@@ -1,13 +1,162 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/fayzzzm/go-bro/pkg/optional"
+)
 
 type Todo struct {
-	ID          int       `json:"id" db:"id"`
-	UserID      int       `json:"user_id" db:"user_id"`
-	Title       *string   `json:"title,omitempty" db:"title"`
-	Description *string   `json:"description,omitempty" db:"description"`
-	Completed   *bool     `json:"completed,omitempty" db:"completed"`
-	CreatedAt   time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	ID          int        `json:"id" db:"id"`
+	UserID      int        `json:"user_id" db:"user_id"`
+	Title       *string    `json:"title,omitempty" db:"title"`
+	Description *string    `json:"description,omitempty" db:"description"`
+	Completed   *bool      `json:"completed,omitempty" db:"completed"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+	// Version increases on every change; sent to clients as the ETag.
+	Version   int           `json:"version" db:"version"`
+	ProjectID *int          `json:"project_id,omitempty" db:"project_id"` // nil for the inbox
+	DueAt     *time.Time    `json:"due_at,omitempty" db:"due_at"`
+	Priority  *TodoPriority `json:"priority,omitempty" db:"priority"`
+	RemindAt  *time.Time    `json:"remind_at,omitempty" db:"remind_at"`
+	// SeriesID links the occurrences of a recurring todo; OccurrenceAt is
+	// this one's slot in the series, its due_at unless moved since
+	SeriesID     *int       `json:"series_id,omitempty" db:"series_id"`
+	OccurrenceAt *time.Time `json:"occurrence_at,omitempty" db:"occurrence_at"`
+	// Progress of the todo's checklist; Subtasks is only filled on request
+	SubtasksTotal int       `json:"subtasks_total" db:"subtasks_total"`
+	SubtasksDone  int       `json:"subtasks_done" db:"subtasks_done"`
+	Subtasks      []Subtask `json:"subtasks,omitempty" db:"-"`
+	// Tags are names; on input nil leaves them alone and empty removes them
+	Tags []string `json:"tags" db:"tags"`
+	// Position is the todo's rank in its owner's manual order; ranks sort
+	// bytewise and change without bumping Version
+	Position string `json:"position" db:"position"`
+	// Recurrence is input only: it starts a series on create and changes one
+	// in UpdateFuture
+	Recurrence *Recurrence `json:"-" db:"-"`
+}
+
+// TodoPriority is how important a todo is; new todos default to medium.
+type TodoPriority string
+
+const (
+	PriorityLow    TodoPriority = "low"
+	PriorityMedium TodoPriority = "medium"
+	PriorityHigh   TodoPriority = "high"
+	PriorityUrgent TodoPriority = "urgent"
+)
+
+// Valid reports whether p is one of the four priorities.
+func (p TodoPriority) Valid() bool {
+	switch p {
+	case PriorityLow, PriorityMedium, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// TodoPatch is a merge-patch update: unset fields are left alone and fields
+// set to null are cleared. A non-zero Version makes the patch conditional.
+type TodoPatch struct {
+	ID          int
+	Title       optional.Field[string]
+	Description optional.Field[string]
+	Completed   optional.Field[bool]
+	ProjectID   optional.Field[int] // null moves the todo to the inbox
+	DueAt       optional.Field[time.Time]
+	Priority    optional.Field[TodoPriority]
+	RemindAt    optional.Field[time.Time]
+	Tags        optional.Field[[]string] // null removes every tag
+	Version     int
+}
+
+// TodoMove places a todo right after AfterID or right before BeforeID in its
+// owner's order; with both, between them.
+type TodoMove struct {
+	ID       int
+	BeforeID *int
+	AfterID  *int
+}
+
+// TodoSort is a field todos can be listed by.
+type TodoSort string
+
+const (
+	SortByTitle     TodoSort = "title"
+	SortByCreatedAt TodoSort = "created_at"
+	SortByUpdatedAt TodoSort = "updated_at"
+	SortByDueAt     TodoSort = "due_at"
+	SortByPosition  TodoSort = "position" // the owner's manual order
+)
+
+// TodoFilter narrows and orders a todo listing. The zero value lists everything
+// newest first.
+type TodoFilter struct {
+	Completed     *bool
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Query         string // full-text search over title and description
+	ProjectID     *int
+	Inbox         bool // only todos without a project
+	Priority      *TodoPriority
+	DueAfter      *time.Time
+	DueBefore     *time.Time
+	Overdue       bool     // due in the past and not completed
+	SeriesID      *int     // occurrences of one recurring todo
+	Tags          []string // todos with any of these tags, lowercase
+	AllTags       bool     // todos with all of Tags instead
+	WithSubtasks  bool     // nest each todo's subtasks
+	Sort          TodoSort
+	Ascending     bool
+	Trashed       bool // list the trash instead of live todos
+}
+
+// DefaultOrder reports whether the listing uses created_at DESC, the only order
+// keyset cursors can follow.
+func (f TodoFilter) DefaultOrder() bool {
+	return (f.Sort == "" || f.Sort == SortByCreatedAt) && !f.Ascending
+}
+
+// BatchOp is one kind of operation in a todo batch.
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+	BatchToggle BatchOp = "toggle"
+)
+
+// TodoBatchOp is one operation of a batch. Todo.ID names the target of
+// update, delete and toggle; the other fields carry create/update input.
+type TodoBatchOp struct {
+	Op   BatchOp
+	Todo Todo
+}
+
+// Outcomes of a batch operation.
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusError      = "error"
+	BatchStatusRolledBack = "rolled_back" // succeeded, then undone by a failure elsewhere in an atomic batch
+	BatchStatusSkipped    = "skipped"     // not attempted after an atomic batch failed
+)
+
+type TodoBatchResult struct {
+	Index  int         `json:"index"`
+	Op     BatchOp     `json:"op"`
+	Status string      `json:"status"`
+	Todo   *Todo       `json:"todo,omitempty"`
+	Error  *BatchError `json:"error,omitempty"`
+}
+
+// BatchError uses the apperr kind names as codes, e.g. "not_found".
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
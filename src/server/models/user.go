@@ -2,17 +2,29 @@ package models
 
 import "time"
 
+// Role decides what a user may do; admins can manage every user and todo.
+type Role string
+
+const (
+	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
+)
+
 type User struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	ID         int        `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Email      string     `json:"email" db:"email"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	Role       Role       `json:"role" db:"role"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
 }
 
 type UserWithPassword struct {
-	ID           int       `json:"id" db:"id"`
-	Name         string    `json:"name" db:"name"`
-	Email        string    `json:"email" db:"email"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID           int        `json:"id" db:"id"`
+	Name         string     `json:"name" db:"name"`
+	Email        string     `json:"email" db:"email"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	Role         Role       `json:"role" db:"role"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty" db:"disabled_at"`
 }
@@ -0,0 +1,113 @@
+// Package apperr defines the domain error taxonomy shared by repositories, services and controllers.
+//
+// Repositories translate storage errors into these kinds, services return them as-is,
+// and pkg/reply maps each kind to an HTTP status in one place.
+package apperr
+
+import "errors"
+
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindPreconditionFailed
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindNotFound:
+		return "not_found"
+	case KindConflict:
+		return "conflict"
+	case KindValidation:
+		return "validation"
+	case KindUnauthorized:
+		return "unauthorized"
+	case KindForbidden:
+		return "forbidden"
+	case KindPreconditionFailed:
+		return "precondition_failed"
+	default:
+		return "internal"
+	}
+}
+
+// Error carries a kind, a client-safe message and the underlying cause for logs.
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+
+	generic bool
+}
+
+// Generic sentinels: errors.Is(err, apperr.ErrNotFound) matches any not-found error.
+var (
+	ErrNotFound     = &Error{Kind: KindNotFound, Message: "resource not found", generic: true}
+	ErrConflict     = &Error{Kind: KindConflict, Message: "resource already exists", generic: true}
+	ErrValidation   = &Error{Kind: KindValidation, Message: "invalid input", generic: true}
+	ErrUnauthorized = &Error{Kind: KindUnauthorized, Message: "unauthorized", generic: true}
+	ErrForbidden    = &Error{Kind: KindForbidden, Message: "forbidden", generic: true}
+
+	ErrPreconditionFailed = &Error{Kind: KindPreconditionFailed, Message: "precondition failed", generic: true}
+)
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches the generic sentinel of the same kind; specific errors still compare by identity.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.generic && t.Kind == e.Kind
+}
+
+func NotFound(message string) *Error {
+	return &Error{Kind: KindNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Kind: KindConflict, Message: message}
+}
+
+func Validation(message string) *Error {
+	return &Error{Kind: KindValidation, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Kind: KindUnauthorized, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Kind: KindForbidden, Message: message}
+}
+
+func PreconditionFailed(message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Message: message}
+}
+
+// Wrap attaches a cause to a new error of the given kind.
+func Wrap(kind Kind, message string, err error) *Error {
+	return &Error{Kind: kind, Message: message, Err: err}
+}
+
+// KindOf returns the kind of the first *Error in err's chain, or KindInternal.
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	return KindInternal
+}
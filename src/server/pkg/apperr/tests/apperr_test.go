@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+)
+
+func TestIs(t *testing.T) {
+	specific := apperr.Unauthorized("invalid credentials")
+	wrapped := fmt.Errorf("login: %w", specific)
+
+	if !errors.Is(wrapped, apperr.ErrUnauthorized) {
+		t.Error("Expected wrapped error to match its generic sentinel")
+	}
+	if !errors.Is(wrapped, specific) {
+		t.Error("Expected wrapped error to match the specific error")
+	}
+	if errors.Is(wrapped, apperr.ErrNotFound) {
+		t.Error("Expected unauthorized error not to match ErrNotFound")
+	}
+	if errors.Is(apperr.Unauthorized("invalid refresh token"), specific) {
+		t.Error("Expected distinct specific errors of the same kind not to match")
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	cause := errors.New("duplicate key")
+	err := fmt.Errorf("signup: %w", apperr.Wrap(apperr.KindConflict, "email already exists", cause))
+
+	if apperr.KindOf(err) != apperr.KindConflict {
+		t.Errorf("Expected conflict, got %s", apperr.KindOf(err))
+	}
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to stay reachable with errors.Is")
+	}
+	if apperr.KindOf(errors.New("boom")) != apperr.KindInternal {
+		t.Error("Expected plain errors to be internal")
+	}
+}
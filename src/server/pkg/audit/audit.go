@@ -0,0 +1,41 @@
+// Package audit carries who is making a request, and from where, through the
+// request context down to the repositories, which hand it to the database
+// triggers that write the audit trail (migration 024).
+package audit
+
+import "context"
+
+// Meta describes the origin of the changes made while serving a request.
+// ActorID is nil until the caller has authenticated.
+type Meta struct {
+	ActorID   *int
+	RequestID string
+	ClientIP  string
+}
+
+type contextKey struct{}
+
+// WithRequest returns a context carrying the request ID and client IP.
+func WithRequest(ctx context.Context, requestID, clientIP string) context.Context {
+	meta := FromContext(ctx)
+	meta.RequestID, meta.ClientIP = requestID, clientIP
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// WithActor returns a context naming the authenticated user as the actor.
+func WithActor(ctx context.Context, userID int) context.Context {
+	meta := FromContext(ctx)
+	meta.ActorID = &userID
+	return context.WithValue(ctx, contextKey{}, meta)
+}
+
+// FromContext returns the metadata stored in ctx, or the zero Meta.
+func FromContext(ctx context.Context) Meta {
+	meta, _ := ctx.Value(contextKey{}).(Meta)
+	return meta
+}
+
+// IsZero reports whether m carries nothing to record.
+func (m Meta) IsZero() bool {
+	return m.ActorID == nil && m.RequestID == "" && m.ClientIP == ""
+}
@@ -0,0 +1,27 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/pkg/audit"
+)
+
+func TestFromContext_Empty(t *testing.T) {
+	if meta := audit.FromContext(context.Background()); !meta.IsZero() {
+		t.Errorf("Expected zero meta, got %+v", meta)
+	}
+}
+
+func TestWithRequestAndActor(t *testing.T) {
+	ctx := audit.WithRequest(context.Background(), "req-1", "10.0.0.1")
+	ctx = audit.WithActor(ctx, 7)
+
+	meta := audit.FromContext(ctx)
+	if meta.RequestID != "req-1" || meta.ClientIP != "10.0.0.1" {
+		t.Errorf("Expected request metadata to survive WithActor, got %+v", meta)
+	}
+	if meta.ActorID == nil || *meta.ActorID != 7 {
+		t.Errorf("Expected actor 7, got %v", meta.ActorID)
+	}
+}
@@ -2,50 +2,64 @@ package auth
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
-
-func init() {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-super-secret-key-change-in-production"
-	}
-	jwtSecret = []byte(secret)
-}
+// AccessTokenTTL is kept short because access tokens are only checked
+// against the session store, not revoked individually.
+const AccessTokenTTL = 15 * time.Minute
 
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role,omitempty"`
+	SessionID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(userID int, email string) (string, error) {
+// TokenManager signs and validates access tokens with keys from a KeyProvider
+type TokenManager struct {
+	keys KeyProvider
+}
+
+func NewTokenManager(keys KeyProvider) *TokenManager {
+	return &TokenManager{keys: keys}
+}
+
+// GenerateToken creates a new short-lived JWT access token bound to a session
+func (m *TokenManager) GenerateToken(userID int, email, role, sessionID string) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:    userID,
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	key := m.keys.SigningKey()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
 // ValidateToken validates a JWT token and returns the claims
-func ValidateToken(tokenString string) (*Claims, error) {
+func (m *TokenManager) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := m.keys.VerificationKey(kid)
+		if !ok {
+			return nil, errors.New("unknown signing key")
+		}
+		// The key decides the algorithm, never the token header
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, errors.New("unexpected signing method")
 		}
-		return jwtSecret, nil
+		return key.Public, nil
 	})
 
 	if err != nil {
@@ -59,3 +73,8 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// JWKS returns the public keys other services use to verify our tokens
+func (m *TokenManager) JWKS() JWKSet {
+	return NewJWKS(m.keys.PublicKeys())
+}
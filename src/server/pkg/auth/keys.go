@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrNoSigningKey is returned in release mode when neither JWT_SIGNING_KEYS nor JWT_SECRET is set.
+var ErrNoSigningKey = errors.New("no JWT signing key configured: set JWT_SIGNING_KEYS or JWT_SECRET")
+
+// Key is a signing key identified by its kid header.
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private is the value passed to SignedString: []byte, *rsa.PrivateKey or ed25519.PrivateKey.
+	Private any
+	// Public verifies signatures. It equals Private for HMAC keys.
+	Public any
+}
+
+// Asymmetric reports whether the key can be published in a JWKS.
+func (k *Key) Asymmetric() bool {
+	_, hmac := k.Method.(*jwt.SigningMethodHMAC)
+	return !hmac
+}
+
+// KeyProvider supplies the key used to sign new tokens and every key still accepted for verification.
+type KeyProvider interface {
+	// SigningKey returns the key new tokens are signed with.
+	SigningKey() *Key
+	// VerificationKey looks up a key by kid.
+	VerificationKey(kid string) (*Key, bool)
+	// PublicKeys returns the asymmetric keys to publish at /.well-known/jwks.json.
+	PublicKeys() []*Key
+}
+
+// StaticKeyProvider signs with its first key and verifies with all of them.
+// Rotating means prepending the new key and dropping the old one once
+// every token it signed has expired (AccessTokenTTL).
+type StaticKeyProvider struct {
+	keys []*Key
+	byID map[string]*Key
+}
+
+func NewStaticKeyProvider(keys ...*Key) (*StaticKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoSigningKey
+	}
+	byID := make(map[string]*Key, len(keys))
+	for _, k := range keys {
+		if _, dup := byID[k.ID]; dup {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		byID[k.ID] = k
+	}
+	return &StaticKeyProvider{keys: keys, byID: byID}, nil
+}
+
+func (p *StaticKeyProvider) SigningKey() *Key {
+	return p.keys[0]
+}
+
+func (p *StaticKeyProvider) VerificationKey(kid string) (*Key, bool) {
+	k, ok := p.byID[kid]
+	return k, ok
+}
+
+func (p *StaticKeyProvider) PublicKeys() []*Key {
+	var keys []*Key
+	for _, k := range p.keys {
+		if k.Asymmetric() {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// NewKeyProviderFromEnv builds the provider from the environment:
+//   - JWT_SIGNING_KEYS: comma-separated PEM files (RSA or Ed25519). The first signs, the rest only verify.
+//   - JWT_SECRET: legacy HS256 secret, used when no asymmetric keys are configured.
+//
+// Outside release mode an ephemeral Ed25519 key is generated if nothing is configured.
+func NewKeyProviderFromEnv() (KeyProvider, error) {
+	if paths := os.Getenv("JWT_SIGNING_KEYS"); paths != "" {
+		var keys []*Key
+		for _, path := range strings.Split(paths, ",") {
+			path = strings.TrimSpace(path)
+			if path == "" {
+				continue
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read signing key %s: %w", filepath.Base(path), err)
+			}
+			key, err := ParsePrivateKeyPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("parse signing key %s: %w", filepath.Base(path), err)
+			}
+			keys = append(keys, key)
+		}
+		return NewStaticKeyProvider(keys...)
+	}
+
+	if secret := os.Getenv("JWT_SECRET"); secret != "" {
+		return NewStaticKeyProvider(NewHMACKey("hs256", []byte(secret)))
+	}
+
+	if os.Getenv("GIN_MODE") == "release" {
+		return nil, ErrNoSigningKey
+	}
+
+	log.Println("⚠️ Warning: no JWT key configured, using an ephemeral Ed25519 key (tokens won't survive a restart)")
+	key, err := GenerateEd25519Key()
+	if err != nil {
+		return nil, err
+	}
+	return NewStaticKeyProvider(key)
+}
+
+// NewHMACKey wraps a shared secret as an HS256 key.
+func NewHMACKey(kid string, secret []byte) *Key {
+	return &Key{ID: kid, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
+
+// GenerateEd25519Key creates a fresh EdDSA key.
+func GenerateEd25519Key() (*Key, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return newAsymmetricKey(priv, pub)
+}
+
+// ParsePrivateKeyPEM reads a PKCS#1 RSA or PKCS#8 RSA/Ed25519 private key.
+// The kid is the key's RFC 7638 thumbprint.
+func ParsePrivateKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var priv any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		priv, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		priv, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key type")
+	}
+	return newAsymmetricKey(priv, signer.Public())
+}
+
+func newAsymmetricKey(priv, pub any) (*Key, error) {
+	key := &Key{Private: priv, Public: pub}
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		if p.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", pub)
+	}
+
+	kid, err := Thumbprint(key)
+	if err != nil {
+		return nil, err
+	}
+	key.ID = kid
+	return key, nil
+}
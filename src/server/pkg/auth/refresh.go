@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new pair.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshReuseGrace is how soon after its rotation a refresh token may be
+// presented once more, by a second tab or a retry, without counting as reuse.
+// A second replay revokes the family.
+const RefreshReuseGrace = 10 * time.Second
+
+// TokenPair is what the client receives after login, signup or refresh.
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+	SessionID    string
+}
+
+// NewRefreshToken returns an opaque random token and the hash to persist.
+// Only the hash is stored, so a database leak doesn't expose usable tokens.
+func NewRefreshToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 of a refresh token.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,17 +1,45 @@
 package tests
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/fayzzzm/go-bro/pkg/auth"
 )
 
+func newTokens(t *testing.T, keys ...*auth.Key) *auth.TokenManager {
+	t.Helper()
+	provider, err := auth.NewStaticKeyProvider(keys...)
+	if err != nil {
+		t.Fatalf("Failed to create key provider: %v", err)
+	}
+	return auth.NewTokenManager(provider)
+}
+
+func generateRSAKeyPEM(t *testing.T) []byte {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+}
+
 func TestJWT(t *testing.T) {
+	tokens := newTokens(t, auth.NewHMACKey("test", []byte("test-secret")))
+
 	userID := 123
 	email := "user@example.com"
+	sessionID := "9b2f6a3e-1c1d-4f0e-9a57-0c6f3d1f2a10"
 
 	// Test GenerateToken
-	token, err := auth.GenerateToken(userID, email)
+	token, err := tokens.GenerateToken(userID, email, "user", sessionID)
 	if err != nil {
 		t.Fatalf("Failed to generate token: %v", err)
 	}
@@ -20,7 +48,7 @@ func TestJWT(t *testing.T) {
 	}
 
 	// Test ValidateToken
-	claims, err := auth.ValidateToken(token)
+	claims, err := tokens.ValidateToken(token)
 	if err != nil {
 		t.Fatalf("Failed to validate token: %v", err)
 	}
@@ -31,14 +59,141 @@ func TestJWT(t *testing.T) {
 	if claims.Email != email {
 		t.Errorf("Expected email %s, got %s", email, claims.Email)
 	}
+	if claims.SessionID != sessionID {
+		t.Errorf("Expected session ID %s, got %s", sessionID, claims.SessionID)
+	}
 
 	// Test Invalid Token
-	_, err = auth.ValidateToken("invalid.token.string")
+	_, err = tokens.ValidateToken("invalid.token.string")
 	if err == nil {
 		t.Error("Expected error for invalid token, got nil")
 	}
 }
 
+func TestAsymmetricKeys(t *testing.T) {
+	rsaKey, err := auth.ParsePrivateKeyPEM(generateRSAKeyPEM(t))
+	if err != nil {
+		t.Fatalf("Failed to parse RSA key: %v", err)
+	}
+	edKey, err := auth.GenerateEd25519Key()
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	for _, key := range []*auth.Key{rsaKey, edKey} {
+		t.Run(key.Method.Alg(), func(t *testing.T) {
+			tokens := newTokens(t, key)
+
+			token, err := tokens.GenerateToken(1, "user@example.com", "user", "session-1")
+			if err != nil {
+				t.Fatalf("Failed to generate token: %v", err)
+			}
+			if _, err := tokens.ValidateToken(token); err != nil {
+				t.Fatalf("Failed to validate token: %v", err)
+			}
+
+			jwks := tokens.JWKS()
+			if len(jwks.Keys) != 1 || jwks.Keys[0].Kid != key.ID {
+				t.Fatalf("Expected JWKS with kid %s, got %+v", key.ID, jwks.Keys)
+			}
+			if jwks.Keys[0].Alg != key.Method.Alg() {
+				t.Errorf("Expected alg %s, got %s", key.Method.Alg(), jwks.Keys[0].Alg)
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, _ := auth.GenerateEd25519Key()
+	newKey, _ := auth.GenerateEd25519Key()
+
+	oldToken, _ := newTokens(t, oldKey).GenerateToken(1, "user@example.com", "user", "session-1")
+
+	// During the overlap the new key signs and the old one still verifies
+	rotated := newTokens(t, newKey, oldKey)
+	if _, err := rotated.ValidateToken(oldToken); err != nil {
+		t.Errorf("Expected old token to validate during overlap, got %v", err)
+	}
+	if len(rotated.JWKS().Keys) != 2 {
+		t.Errorf("Expected both keys published during overlap, got %d", len(rotated.JWKS().Keys))
+	}
+
+	newToken, _ := rotated.GenerateToken(1, "user@example.com", "user", "session-1")
+	if _, err := newTokens(t, newKey).ValidateToken(newToken); err != nil {
+		t.Errorf("Expected new token to be signed by the new key, got %v", err)
+	}
+
+	// Once the old key is dropped its tokens are rejected
+	if _, err := newTokens(t, newKey).ValidateToken(oldToken); err == nil {
+		t.Error("Expected old token to be rejected after the old key was removed")
+	}
+}
+
+func TestAlgorithmConfusion(t *testing.T) {
+	// A token signed with HS256 must not verify against an asymmetric key sharing its kid
+	edKey, _ := auth.GenerateEd25519Key()
+	forged, _ := newTokens(t, auth.NewHMACKey(edKey.ID, []byte("attacker"))).GenerateToken(1, "user@example.com", "user", "session-1")
+
+	if _, err := newTokens(t, edKey).ValidateToken(forged); err == nil {
+		t.Error("Expected HS256 token to be rejected by an EdDSA key")
+	}
+}
+
+func TestNewKeyProviderFromEnv(t *testing.T) {
+	t.Run("ReleaseWithoutKey", func(t *testing.T) {
+		t.Setenv("JWT_SIGNING_KEYS", "")
+		t.Setenv("JWT_SECRET", "")
+		t.Setenv("GIN_MODE", "release")
+
+		if _, err := auth.NewKeyProviderFromEnv(); !errors.Is(err, auth.ErrNoSigningKey) {
+			t.Errorf("Expected ErrNoSigningKey, got %v", err)
+		}
+	})
+
+	t.Run("SigningKeysFromFiles", func(t *testing.T) {
+		dir := t.TempDir()
+		newPath := filepath.Join(dir, "new.pem")
+		oldPath := filepath.Join(dir, "old.pem")
+		os.WriteFile(newPath, generateRSAKeyPEM(t), 0o600)
+		os.WriteFile(oldPath, generateRSAKeyPEM(t), 0o600)
+
+		t.Setenv("JWT_SIGNING_KEYS", newPath+","+oldPath)
+		t.Setenv("GIN_MODE", "release")
+
+		provider, err := auth.NewKeyProviderFromEnv()
+		if err != nil {
+			t.Fatalf("Failed to load keys: %v", err)
+		}
+		if len(provider.PublicKeys()) != 2 {
+			t.Errorf("Expected 2 public keys, got %d", len(provider.PublicKeys()))
+		}
+		if provider.SigningKey().ID != provider.PublicKeys()[0].ID {
+			t.Error("Expected the first configured key to sign")
+		}
+	})
+}
+
+func TestRefreshToken(t *testing.T) {
+	token, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		t.Fatalf("Failed to generate refresh token: %v", err)
+	}
+	if token == "" || hash == "" {
+		t.Fatal("Generated refresh token or hash is empty")
+	}
+	if hash == token {
+		t.Error("Expected hash to differ from the raw token")
+	}
+	if auth.HashRefreshToken(token) != hash {
+		t.Error("Expected HashRefreshToken to match the generated hash")
+	}
+
+	other, _, _ := auth.NewRefreshToken()
+	if other == token {
+		t.Error("Expected refresh tokens to be unique")
+	}
+}
+
 func TestExpiredToken(t *testing.T) {
 	// This would require mocking the time or having a way to generate expired tokens
 	// Since pkg/auth doesn't support this easily, we'll skip for now or
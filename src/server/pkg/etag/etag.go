@@ -0,0 +1,63 @@
+// Package etag turns resource versions into HTTP entity tags and evaluates
+// If-Match / If-None-Match preconditions against them (RFC 9110 §13).
+package etag
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+)
+
+const (
+	Header            = "ETag"
+	IfMatchHeader     = "If-Match"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+var ErrInvalidIfMatch = apperr.Validation(`If-Match must be a single entity tag such as "v3" or *`)
+
+// Format returns the strong entity tag for a version, e.g. "v3".
+func Format(version int) string {
+	return `"v` + strconv.Itoa(version) + `"`
+}
+
+// ParseIfMatch returns the version an If-Match header requires.
+// An empty header or * yields 0, meaning no version precondition.
+func ParseIfMatch(header string) (int, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	version, ok := parse(header)
+	if !ok {
+		return 0, ErrInvalidIfMatch
+	}
+	return version, nil
+}
+
+// NoneMatch reports whether an If-None-Match header matches the given version,
+// i.e. whether the client's cached copy is current. Weak tags compare equal.
+func NoneMatch(header string, version int) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if v, ok := parse(strings.TrimPrefix(tag, "W/")); ok && v == version {
+			return true
+		}
+	}
+	return false
+}
+
+func parse(tag string) (int, bool) {
+	if len(tag) < 4 || !strings.HasPrefix(tag, `"v`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+	version, err := strconv.Atoi(tag[2 : len(tag)-1])
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
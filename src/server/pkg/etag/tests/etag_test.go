@@ -0,0 +1,37 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/etag"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	cases := map[string]int{"": 0, "*": 0, `"v3"`: 3, ` "v12" `: 12}
+	for header, want := range cases {
+		got, err := etag.ParseIfMatch(header)
+		if err != nil || got != want {
+			t.Errorf("ParseIfMatch(%q) = %d, %v; want %d", header, got, err, want)
+		}
+	}
+
+	for _, header := range []string{"v3", `"3"`, `"v0"`, `"v1", "v2"`, `W/"v3"`} {
+		if _, err := etag.ParseIfMatch(header); !errors.Is(err, apperr.ErrValidation) {
+			t.Errorf("Expected a validation error for %q, got %v", header, err)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	if !etag.NoneMatch(etag.Format(4), 4) {
+		t.Error("Expected the current tag to match")
+	}
+	if !etag.NoneMatch(`"v1", W/"v4"`, 4) || !etag.NoneMatch("*", 4) {
+		t.Error("Expected lists, weak tags and * to match")
+	}
+	if etag.NoneMatch(`"v3"`, 4) || etag.NoneMatch("", 4) {
+		t.Error("Expected stale or missing tags not to match")
+	}
+}
@@ -0,0 +1,88 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const usage = "usage: migrate up | down [steps] | status"
+
+// Run opens a dedicated connection to connStr and applies all pending migrations.
+func Run(ctx context.Context, connStr string, fsys fs.FS) (int, error) {
+	conn, err := pgx.Connect(ctx, connStr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close(context.Background())
+
+	m, err := New(conn, fsys)
+	if err != nil {
+		return 0, err
+	}
+	return m.Up(ctx)
+}
+
+// Command implements the `migrate up|down|status` CLI subcommand.
+func Command(ctx context.Context, connStr string, fsys fs.FS, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	conn, err := pgx.Connect(ctx, connStr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	m, err := New(conn, fsys)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := m.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "applied %d migration(s)\n", n)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps %q: %s", args[1], usage)
+			}
+		}
+		n, err := m.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "reverted %d migration(s)\n", n)
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(out, "%03d_%-24s %s\n", s.Version, s.Name, applied)
+		}
+
+	default:
+		return fmt.Errorf("unknown command %q: %s", args[0], usage)
+	}
+
+	return nil
+}
@@ -0,0 +1,157 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fayzzzm/go-bro/migrations"
+	"github.com/fayzzzm/go-bro/pkg/migrate"
+	"github.com/jackc/pgx/v5"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"010_second.sql":     {Data: []byte("CREATE TABLE b ();")},
+		"002_first.sql":      {Data: []byte("CREATE TABLE a ();")},
+		"002_first.down.sql": {Data: []byte("DROP TABLE a;")},
+		"embed.go":           {Data: []byte("package migrations")},
+		"100_third_part.sql": {Data: []byte("SELECT 1;")},
+	}
+
+	migs, err := migrate.Load(fsys)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if len(migs) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migs))
+	}
+	if migs[0].Version != 2 || migs[1].Version != 10 || migs[2].Version != 100 {
+		t.Errorf("Expected versions sorted 2, 10, 100, got %d, %d, %d", migs[0].Version, migs[1].Version, migs[2].Version)
+	}
+	if migs[0].Down != "DROP TABLE a;" {
+		t.Errorf("Expected down script for 002, got %q", migs[0].Down)
+	}
+	if migs[1].Down != "" {
+		t.Errorf("Expected no down script for 010, got %q", migs[1].Down)
+	}
+	if migs[2].Name != "third_part" {
+		t.Errorf("Expected name third_part, got %s", migs[2].Name)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{
+			name: "Missing version",
+			fsys: fstest.MapFS{"init.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "Non-numeric version",
+			fsys: fstest.MapFS{"abc_init.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "Down without up",
+			fsys: fstest.MapFS{"001_init.down.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "Duplicate version",
+			fsys: fstest.MapFS{
+				"001_init.sql":  {Data: []byte("SELECT 1;")},
+				"001_other.sql": {Data: []byte("SELECT 2;")},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := migrate.Load(tc.fsys); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migs, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Failed to load embedded migrations: %v", err)
+	}
+	if len(migs) == 0 {
+		t.Fatal("Expected embedded migrations, got none")
+	}
+
+	for _, m := range migs {
+		if m.Down == "" {
+			t.Errorf("Migration %03d_%s has no down file", m.Version, m.Name)
+		}
+	}
+}
+
+// TestRunBaselinesHandMigratedSchema applies 001 to 006 by hand, as databases
+// were migrated before the runner, in a scratch database and runs the rest.
+func TestRunBaselinesHandMigratedSchema(t *testing.T) {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("DATABASE_URL not set, skipping integration test")
+	}
+
+	ctx := context.Background()
+	admin, err := pgx.Connect(ctx, dbURL)
+	if err != nil {
+		t.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer admin.Close(ctx)
+
+	name := fmt.Sprintf("migrate_baseline_%d", time.Now().UnixNano())
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+name); err != nil {
+		t.Fatalf("Failed to create scratch database: %v", err)
+	}
+	defer admin.Exec(ctx, "DROP DATABASE "+name+" WITH (FORCE)")
+
+	scratch, err := url.Parse(dbURL)
+	if err != nil {
+		t.Fatalf("Invalid DATABASE_URL: %v", err)
+	}
+	scratch.Path = "/" + name
+
+	migs, err := migrate.Load(migrations.FS)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	conn, err := pgx.Connect(ctx, scratch.String())
+	if err != nil {
+		t.Fatalf("Failed to connect to scratch database: %v", err)
+	}
+	for _, m := range migs {
+		if m.Version > migrate.BaselineVersion {
+			break
+		}
+		if _, err := conn.Exec(ctx, m.Up); err != nil {
+			t.Fatalf("Applying %03d_%s by hand failed: %v", m.Version, m.Name, err)
+		}
+	}
+	conn.Close(ctx)
+
+	applied, err := migrate.Run(ctx, scratch.String(), migrations.FS)
+	if err != nil {
+		t.Fatalf("Run on a hand-migrated schema failed: %v", err)
+	}
+	pending := 0
+	for _, m := range migs {
+		if m.Version > migrate.BaselineVersion {
+			pending++
+		}
+	}
+	if applied != pending {
+		t.Errorf("Expected %d migrations applied after the baseline, got %d", pending, applied)
+	}
+}
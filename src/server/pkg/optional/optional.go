@@ -0,0 +1,52 @@
+// Package optional provides a tri-state field for partial updates.
+//
+// A Field decoded from JSON is either absent (Set false), explicitly null
+// (Set true, Value nil) or set to a value, which is what RFC 7396 JSON Merge
+// Patch needs to tell "leave alone" from "clear".
+package optional
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+type Field[T any] struct {
+	Set   bool
+	Value *T
+}
+
+// Of returns a field set to v.
+func Of[T any](v T) Field[T] {
+	return Field[T]{Set: true, Value: &v}
+}
+
+// Null returns a field explicitly set to null.
+func Null[T any]() Field[T] {
+	return Field[T]{Set: true}
+}
+
+// IsNull reports whether the field was explicitly set to null.
+func (f Field[T]) IsNull() bool {
+	return f.Set && f.Value == nil
+}
+
+// UnmarshalJSON is only called for keys present in the document, so an
+// absent key leaves the zero Field (not set).
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		f.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	f.Value = &v
+	return nil
+}
+
+// MarshalJSON writes the value, or null when the field is unset or null.
+func (f Field[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(f.Value)
+}
@@ -0,0 +1,38 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/pkg/optional"
+)
+
+type patch struct {
+	Title       optional.Field[string] `json:"title"`
+	Description optional.Field[string] `json:"description"`
+	Completed   optional.Field[bool]   `json:"completed"`
+}
+
+func TestField_TriState(t *testing.T) {
+	var p patch
+	if err := json.Unmarshal([]byte(`{"title": "Buy milk", "description": null}`), &p); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+
+	if !p.Title.Set || p.Title.Value == nil || *p.Title.Value != "Buy milk" {
+		t.Errorf("Expected title to be set, got %+v", p.Title)
+	}
+	if !p.Description.IsNull() {
+		t.Errorf("Expected description to be explicitly null, got %+v", p.Description)
+	}
+	if p.Completed.Set {
+		t.Errorf("Expected completed to be absent, got %+v", p.Completed)
+	}
+}
+
+func TestField_WrongType(t *testing.T) {
+	var p patch
+	if err := json.Unmarshal([]byte(`{"completed": "yes"}`), &p); err == nil {
+		t.Error("Expected an error for a non-boolean completed")
+	}
+}
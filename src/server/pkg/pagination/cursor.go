@@ -0,0 +1,120 @@
+// Package pagination implements opaque, signed keyset cursors and the page window
+// shared by every list endpoint.
+//
+// A cursor points at the (created_at, id) of the row on the edge of a page. It is
+// HMAC-signed so clients can't forge positions, and is only meaningful for the
+// list it was issued by.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or fail signature checks.
+var ErrInvalidCursor = apperr.Validation("invalid cursor")
+
+// ErrNoCursorSecret is returned in release mode when CURSOR_SECRET is not set.
+var ErrNoCursorSecret = errors.New("no cursor secret configured: set CURSOR_SECRET")
+
+// Direction tells the list which side of the cursor to read.
+type Direction string
+
+const (
+	// Next reads rows older than the cursor.
+	Next Direction = "next"
+	// Prev reads rows newer than the cursor.
+	Prev Direction = "prev"
+)
+
+// Cursor is a position in a list sorted by (created_at DESC, id DESC).
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int
+	Dir       Direction
+}
+
+// payload is the signed wire form of a cursor.
+type payload struct {
+	T   int64     `json:"t"`
+	ID  int       `json:"id"`
+	Dir Direction `json:"d"`
+}
+
+// Codec signs and verifies cursors.
+type Codec struct {
+	secret []byte
+}
+
+func NewCodec(secret []byte) *Codec {
+	return &Codec{secret: secret}
+}
+
+// NewCodecFromEnv reads CURSOR_SECRET. Outside release mode it falls back to a random
+// secret, which invalidates outstanding cursors on restart.
+func NewCodecFromEnv() (*Codec, error) {
+	if secret := os.Getenv("CURSOR_SECRET"); secret != "" {
+		return NewCodec([]byte(secret)), nil
+	}
+
+	if os.Getenv("GIN_MODE") == "release" {
+		return nil, ErrNoCursorSecret
+	}
+
+	log.Println("⚠️ Warning: no CURSOR_SECRET configured, using a random one (cursors won't survive a restart)")
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return NewCodec(secret), nil
+}
+
+// Encode returns the opaque form "<payload>.<signature>", both base64url.
+func (c *Codec) Encode(cur Cursor) string {
+	raw, _ := json.Marshal(payload{T: cur.CreatedAt.UnixMicro(), ID: cur.ID, Dir: cur.Dir})
+	body := base64.RawURLEncoding.EncodeToString(raw)
+	return body + "." + base64.RawURLEncoding.EncodeToString(c.sign(body))
+}
+
+// Decode verifies and parses a cursor produced by Encode.
+func (c *Codec) Decode(s string) (*Cursor, error) {
+	body, sig, ok := strings.Cut(s, ".")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, c.sign(body)) {
+		return nil, ErrInvalidCursor
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var p payload
+	if err := json.Unmarshal(raw, &p); err != nil || p.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+	if p.Dir != Next && p.Dir != Prev {
+		return nil, ErrInvalidCursor
+	}
+
+	return &Cursor{CreatedAt: time.UnixMicro(p.T).UTC(), ID: p.ID, Dir: p.Dir}, nil
+}
+
+func (c *Codec) sign(body string) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write([]byte(body))
+	return mac.Sum(nil)
+}
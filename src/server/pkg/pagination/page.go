@@ -0,0 +1,105 @@
+package pagination
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultLimit is used when the client asks for no or a non-positive limit.
+const DefaultLimit = 100
+
+// TotalCountHeader carries the number of rows matching a listing across all pages.
+const TotalCountHeader = "X-Total-Count"
+
+// Page selects a slice of a list. With a Cursor it is keyset pagination and
+// Offset is ignored; without one it falls back to limit/offset.
+type Page struct {
+	Limit  int
+	Offset int
+	Cursor *Cursor
+
+	// SkipTotal leaves Result.Total nil, saving a count query.
+	SkipTotal bool
+}
+
+// Normalize applies the default limit and clamps a negative offset.
+func (p Page) Normalize() Page {
+	if p.Limit <= 0 {
+		p.Limit = DefaultLimit
+	}
+	if p.Offset < 0 || p.Cursor != nil {
+		p.Offset = 0
+	}
+	return p
+}
+
+// Probe is the page to fetch from storage: one extra row tells whether more rows exist.
+func (p Page) Probe() Page {
+	p.Limit++
+	return p
+}
+
+// Result is one page of items with cursors to its neighbours; a nil cursor means no more rows.
+// Total counts every matching row, or is nil when the page skipped it.
+type Result[T any] struct {
+	Items []T
+	Next  *Cursor
+	Prev  *Cursor
+	Total *int
+}
+
+// Window trims rows fetched with page.Probe() to page.Limit and derives the
+// neighbouring cursors. key returns the (created_at, id) of an item.
+func Window[T any](rows []T, page Page, key func(T) Cursor) Result[T] {
+	backward := page.Cursor != nil && page.Cursor.Dir == Prev
+	hasMore := len(rows) > page.Limit
+
+	// A backward read returns newest first, so the extra row sits at the front
+	if hasMore {
+		if backward {
+			rows = rows[len(rows)-page.Limit:]
+		} else {
+			rows = rows[:page.Limit]
+		}
+	}
+
+	res := Result[T]{Items: rows}
+	if len(rows) == 0 {
+		return res
+	}
+
+	first, last := key(rows[0]), key(rows[len(rows)-1])
+	first.Dir, last.Dir = Prev, Next
+
+	if backward {
+		res.Next = &last
+		if hasMore {
+			res.Prev = &first
+		}
+		return res
+	}
+
+	if hasMore {
+		res.Next = &last
+	}
+	if page.Cursor != nil || page.Offset > 0 {
+		res.Prev = &first
+	}
+	return res
+}
+
+// LinkHeader builds an RFC 8288 Link header pointing at the next and previous pages.
+// Other query parameters, e.g. limit, are kept; offset is dropped in favour of the cursor.
+func LinkHeader(u *url.URL, next, prev string) string {
+	var links []string
+	for _, l := range []struct{ rel, cursor string }{{"next", next}, {"prev", prev}} {
+		if l.cursor == "" {
+			continue
+		}
+		q := u.Query()
+		q.Del("offset")
+		q.Set("cursor", l.cursor)
+		links = append(links, "<"+u.Path+"?"+q.Encode()+`>; rel="`+l.rel+`"`)
+	}
+	return strings.Join(links, ", ")
+}
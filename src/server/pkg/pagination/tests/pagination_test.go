@@ -0,0 +1,61 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := pagination.NewCodec([]byte("secret"))
+	want := pagination.Cursor{
+		CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC),
+		ID:        42,
+		Dir:       pagination.Prev,
+	}
+
+	got, err := codec.Decode(codec.Encode(want))
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) || got.ID != want.ID || got.Dir != want.Dir {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestCodec_RejectsForgedCursors(t *testing.T) {
+	codec := pagination.NewCodec([]byte("secret"))
+	other := pagination.NewCodec([]byte("other-secret"))
+	token := other.Encode(pagination.Cursor{CreatedAt: time.Now(), ID: 1, Dir: pagination.Next})
+
+	for name, raw := range map[string]string{
+		"wrong key":     token,
+		"no signature":  "eyJ0IjoxLCJpZCI6MSwiZCI6Im5leHQifQ",
+		"garbage":       "!!!.???",
+		"empty payload": ".",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := codec.Decode(raw)
+			if apperr.KindOf(err) != apperr.KindValidation {
+				t.Errorf("Expected validation error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestLinkHeader(t *testing.T) {
+	u, _ := url.Parse("/api/v1/todos?limit=20&offset=40")
+
+	got := pagination.LinkHeader(u, "abc", "xyz")
+	want := `</api/v1/todos?cursor=abc&limit=20>; rel="next", </api/v1/todos?cursor=xyz&limit=20>; rel="prev"`
+	if got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if got := pagination.LinkHeader(u, "", ""); got != "" {
+		t.Errorf("Expected no header without cursors, got %s", got)
+	}
+}
@@ -0,0 +1,178 @@
+package reply
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ProblemContentType is the RFC 7807 media type for error responses.
+const ProblemContentType = "application/problem+json"
+
+// RequestIDKey is where the RequestID middleware stores the request ID.
+const RequestIDKey = "request_id"
+
+// Problem is an RFC 7807 error body.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError points at one invalid request field by its JSON name.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSONFieldName makes validator report fields by their json tag, e.g. "password"
+// instead of "SignupRequest.Password". Register it with RegisterTagNameFunc.
+func JSONFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// writeProblem aborts the request and writes the problem as application/problem+json.
+func writeProblem(c *gin.Context, p Problem) {
+	if c.Request != nil {
+		p.Instance = c.Request.URL.Path
+	}
+	p.RequestID = c.GetString(RequestIDKey)
+
+	body, err := json.Marshal(p)
+	if err != nil {
+		c.AbortWithStatus(p.Status)
+		return
+	}
+	c.Abort()
+	c.Data(p.Status, ProblemContentType, body)
+}
+
+// newProblem classifies err into a problem, using code and message for unclassified errors.
+func newProblem(code int, message string, err error) Problem {
+	var appErr *apperr.Error
+	var validationErrs validator.ValidationErrors
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.As(err, &appErr) && statusByKind[appErr.Kind] != 0:
+		return Problem{
+			Type:   problemType(appErr.Kind.String()),
+			Title:  http.StatusText(statusByKind[appErr.Kind]),
+			Status: statusByKind[appErr.Kind],
+			Detail: appErr.Message,
+		}
+
+	case errors.As(err, &validationErrs):
+		fields := make([]FieldError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = FieldError{Field: fieldPath(fe), Code: fe.Tag(), Message: fieldMessage(fe)}
+		}
+		return Problem{
+			Type:   problemType("validation"),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: "request body failed validation",
+			Errors: fields,
+		}
+
+	case errors.As(err, &typeErr):
+		return Problem{
+			Type:   problemType("validation"),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: "request body failed validation",
+			Errors: []FieldError{{
+				Field:   typeErr.Field,
+				Code:    "type",
+				Message: fmt.Sprintf("must be of type %s", typeErr.Type.Kind()),
+			}},
+		}
+
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return Problem{
+			Type:   problemType("malformed-body"),
+			Title:  http.StatusText(http.StatusBadRequest),
+			Status: http.StatusBadRequest,
+			Detail: "request body is not valid JSON",
+		}
+	}
+
+	return Problem{
+		Type:   problemType(slug(http.StatusText(code))),
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: message,
+	}
+}
+
+func problemType(name string) string {
+	return "/problems/" + strings.ReplaceAll(name, "_", "-")
+}
+
+func slug(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, " ", "-"))
+}
+
+// fieldPath drops the root struct name: "SignupRequest.password" -> "password".
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	} else if fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map {
+		unit = " items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min", "gte":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "max", "lte":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("must be greater than %s%s", fe.Param(), unit)
+	case "lt":
+		return fmt.Sprintf("must be less than %s%s", fe.Param(), unit)
+	case "len":
+		return fmt.Sprintf("must be exactly %s%s", fe.Param(), unit)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.ReplaceAll(fe.Param(), " ", ", "))
+	case "url":
+		return "must be a valid URL"
+	case "uuid":
+		return "must be a valid UUID"
+	default:
+		return fmt.Sprintf("failed the %s rule", fe.Tag())
+	}
+}
@@ -4,37 +4,51 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/fayzzzm/go-bro/pkg/apperr"
 	"github.com/gin-gonic/gin"
 )
 
-// Error sends a JSON error response and returns true if an error exists.
+// statusByKind is the single place domain error kinds become HTTP status codes.
+var statusByKind = map[apperr.Kind]int{
+	apperr.KindNotFound:     http.StatusNotFound,
+	apperr.KindConflict:     http.StatusConflict,
+	apperr.KindValidation:   http.StatusBadRequest,
+	apperr.KindUnauthorized: http.StatusUnauthorized,
+	apperr.KindForbidden:    http.StatusForbidden,
+
+	apperr.KindPreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// Error aborts with an RFC 7807 problem response and returns true if an error exists.
+// Domain errors (pkg/apperr) and binding/validation errors pick their own status and
+// detail; code and message are the fallback for anything outside the taxonomy.
 func Error(c *gin.Context, code int, message string, err error) bool {
 	if err != nil {
-		log.Printf("[REPLY ERROR] %d %s: %v", code, message, err)
-		c.JSON(code, gin.H{"error": message})
+		problem := newProblem(code, message, err)
+		log.Printf("[REPLY ERROR] %s %d %s: %v", c.GetString(RequestIDKey), problem.Status, problem.Detail, err)
+		writeProblem(c, problem)
 		return true
 	}
 	return false
 }
 
+// ProblemFor classifies err as Error does, for errors sent outside an HTTP
+// response such as over a WebSocket. The error is logged under requestID.
+func ProblemFor(requestID string, code int, message string, err error) Problem {
+	problem := newProblem(code, message, err)
+	problem.RequestID = requestID
+	log.Printf("[REPLY ERROR] %s %d %s: %v", requestID, problem.Status, problem.Detail, err)
+	return problem
+}
+
 // NotFound is a specialized version of Error for 404 responses.
 func NotFound(c *gin.Context, err error) bool {
-	if err != nil {
-		log.Printf("[REPLY NOT FOUND]: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
-		return true
-	}
-	return false
+	return Error(c, http.StatusNotFound, "resource not found", err)
 }
 
 // InternalError is a specialized version of Error for 500 responses.
 func InternalError(c *gin.Context, err error) bool {
-	if err != nil {
-		log.Printf("[REPLY INTERNAL ERROR]: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
-		return true
-	}
-	return false
+	return Error(c, http.StatusInternalServerError, "internal server error", err)
 }
 
 // OK sends a 200 OK response.
@@ -46,3 +60,13 @@ func OK(c *gin.Context, data any) {
 func Created(c *gin.Context, data any) {
 	c.JSON(http.StatusCreated, data)
 }
+
+// NotModified sends a bodiless 304 Not Modified response.
+func NotModified(c *gin.Context) {
+	c.Status(http.StatusNotModified)
+}
+
+// MultiStatus sends a 207 Multi-Status response for batches where some parts failed.
+func MultiStatus(c *gin.Context, data any) {
+	c.JSON(http.StatusMultiStatus, data)
+}
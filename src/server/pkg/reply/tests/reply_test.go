@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/reply"
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorMapping(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	testCases := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{"Not found", apperr.NotFound("todo not found"), http.StatusNotFound, "todo not found"},
+		{"Conflict", apperr.Conflict("email already exists"), http.StatusConflict, "email already exists"},
+		{"Validation", apperr.Validation("name required"), http.StatusBadRequest, "name required"},
+		{"Unauthorized", apperr.ErrUnauthorized, http.StatusUnauthorized, "unauthorized"},
+		{"Forbidden", apperr.Forbidden("admins only"), http.StatusForbidden, "admins only"},
+		{"Plain error uses fallback", errors.New("connection refused"), http.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(w)
+
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/api/v1/todos/1", nil)
+			ctx.Set(reply.RequestIDKey, "req-123")
+
+			if !reply.InternalError(ctx, tc.err) {
+				t.Fatal("Expected InternalError to report the error")
+			}
+
+			if w.Code != tc.wantStatus {
+				t.Errorf("Expected status %d, got %d", tc.wantStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != reply.ProblemContentType {
+				t.Errorf("Expected content type %s, got %s", reply.ProblemContentType, ct)
+			}
+
+			var problem reply.Problem
+			json.Unmarshal(w.Body.Bytes(), &problem)
+			if problem.Detail != tc.wantMessage {
+				t.Errorf("Expected detail %q, got %q", tc.wantMessage, problem.Detail)
+			}
+			if problem.Status != tc.wantStatus || problem.Title != http.StatusText(tc.wantStatus) {
+				t.Errorf("Expected status %d with its title, got %d %q", tc.wantStatus, problem.Status, problem.Title)
+			}
+			if problem.Type == "" || problem.Instance != "/api/v1/todos/1" || problem.RequestID != "req-123" {
+				t.Errorf("Expected type, instance and request ID, got %+v", problem)
+			}
+		})
+	}
+}
+
+func TestErrorNil(t *testing.T) {
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+
+	if reply.Error(ctx, http.StatusBadRequest, "bad request", nil) {
+		t.Error("Expected no reply for a nil error")
+	}
+}
+
+func TestProblemFor(t *testing.T) {
+	problem := reply.ProblemFor("req-1", http.StatusInternalServerError, "internal server error", apperr.Forbidden("no access"))
+	if problem.Status != http.StatusForbidden || problem.Detail != "no access" || problem.RequestID != "req-1" {
+		t.Errorf("Expected a 403 problem for req-1, got %+v", problem)
+	}
+
+	problem = reply.ProblemFor("req-2", http.StatusInternalServerError, "internal server error", errors.New("connection refused"))
+	if problem.Status != http.StatusInternalServerError || problem.Detail != "internal server error" {
+		t.Errorf("Expected the fallback to hide the cause, got %+v", problem)
+	}
+}
@@ -0,0 +1,292 @@
+package rrule
+
+import "time"
+
+// maxEmptyPeriods stops rules that can never match, e.g. 30 February. It is
+// long enough for 29 February under a DAILY rule across a skipped leap year.
+const maxEmptyPeriods = 3000
+
+// Iterator yields the occurrences of a rule from DTSTART on, in order.
+type Iterator struct {
+	rule    *Rule
+	start   time.Time
+	until   time.Time
+	period  int
+	empty   int
+	emitted int
+	buf     []time.Time
+	done    bool
+}
+
+// Iterator expands the rule from dtstart; its location decides the wall clock
+// every occurrence keeps, across DST changes too. dtstart itself is only an
+// occurrence when it matches the rule.
+func (r *Rule) Iterator(dtstart time.Time) *Iterator {
+	it := &Iterator{rule: r, start: dtstart, until: r.Until}
+	if r.untilFloating {
+		u := r.Until
+		it.until = time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), 0, dtstart.Location())
+	}
+	return it
+}
+
+// Next returns the next occurrence, or false once the rule has ended.
+func (it *Iterator) Next() (time.Time, bool) {
+	for len(it.buf) == 0 {
+		if it.done {
+			return time.Time{}, false
+		}
+		it.expand()
+	}
+
+	t := it.buf[0]
+	it.buf = it.buf[1:]
+	if !it.until.IsZero() && t.After(it.until) {
+		it.done, it.buf = true, nil
+		return time.Time{}, false
+	}
+	it.emitted++
+	if it.rule.Count > 0 && it.emitted >= it.rule.Count {
+		it.done, it.buf = true, nil
+	}
+	return t, true
+}
+
+// After returns the first occurrence strictly after t.
+func (r *Rule) After(dtstart, t time.Time) (time.Time, bool) {
+	it := r.Iterator(dtstart)
+	for {
+		next, ok := it.Next()
+		if !ok || next.After(t) {
+			return next, ok
+		}
+	}
+}
+
+// Take returns up to n occurrences from dtstart on.
+func (r *Rule) Take(dtstart time.Time, n int) []time.Time {
+	var out []time.Time
+	it := r.Iterator(dtstart)
+	for len(out) < n {
+		t, ok := it.Next()
+		if !ok {
+			break
+		}
+		out = append(out, t)
+	}
+	return out
+}
+
+// expand fills buf with the occurrences of the next period.
+func (it *Iterator) expand() {
+	candidates := it.rule.period(it.start, it.period)
+	it.period++
+
+	candidates = it.rule.applySetPos(candidates)
+	for _, t := range candidates {
+		if !t.Before(it.start) {
+			it.buf = append(it.buf, t)
+		}
+	}
+
+	if len(it.buf) > 0 {
+		it.empty = 0
+		return
+	}
+	it.empty++
+	// Every later period starts after the last candidate of this one
+	if it.empty > maxEmptyPeriods || (!it.until.IsZero() && it.periodStart(it.period).After(it.until)) {
+		it.done = true
+	}
+}
+
+// periodStart is the first day of period k, for ending rules whose periods match nothing.
+func (it *Iterator) periodStart(k int) time.Time {
+	y, m, d := it.start.Date()
+	n := k * it.rule.Interval
+	loc := it.start.Location()
+	switch it.rule.Freq {
+	case Daily:
+		return time.Date(y, m, d+n, 0, 0, 0, 0, loc)
+	case Weekly:
+		return time.Date(y, m, d+7*n-weekOffset(it.start.Weekday(), it.rule.WeekStart), 0, 0, 0, 0, loc)
+	case Monthly:
+		return time.Date(y, m+time.Month(n), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(y+n, time.January, 1, 0, 0, 0, 0, loc)
+	}
+}
+
+// period lists the candidate occurrences of period k, sorted.
+func (r *Rule) period(start time.Time, k int) []time.Time {
+	y, m, d := start.Date()
+	n := k * r.Interval
+	var days []time.Time
+
+	switch r.Freq {
+	case Daily:
+		day := date(y, m, d+n, start)
+		if r.inMonth(day.Month()) && r.matchDay(day, 0, 0) {
+			days = append(days, day)
+		}
+
+	case Weekly:
+		first := d + 7*n - weekOffset(start.Weekday(), r.WeekStart)
+		for i := 0; i < 7; i++ {
+			day := date(y, m, first+i, start)
+			if !r.inMonth(day.Month()) {
+				continue
+			}
+			match := day.Weekday() == start.Weekday()
+			if len(r.ByDay) > 0 {
+				match = r.matchDay(day, 0, 0)
+			}
+			if match {
+				days = append(days, day)
+			}
+		}
+
+	case Monthly:
+		first := date(y, m+time.Month(n), 1, start)
+		if r.inMonth(first.Month()) {
+			days = r.monthDays(first, start.Day())
+		}
+
+	case Yearly:
+		year := y + n
+		switch {
+		case len(r.ByMonth) > 0:
+			for _, month := range r.ByMonth {
+				days = append(days, r.monthDays(date(year, month, 1, start), start.Day())...)
+			}
+		case len(r.ByMonthDay) > 0:
+			for month := time.January; month <= time.December; month++ {
+				days = append(days, r.monthDays(date(year, month, 1, start), start.Day())...)
+			}
+		case len(r.ByDay) > 0:
+			// Numbered weekdays count within the year
+			length := date(year, time.December, 31, start).YearDay()
+			for i := 1; i <= length; i++ {
+				if day := date(year, time.January, i, start); r.matchDay(day, i, length) {
+					days = append(days, day)
+				}
+			}
+		default:
+			if day := date(year, m, d, start); day.Month() == m {
+				days = append(days, day)
+			}
+		}
+	}
+
+	out := make([]time.Time, len(days))
+	for i, day := range days {
+		out[i] = time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	sortTimes(out)
+	return out
+}
+
+// monthDays lists the matching days of first's month; without BYMONTHDAY and
+// BYDAY that is the day of DTSTART, skipped in months too short for it.
+func (r *Rule) monthDays(first time.Time, startDay int) []time.Time {
+	length := monthLength(first)
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if startDay <= length {
+			days = append(days, first.AddDate(0, 0, startDay-1))
+		}
+		return days
+	}
+	for i := 1; i <= length; i++ {
+		if day := first.AddDate(0, 0, i-1); r.matchDay(day, i, length) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// matchDay applies BYMONTHDAY and BYDAY. pos is the 1-based place of day in the
+// month or year numbered weekdays count within, length the size of that scope.
+func (r *Rule) matchDay(day time.Time, pos, length int) bool {
+	if len(r.ByMonthDay) > 0 {
+		days := monthLength(day)
+		found := false
+		for _, md := range r.ByMonthDay {
+			if md == day.Day() || md < 0 && days+md+1 == day.Day() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Day != day.Weekday() {
+			continue
+		}
+		if wd.N == 0 || wd.N > 0 && (pos-1)/7+1 == wd.N || wd.N < 0 && -((length-pos)/7+1) == wd.N {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) inMonth(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+// applySetPos keeps the BYSETPOS-th candidates of a period.
+func (r *Rule) applySetPos(candidates []time.Time) []time.Time {
+	if len(r.BySetPos) == 0 {
+		return candidates
+	}
+	var out []time.Time
+	for _, pos := range r.BySetPos {
+		i := pos - 1
+		if pos < 0 {
+			i = len(candidates) + pos
+		}
+		if i >= 0 && i < len(candidates) {
+			out = append(out, candidates[i])
+		}
+	}
+	sortTimes(out)
+	return dedupe(out)
+}
+
+func dedupe(ts []time.Time) []time.Time {
+	var out []time.Time
+	for _, t := range ts {
+		if len(out) == 0 || !t.Equal(out[len(out)-1]) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// date normalizes y-m-d (overflowing days roll over) at midnight in ref's location.
+func date(y int, m time.Month, d int, ref time.Time) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, ref.Location())
+}
+
+// monthLength is the number of days in day's month.
+func monthLength(day time.Time) int {
+	return time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// weekOffset is how many days day lies after the start of its week.
+func weekOffset(day, weekStart time.Weekday) int {
+	return (int(day) - int(weekStart) + 7) % 7
+}
@@ -0,0 +1,291 @@
+// Package rrule parses RFC 5545 recurrence rules and expands them into
+// occurrence times (RFC 5545 §3.3.10).
+//
+// It covers what recurring todos need: FREQ of DAILY, WEEKLY, MONTHLY or
+// YEARLY with INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY, BYMONTH, BYSETPOS and
+// WKST. Sub-daily frequencies, BYYEARDAY, BYWEEKNO and the BYHOUR family are
+// rejected; every occurrence keeps the wall-clock time of DTSTART.
+package rrule
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Weekday is one BYDAY entry. N picks the Nth such weekday of the month or
+// year (negative counts from the end); 0 means every one.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+// Rule is a parsed RRULE. A zero Until and Count mean the rule never ends.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+	BySetPos   []int
+	WeekStart  time.Weekday
+
+	// untilFloating marks an UNTIL without a zone, read in DTSTART's location
+	untilFloating bool
+}
+
+var weekdayNames = [...]string{"SU", "MO", "TU", "WE", "TH", "FR", "SA"}
+
+const (
+	untilUTC      = "20060102T150405Z"
+	untilFloating = "20060102T150405"
+	untilDate     = "20060102"
+)
+
+// Parse reads an RRULE value such as "FREQ=WEEKLY;BYDAY=MO,WE". A leading
+// "RRULE:" is accepted.
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 6 && strings.EqualFold(s[:6], "RRULE:") {
+		s = s[6:]
+	}
+	if s == "" {
+		return nil, errors.New("rrule: empty rule")
+	}
+
+	r := &Rule{Interval: 1, WeekStart: time.Monday}
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		name, value, ok := strings.Cut(part, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		value = strings.ToUpper(strings.TrimSpace(value))
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("rrule: malformed part %q", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("rrule: %s given twice", name)
+		}
+		seen[name] = true
+
+		var err error
+		switch name {
+		case "FREQ":
+			r.Freq, err = parseFreq(value)
+		case "INTERVAL":
+			r.Interval, err = parseInt(name, value, 1, 10000)
+		case "COUNT":
+			r.Count, err = parseInt(name, value, 1, 100000)
+		case "UNTIL":
+			err = r.parseUntil(value)
+		case "BYDAY":
+			r.ByDay, err = parseList(value, parseWeekday)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseList(value, signedInt(name, 31))
+		case "BYMONTH":
+			r.ByMonth, err = parseList(value, func(v string) (time.Month, error) {
+				m, err := parseInt(name, v, 1, 12)
+				return time.Month(m), err
+			})
+		case "BYSETPOS":
+			r.BySetPos, err = parseList(value, signedInt(name, 366))
+		case "WKST":
+			var wd Weekday
+			wd, err = parseWeekday(value)
+			if err == nil && wd.N != 0 {
+				err = fmt.Errorf("rrule: invalid WKST %q", value)
+			}
+			r.WeekStart = wd.Day
+		default:
+			err = fmt.Errorf("rrule: %s is not supported", name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Validate checks the combinations RFC 5545 forbids or this package does not expand.
+func (r *Rule) Validate() error {
+	switch r.Freq {
+	case Daily, Weekly, Monthly, Yearly:
+	case "":
+		return errors.New("rrule: FREQ is required")
+	default:
+		return fmt.Errorf("rrule: FREQ=%s is not supported", r.Freq)
+	}
+	if r.Interval < 1 {
+		return errors.New("rrule: INTERVAL must be positive")
+	}
+	if r.Count < 0 {
+		return errors.New("rrule: COUNT must be positive")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return errors.New("rrule: COUNT and UNTIL cannot both be set")
+	}
+	if r.Freq == Weekly && len(r.ByMonthDay) > 0 {
+		return errors.New("rrule: BYMONTHDAY cannot be used with FREQ=WEEKLY")
+	}
+	if r.Freq == Daily || r.Freq == Weekly {
+		for _, wd := range r.ByDay {
+			if wd.N != 0 {
+				return fmt.Errorf("rrule: numbered BYDAY needs FREQ=MONTHLY or YEARLY")
+			}
+		}
+	}
+	if len(r.BySetPos) > 0 && len(r.ByDay)+len(r.ByMonthDay)+len(r.ByMonth) == 0 {
+		return errors.New("rrule: BYSETPOS needs another BYxxx part")
+	}
+	return nil
+}
+
+// String returns the rule in canonical form, omitting defaults.
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		if r.untilFloating {
+			parts = append(parts, "UNTIL="+r.Until.Format(untilFloating))
+		} else {
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(untilUTC))
+		}
+	}
+	if len(r.ByMonth) > 0 {
+		parts = append(parts, "BYMONTH="+joinList(r.ByMonth, func(m time.Month) string { return strconv.Itoa(int(m)) }))
+	}
+	if len(r.ByMonthDay) > 0 {
+		parts = append(parts, "BYMONTHDAY="+joinList(r.ByMonthDay, strconv.Itoa))
+	}
+	if len(r.ByDay) > 0 {
+		parts = append(parts, "BYDAY="+joinList(r.ByDay, Weekday.String))
+	}
+	if len(r.BySetPos) > 0 {
+		parts = append(parts, "BYSETPOS="+joinList(r.BySetPos, strconv.Itoa))
+	}
+	if r.WeekStart != time.Monday {
+		parts = append(parts, "WKST="+weekdayNames[r.WeekStart])
+	}
+	return strings.Join(parts, ";")
+}
+
+// String formats the entry as in BYDAY, e.g. "MO" or "-1FR".
+func (w Weekday) String() string {
+	if w.N == 0 {
+		return weekdayNames[w.Day]
+	}
+	return strconv.Itoa(w.N) + weekdayNames[w.Day]
+}
+
+func parseFreq(value string) (Frequency, error) {
+	switch f := Frequency(value); f {
+	case Daily, Weekly, Monthly, Yearly:
+		return f, nil
+	}
+	return "", fmt.Errorf("rrule: FREQ=%s is not supported", value)
+}
+
+// parseUntil accepts a UTC date-time, a floating date-time or a date. A date
+// includes the whole day.
+func (r *Rule) parseUntil(value string) error {
+	if t, err := time.Parse(untilUTC, value); err == nil {
+		r.Until = t
+		return nil
+	}
+	if t, err := time.Parse(untilFloating, value); err == nil {
+		r.Until, r.untilFloating = t, true
+		return nil
+	}
+	if t, err := time.Parse(untilDate, value); err == nil {
+		r.Until, r.untilFloating = t.Add(24*time.Hour-time.Second), true
+		return nil
+	}
+	return fmt.Errorf("rrule: invalid UNTIL %q", value)
+}
+
+func parseWeekday(value string) (Weekday, error) {
+	if len(value) < 2 {
+		return Weekday{}, fmt.Errorf("rrule: invalid weekday %q", value)
+	}
+	name, num := value[len(value)-2:], value[:len(value)-2]
+	for day, n := range weekdayNames {
+		if n != name {
+			continue
+		}
+		wd := Weekday{Day: time.Weekday(day)}
+		if num != "" {
+			v, err := signedInt("BYDAY", 53)(num)
+			if err != nil {
+				return Weekday{}, err
+			}
+			wd.N = v
+		}
+		return wd, nil
+	}
+	return Weekday{}, fmt.Errorf("rrule: invalid weekday %q", value)
+}
+
+func parseInt(name, value string, min, max int) (int, error) {
+	v, err := strconv.Atoi(value)
+	if err != nil || v < min || v > max {
+		return 0, fmt.Errorf("rrule: %s must be between %d and %d, got %q", name, min, max, value)
+	}
+	return v, nil
+}
+
+// signedInt parses a non-zero value within ±max.
+func signedInt(name string, max int) func(string) (int, error) {
+	return func(value string) (int, error) {
+		v, err := parseInt(name, strings.TrimPrefix(value, "+"), -max, max)
+		if err == nil && v == 0 {
+			err = fmt.Errorf("rrule: %s cannot be 0", name)
+		}
+		return v, err
+	}
+}
+
+func parseList[T any](value string, parse func(string) (T, error)) ([]T, error) {
+	var out []T
+	for _, item := range strings.Split(value, ",") {
+		v, err := parse(strings.TrimSpace(item))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func joinList[T any](items []T, format func(T) string) string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = format(item)
+	}
+	return strings.Join(out, ",")
+}
+
+func sortTimes(ts []time.Time) {
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Before(ts[j]) })
+}
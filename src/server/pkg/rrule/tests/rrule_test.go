@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/pkg/rrule"
+)
+
+func newYork(t *testing.T) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %v", err)
+	}
+	return loc
+}
+
+func format(ts []time.Time) []string {
+	out := make([]string, len(ts))
+	for i, ts := range ts {
+		out[i] = ts.Format("2006-01-02 15:04")
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Most cases are the examples of RFC 5545 §3.8.5.3
+func TestExpand(t *testing.T) {
+	loc := newYork(t)
+	at := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 9, 0, 0, 0, loc) }
+
+	cases := []struct {
+		rule    string
+		dtstart time.Time
+		n       int
+		want    []string
+	}{
+		{"FREQ=DAILY;COUNT=3", at(1997, 9, 2), 10,
+			[]string{"1997-09-02 09:00", "1997-09-03 09:00", "1997-09-04 09:00"}},
+		{"FREQ=DAILY;UNTIL=19970905", at(1997, 9, 2), 10,
+			[]string{"1997-09-02 09:00", "1997-09-03 09:00", "1997-09-04 09:00", "1997-09-05 09:00"}},
+		{"FREQ=WEEKLY;INTERVAL=2;WKST=SU;BYDAY=TU,TH;COUNT=8", at(1997, 9, 2), 10,
+			[]string{"1997-09-02 09:00", "1997-09-04 09:00", "1997-09-16 09:00", "1997-09-18 09:00",
+				"1997-09-30 09:00", "1997-10-02 09:00", "1997-10-14 09:00", "1997-10-16 09:00"}},
+		{"FREQ=MONTHLY;BYDAY=1FR;COUNT=4", at(1997, 9, 5), 10,
+			[]string{"1997-09-05 09:00", "1997-10-03 09:00", "1997-11-07 09:00", "1997-12-05 09:00"}},
+		{"FREQ=MONTHLY;INTERVAL=2;COUNT=6;BYDAY=1SU,-1SU", at(1997, 9, 7), 10,
+			[]string{"1997-09-07 09:00", "1997-09-28 09:00", "1997-11-02 09:00", "1997-11-30 09:00",
+				"1998-01-04 09:00", "1998-01-25 09:00"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=-3", at(1997, 9, 28), 4,
+			[]string{"1997-09-28 09:00", "1997-10-29 09:00", "1997-11-28 09:00", "1997-12-29 09:00"}},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", at(1997, 9, 30), 3,
+			[]string{"1997-09-30 09:00", "1997-10-31 09:00", "1997-11-28 09:00"}},
+		{"FREQ=YEARLY;BYMONTH=6,7;COUNT=4", at(1997, 6, 10), 10,
+			[]string{"1997-06-10 09:00", "1997-07-10 09:00", "1998-06-10 09:00", "1998-07-10 09:00"}},
+		{"FREQ=YEARLY;BYDAY=20MO", at(1997, 5, 19), 3,
+			[]string{"1997-05-19 09:00", "1998-05-18 09:00", "1999-05-17 09:00"}},
+		{"FREQ=YEARLY;BYMONTH=3;BYDAY=TH", at(1997, 3, 13), 4,
+			[]string{"1997-03-13 09:00", "1997-03-20 09:00", "1997-03-27 09:00", "1998-03-05 09:00"}},
+		// Months without a 31st are skipped, not clamped
+		{"FREQ=MONTHLY;COUNT=4", at(2024, 1, 31), 10,
+			[]string{"2024-01-31 09:00", "2024-03-31 09:00", "2024-05-31 09:00", "2024-07-31 09:00"}},
+		{"FREQ=YEARLY", at(2024, 2, 29), 2,
+			[]string{"2024-02-29 09:00", "2028-02-29 09:00"}},
+		// The wall clock holds across the end of daylight saving time
+		{"FREQ=WEEKLY;BYDAY=MO", at(2025, 10, 27), 2,
+			[]string{"2025-10-27 09:00", "2025-11-03 09:00"}},
+		// Occurrences before DTSTART are dropped
+		{"FREQ=WEEKLY;BYDAY=MO,FR", at(2025, 10, 29), 2,
+			[]string{"2025-10-31 09:00", "2025-11-03 09:00"}},
+		{"FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", at(2025, 1, 1), 1, nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.rule, func(t *testing.T) {
+			rule, err := rrule.Parse(tc.rule)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			got := rule.Take(tc.dtstart, tc.n)
+			if !equal(format(got), tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, format(got))
+			}
+			for _, ts := range got {
+				if ts.Location() != loc {
+					t.Errorf("Expected occurrences in %s, got %s", loc, ts.Location())
+				}
+			}
+		})
+	}
+}
+
+func TestUntilIsInclusive(t *testing.T) {
+	loc := newYork(t)
+	rule, err := rrule.Parse("FREQ=DAILY;UNTIL=19971224T000000Z")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	all := rule.Take(time.Date(1997, 9, 2, 9, 0, 0, 0, loc), 500)
+	if len(all) != 113 {
+		t.Errorf("Expected 113 occurrences, got %d", len(all))
+	}
+	if last := all[len(all)-1].Format("2006-01-02"); last != "1997-12-23" {
+		t.Errorf("Expected the last occurrence on 1997-12-23, got %s", last)
+	}
+}
+
+func TestAfter(t *testing.T) {
+	rule, _ := rrule.Parse("FREQ=WEEKLY;COUNT=3")
+	start := time.Date(2025, 1, 6, 10, 0, 0, 0, time.UTC)
+
+	next, ok := rule.After(start, start)
+	if !ok || !next.Equal(start.AddDate(0, 0, 7)) {
+		t.Errorf("Expected the following week, got %v %v", next, ok)
+	}
+	next, ok = rule.After(start, start.AddDate(0, 0, 10))
+	if !ok || !next.Equal(start.AddDate(0, 0, 14)) {
+		t.Errorf("Expected the third occurrence, got %v %v", next, ok)
+	}
+	if next, ok := rule.After(start, start.AddDate(0, 0, 14)); ok {
+		t.Errorf("Expected the rule to have ended, got %v", next)
+	}
+}
+
+func TestParseCanonical(t *testing.T) {
+	rule, err := rrule.Parse("rrule:freq=monthly;interval=2;byday=mo,-1fr;wkst=su")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got, want := rule.String(), "FREQ=MONTHLY;INTERVAL=2;BYDAY=MO,-1FR;WKST=SU"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	again, err := rrule.Parse(rule.String())
+	if err != nil || again.String() != rule.String() {
+		t.Errorf("Expected the canonical form to round-trip, got %v %v", again, err)
+	}
+
+	until, _ := rrule.Parse("FREQ=DAILY;INTERVAL=1;UNTIL=20250101T120000Z")
+	if got := until.String(); got != "FREQ=DAILY;UNTIL=20250101T120000Z" {
+		t.Errorf("Expected defaults dropped, got %q", got)
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, rule := range []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;FREQ=WEEKLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20250101",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=WEEKLY;BYMONTHDAY=1",
+		"FREQ=MONTHLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=MONTHLY;BYMONTH=13",
+		"FREQ=DAILY;BYSETPOS=1",
+		"FREQ=YEARLY;BYYEARDAY=1",
+		"FREQ=DAILY;COUNT",
+	} {
+		if _, err := rrule.Parse(rule); err == nil {
+			t.Errorf("Expected %q to be rejected", rule)
+		}
+	}
+}
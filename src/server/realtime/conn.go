@@ -0,0 +1,185 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// SendBuffer is how many frames a connection may fall behind before it
+	// is closed; the client reconnects and subscribes again.
+	SendBuffer = 64
+
+	// MaxMessageSize caps one frame from the client.
+	MaxMessageSize = 64 << 10
+
+	// PingInterval is how often the server pings; a client that has not
+	// answered within PongWait is disconnected.
+	PingInterval = 30 * time.Second
+	PongWait     = 60 * time.Second
+
+	writeWait = 10 * time.Second
+)
+
+var (
+	ErrClosed       = errors.New("connection closed")
+	ErrShuttingDown = errors.New("server shutting down")
+)
+
+// Conn is one WebSocket connection of an authenticated user. Frames are
+// written by a single goroutine from a bounded queue, so a slow client
+// cannot hold up the rest of the server.
+type Conn struct {
+	UserID int
+
+	ws   *websocket.Conn
+	hub  *Hub
+	send chan []byte
+
+	mu        sync.Mutex
+	closing   chan struct{}
+	closeCode int
+	closeText string
+}
+
+// Send queues v as a JSON text frame. A client too slow to keep up with its
+// queue is disconnected.
+func (c *Conn) Send(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.closing:
+		return ErrClosed
+	default:
+	}
+
+	select {
+	case c.send <- data:
+		return nil
+	default:
+		c.Close(websocket.CloseTryAgainLater, "too slow")
+		return ErrClosed
+	}
+}
+
+// Close stops reading and sends the client a close frame with code and text
+// once the frame being handled is done. Only the first call counts.
+func (c *Conn) Close(code int, text string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closing:
+		return
+	default:
+	}
+	c.closeCode, c.closeText = code, text
+	close(c.closing)
+	// Unblocks a pending read; a frame being handled finishes first
+	c.ws.SetReadDeadline(time.Now())
+}
+
+// Closing is closed once the connection starts closing.
+func (c *Conn) Closing() <-chan struct{} {
+	return c.closing
+}
+
+// Run hands every frame from the client to handle, one at a time, until the
+// connection closes. Reading waits for handle, which bounds the work a
+// client can queue up.
+func (c *Conn) Run(handle func(data []byte)) {
+	readDone := make(chan struct{})
+	writeDone := make(chan struct{})
+	go func() {
+		defer close(writeDone)
+		c.writeLoop(readDone)
+	}()
+
+	c.readLoop(handle)
+	c.Close(websocket.CloseNormalClosure, "")
+	close(readDone)
+	<-writeDone
+	c.hub.unregister(c)
+}
+
+func (c *Conn) readLoop(handle func(data []byte)) {
+	c.ws.SetReadLimit(MaxMessageSize)
+	c.extendReadDeadline()
+	c.ws.SetPongHandler(func(string) error {
+		c.extendReadDeadline()
+		return nil
+	})
+
+	for {
+		_, data, err := c.ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		select {
+		case <-c.closing:
+			return
+		default:
+		}
+		handle(data)
+	}
+}
+
+// extendReadDeadline gives the client PongWait to answer the next ping,
+// unless the connection is closing.
+func (c *Conn) extendReadDeadline() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.closing:
+	default:
+		c.ws.SetReadDeadline(time.Now().Add(PongWait))
+	}
+}
+
+// writeLoop writes queued frames and pings. Once reading has stopped it
+// flushes what is queued, unless the client is too slow, and says goodbye.
+func (c *Conn) writeLoop(readDone <-chan struct{}) {
+	ping := time.NewTicker(PingInterval)
+	defer ping.Stop()
+	defer c.ws.Close()
+
+	for {
+		select {
+		case data := <-c.send:
+			if err := c.write(data); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				return
+			}
+		case <-readDone:
+			if c.closeCode != websocket.CloseTryAgainLater {
+				for flushed := false; !flushed; {
+					select {
+					case data := <-c.send:
+						if err := c.write(data); err != nil {
+							return
+						}
+					default:
+						flushed = true
+					}
+				}
+			}
+			msg := websocket.FormatCloseMessage(c.closeCode, c.closeText)
+			c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+			return
+		}
+	}
+}
+
+func (c *Conn) write(data []byte) error {
+	c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.ws.WriteMessage(websocket.TextMessage, data)
+}
@@ -0,0 +1,178 @@
+// Package realtime keeps track of this server's WebSocket connections: who
+// is viewing which channel, and closing them all on shutdown. Presence is
+// per server; behind several replicas a client sees the viewers connected
+// to the same one.
+package realtime
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Presence tells a channel's viewers who is viewing it.
+type Presence struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+	Users   []int  `json:"users"`
+}
+
+// Hub holds the open connections and the channels they view.
+type Hub struct {
+	mu      sync.Mutex
+	conns   map[*Conn]map[string]struct{}
+	viewers map[string]map[*Conn]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		conns:   map[*Conn]map[string]struct{}{},
+		viewers: map[string]map[*Conn]struct{}{},
+	}
+}
+
+// Register tracks an upgraded connection. Once the hub is stopping it turns
+// the connection away with ErrShuttingDown.
+func (h *Hub) Register(ws *websocket.Conn, userID int) (*Conn, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, ErrShuttingDown.Error())
+		ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+		ws.Close()
+		return nil, ErrShuttingDown
+	}
+
+	c := &Conn{
+		UserID:  userID,
+		ws:      ws,
+		hub:     h,
+		send:    make(chan []byte, SendBuffer),
+		closing: make(chan struct{}),
+	}
+	h.conns[c] = map[string]struct{}{}
+	h.wg.Add(1)
+	return c, nil
+}
+
+// Join adds c to the viewers of channel and tells them all.
+func (h *Hub) Join(c *Conn, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	joined, ok := h.conns[c]
+	if !ok {
+		return
+	}
+	joined[channel] = struct{}{}
+	if h.viewers[channel] == nil {
+		h.viewers[channel] = map[*Conn]struct{}{}
+	}
+	h.viewers[channel][c] = struct{}{}
+	h.announce(channel)
+}
+
+// Leave removes c from the viewers of channel and tells the rest.
+func (h *Hub) Leave(c *Conn, channel string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.leave(c, channel)
+}
+
+// Viewers returns the users viewing channel, in order.
+func (h *Hub) Viewers(channel string) []int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.users(channel)
+}
+
+// Accepting reports whether new connections are welcome.
+func (h *Hub) Accepting() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return !h.closed
+}
+
+// Stop asks every connection to close once the frame it is handling is done
+// and waits for them, or for ctx to expire, after which the rest are cut off.
+func (h *Hub) Stop(ctx context.Context) error {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close(websocket.CloseGoingAway, ErrShuttingDown.Error())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		for _, c := range conns {
+			c.ws.Close()
+		}
+		return ctx.Err()
+	}
+}
+
+func (h *Hub) unregister(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	joined, ok := h.conns[c]
+	if !ok {
+		return
+	}
+	for channel := range joined {
+		h.leave(c, channel)
+	}
+	delete(h.conns, c)
+	h.wg.Done()
+}
+
+func (h *Hub) leave(c *Conn, channel string) {
+	if _, ok := h.conns[c][channel]; !ok {
+		return
+	}
+	delete(h.conns[c], channel)
+	delete(h.viewers[channel], c)
+	if len(h.viewers[channel]) == 0 {
+		delete(h.viewers, channel)
+		return
+	}
+	h.announce(channel)
+}
+
+// announce sends the channel's viewers to each of them; h.mu must be held.
+func (h *Hub) announce(channel string) {
+	presence := Presence{Type: "presence", Channel: channel, Users: h.users(channel)}
+	for c := range h.viewers[channel] {
+		c.Send(presence)
+	}
+}
+
+// users lists the distinct users viewing channel; h.mu must be held.
+func (h *Hub) users(channel string) []int {
+	seen := map[int]struct{}{}
+	users := []int{}
+	for c := range h.viewers[channel] {
+		if _, ok := seen[c.UserID]; !ok {
+			seen[c.UserID] = struct{}{}
+			users = append(users, c.UserID)
+		}
+	}
+	sort.Ints(users)
+	return users
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/realtime"
+	"github.com/gorilla/websocket"
+)
+
+// startHub serves connections that join the channel named by each frame
+// they send; ?user= picks the user
+func startHub(t *testing.T) (*realtime.Hub, string) {
+	hub := realtime.NewHub()
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := strconv.Atoi(r.URL.Query().Get("user"))
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		conn, err := hub.Register(ws, userID)
+		if err != nil {
+			return
+		}
+		conn.Run(func(data []byte) { hub.Join(conn, string(data)) })
+	}))
+	t.Cleanup(func() {
+		hub.Stop(context.Background())
+		server.Close()
+	})
+	return hub, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func dial(t *testing.T, url string, userID int) *websocket.Conn {
+	t.Helper()
+	ws, _, err := websocket.DefaultDialer.Dial(url+"?user="+strconv.Itoa(userID), nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	return ws
+}
+
+func readPresence(t *testing.T, ws *websocket.Conn) realtime.Presence {
+	t.Helper()
+	var presence realtime.Presence
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	if err := ws.ReadJSON(&presence); err != nil {
+		t.Fatalf("Expected a presence frame, got %v", err)
+	}
+	return presence
+}
+
+func TestHub_Presence(t *testing.T) {
+	hub, url := startHub(t)
+	alice := dial(t, url, 1)
+	bob := dial(t, url, 2)
+
+	alice.WriteMessage(websocket.TextMessage, []byte("todo:5"))
+	if p := readPresence(t, alice); p.Channel != "todo:5" || len(p.Users) != 1 || p.Users[0] != 1 {
+		t.Errorf("Expected alice alone on todo:5, got %+v", p)
+	}
+
+	bob.WriteMessage(websocket.TextMessage, []byte("todo:5"))
+	readPresence(t, bob)
+	if p := readPresence(t, alice); len(p.Users) != 2 {
+		t.Errorf("Expected alice to see both viewers, got %+v", p)
+	}
+
+	bob.Close()
+	if p := readPresence(t, alice); len(p.Users) != 1 || p.Users[0] != 1 {
+		t.Errorf("Expected bob to have left, got %+v", p)
+	}
+	if users := hub.Viewers("todo:5"); len(users) != 1 {
+		t.Errorf("Expected one viewer left, got %v", users)
+	}
+}
+
+func TestHub_StopClosesConnections(t *testing.T) {
+	hub, url := startHub(t)
+	ws := dial(t, url, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := hub.Stop(ctx); err != nil {
+		t.Fatalf("Expected a clean stop, got %v", err)
+	}
+
+	ws.SetReadDeadline(time.Now().Add(time.Second))
+	_, _, err := ws.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.CloseGoingAway {
+		t.Errorf("Expected a going away close frame, got %v", err)
+	}
+	if hub.Accepting() {
+		t.Error("Expected a stopped hub to turn connections away")
+	}
+}
@@ -2,12 +2,10 @@ package memory
 
 import (
 	"context"
-	"errors"
-	"strings"
 	"sync"
-	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
 )
 
 // UserRepo is an in-memory implementation of the service.UserRepository interface.
@@ -25,42 +23,10 @@ func NewUserRepo() *UserRepo {
 	}
 }
 
-// RegisterUser simulates the fn_register_user SQL function behavior.
-func (r *UserRepo) RegisterUser(ctx context.Context, name, email string) (*models.User, error) {
-	// Simulate SQL function validation
-	if strings.TrimSpace(name) == "" {
-		return nil, errors.New("name cannot be empty")
-	}
-	if !strings.Contains(email, "@") {
-		return nil, errors.New("invalid email address")
-	}
-
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	// Check for duplicate email
-	for _, u := range r.users {
-		if u.Email == email {
-			return nil, errors.New("email already exists")
-		}
-	}
-
-	user := &models.User{
-		ID:        r.nextID,
-		Name:      strings.TrimSpace(name),
-		Email:     strings.ToLower(strings.TrimSpace(email)),
-		CreatedAt: time.Now(),
-	}
-	r.users[user.ID] = user
-	r.nextID++
-
-	return user, nil
-}
-
 // GetByID simulates the fn_get_user_by_id SQL function behavior.
 func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
 	if id <= 0 {
-		return nil, errors.New("invalid user id")
+		return nil, apperr.Validation("invalid user id")
 	}
 
 	r.mu.RLock()
@@ -68,7 +34,7 @@ func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
 
 	u, ok := r.users[id]
 	if !ok {
-		return nil, errors.New("user not found")
+		return nil, apperr.NotFound("user not found")
 	}
 	return u, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/fayzzzm/go-bro/pkg/apperr"
 	"github.com/fayzzzm/go-bro/repository/memory"
 )
 
@@ -11,27 +12,22 @@ func TestInMemUserRepo(t *testing.T) {
 	repo := memory.NewUserRepo()
 	ctx := context.Background()
 
-	// Test Register
-	user, err := repo.RegisterUser(ctx, "InMem User", "inmem@test.com")
-	if err != nil {
-		t.Fatalf("Register failed: %v", err)
+	// Test invalid ID
+	if _, err := repo.GetByID(ctx, 0); apperr.KindOf(err) != apperr.KindValidation {
+		t.Errorf("Expected a validation error for id 0, got %v", err)
 	}
-	if user.ID != 1 {
-		t.Errorf("Expected ID 1, got %d", user.ID)
+
+	// Test missing user
+	if _, err := repo.GetByID(ctx, 1); apperr.KindOf(err) != apperr.KindNotFound {
+		t.Errorf("Expected not found for an unknown user, got %v", err)
 	}
 
-	// Test Get
-	found, err := repo.GetByID(ctx, 1)
+	// Test empty list
+	users, err := repo.GetAll(ctx, 10, 0)
 	if err != nil {
-		t.Fatalf("GetByID failed: %v", err)
+		t.Fatalf("GetAll failed: %v", err)
 	}
-	if found.Email != "inmem@test.com" {
-		t.Errorf("Expected email inmem@test.com, got %s", found.Email)
-	}
-
-	// Test Duplicate
-	_, err = repo.RegisterUser(ctx, "Other", "inmem@test.com")
-	if err == nil {
-		t.Error("Expected error for duplicate email, got nil")
+	if len(users) != 0 {
+		t.Errorf("Expected no users, got %d", len(users))
 	}
 }
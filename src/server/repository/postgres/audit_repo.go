@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditRepo reads the audit trail. Events are written by database triggers
+// (migration 024), never from here.
+type AuditRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewAuditRepo(pool *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{pool: pool}
+}
+
+// List returns events matching the filter, newest first, by limit and offset.
+func (r *AuditRepo) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	payload := AuditRequest{
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	payload.setFilter(filter)
+	return queryRows[models.AuditEvent](ctx, r.pool, "SELECT * FROM audit.list($1)", payload)
+}
+
+// TodoHistory returns the events of one of the user's todos, newest first.
+func (r *AuditRepo) TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	payload := AuditRequest{
+		UserID:    &userID,
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	payload.setFilter(filter)
+	payload.TodoID = &todoID
+	return queryRows[models.AuditEvent](ctx, r.pool, "SELECT * FROM audit.todo_history($1)", payload)
+}
@@ -2,6 +2,7 @@ package postgres
 
 import (
 	"context"
+	"time"
 
 	"github.com/fayzzzm/go-bro/models"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -30,3 +31,40 @@ func (r *AuthRepo) GetUserByEmail(ctx context.Context, email string) (*models.Us
 	}
 	return queryOne[models.UserWithPassword](ctx, r.pool, "SELECT * FROM users.get_by_email($1)", payload)
 }
+
+func (r *AuthRepo) CreateSession(ctx context.Context, userID int, tokenHash string, ttl time.Duration) (*models.Session, error) {
+	seconds := int(ttl.Seconds())
+	payload := SessionRequest{
+		UserID:     &userID,
+		TokenHash:  &tokenHash,
+		TTLSeconds: &seconds,
+	}
+	return queryOne[models.Session](ctx, r.pool, "SELECT * FROM auth.create_session($1)", payload)
+}
+
+func (r *AuthRepo) RotateSession(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error) {
+	seconds := int(ttl.Seconds())
+	graceSeconds := int(grace.Seconds())
+	payload := SessionRequest{
+		TokenHash:    &tokenHash,
+		NewTokenHash: &newTokenHash,
+		TTLSeconds:   &seconds,
+		GraceSeconds: &graceSeconds,
+	}
+	return queryOne[models.Session](ctx, r.pool, "SELECT * FROM auth.rotate($1)", payload)
+}
+
+func (r *AuthRepo) RevokeSession(ctx context.Context, tokenHash string) error {
+	payload := SessionRequest{
+		TokenHash: &tokenHash,
+	}
+	_, err := queryValue[bool](ctx, r.pool, "SELECT auth.revoke($1)", payload)
+	return err
+}
+
+func (r *AuthRepo) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	payload := SessionRequest{
+		FamilyID: &sessionID,
+	}
+	return queryValue[bool](ctx, r.pool, "SELECT auth.is_active($1)", payload)
+}
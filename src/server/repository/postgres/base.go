@@ -2,34 +2,173 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/audit"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// queryOne is a generic helper to execute a query and collect a single row into a struct.
-func queryOne[T any](ctx context.Context, pool *pgxpool.Pool, query string, args ...any) (*T, error) {
-	rows, err := pool.Query(ctx, query, args...)
+// SQLSTATE codes raised by the SQL API functions (see migrations) and by constraints.
+var sqlStateKinds = map[string]apperr.Kind{
+	"P0002": apperr.KindNotFound,     // no_data_found
+	"23505": apperr.KindConflict,     // unique_violation
+	"23514": apperr.KindValidation,   // check_violation
+	"23502": apperr.KindValidation,   // not_null_violation
+	"23503": apperr.KindValidation,   // foreign_key_violation
+	"22P02": apperr.KindValidation,   // invalid_text_representation
+	"22023": apperr.KindValidation,   // invalid_parameter_value
+	"22001": apperr.KindValidation,   // string_data_right_truncation
+	"28000": apperr.KindUnauthorized, // invalid_authorization_specification
+	"42501": apperr.KindForbidden,    // insufficient_privilege
+
+	// Custom codes raised by our own functions
+	"VC001": apperr.KindPreconditionFailed, // stale todo version (migration 013)
+}
+
+// kindForSQLState returns the apperr kind for a SQLSTATE, or KindInternal.
+func kindForSQLState(code string) apperr.Kind {
+	if kind, ok := sqlStateKinds[code]; ok {
+		return kind
+	}
+	return apperr.KindInternal
+}
+
+// translateError maps pgx errors to the apperr taxonomy, keeping the original as the cause.
+func translateError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return apperr.Wrap(apperr.KindNotFound, "resource not found", err)
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		kind, ok := sqlStateKinds[pgErr.Code]
+		if !ok {
+			return err
+		}
+		// Messages from RAISE EXCEPTION are written for clients; constraint
+		// messages name tables and indexes, so use the generic one instead.
+		message := pgErr.Message
+		if pgErr.ConstraintName != "" {
+			message = genericMessages[kind]
+		}
+		return apperr.Wrap(kind, message, err)
+	}
+
+	return err
+}
+
+var genericMessages = map[apperr.Kind]string{
+	apperr.KindNotFound:     apperr.ErrNotFound.Message,
+	apperr.KindConflict:     apperr.ErrConflict.Message,
+	apperr.KindValidation:   apperr.ErrValidation.Message,
+	apperr.KindUnauthorized: apperr.ErrUnauthorized.Message,
+	apperr.KindForbidden:    apperr.ErrForbidden.Message,
+}
+
+// setAuditMeta hands the request's audit metadata to the triggers of
+// migration 024 as settings local to the transaction.
+const setAuditMeta = `SELECT set_config('audit.actor_id', $1, true),
+	set_config('audit.request_id', $2, true),
+	set_config('audit.client_ip', $3, true)`
+
+// query runs a query with the audit metadata from ctx. The settings and the
+// query are sent as one batch, which Postgres runs as one implicit
+// transaction, so they apply to exactly this query in a single round trip.
+func query(ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) (pgx.Rows, error) {
+	meta := audit.FromContext(ctx)
+	if meta.IsZero() {
+		return pool.Query(ctx, sql, args...)
+	}
+
+	actorID := ""
+	if meta.ActorID != nil {
+		actorID = strconv.Itoa(*meta.ActorID)
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(setAuditMeta, actorID, meta.RequestID, meta.ClientIP)
+	batch.Queue(sql, args...)
+
+	results := pool.SendBatch(ctx, batch)
+	if _, err := results.Exec(); err != nil {
+		results.Close()
+		return nil, err
+	}
+	rows, err := results.Query()
 	if err != nil {
+		results.Close()
 		return nil, err
 	}
+	return &batchRows{Rows: rows, results: results}, nil
+}
+
+// batchRows releases the batch, and its connection, along with the rows.
+type batchRows struct {
+	pgx.Rows
+	results pgx.BatchResults
+	closed  bool
+	err     error
+}
+
+func (r *batchRows) Close() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.Rows.Close()
+	r.err = r.results.Close()
+}
+
+func (r *batchRows) Err() error {
+	if err := r.Rows.Err(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// queryOne is a generic helper to execute a query and collect a single row into a struct.
+func queryOne[T any](ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) (*T, error) {
+	rows, err := query(ctx, pool, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
 	// CollectOneRow handles closing the rows
 	val, err := pgx.CollectOneRow(rows, pgx.RowToStructByName[T])
 
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	return &val, nil
 }
 
 // queryRows is a generic helper to execute a query and collect multiple rows into a slice of structs.
-func queryRows[T any](ctx context.Context, pool *pgxpool.Pool, query string, args ...any) ([]T, error) {
-	rows, err := pool.Query(ctx, query, args...)
+func queryRows[T any](ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) ([]T, error) {
+	rows, err := query(ctx, pool, sql, args...)
 	if err != nil {
-		return nil, err
+		return nil, translateError(err)
 	}
 
 	// CollectRows handles closing the rows
-	return pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	vals, err := pgx.CollectRows(rows, pgx.RowToStructByName[T])
+	return vals, translateError(err)
+}
+
+// queryValue is a generic helper for SQL functions that return a single scalar.
+func queryValue[T any](ctx context.Context, pool *pgxpool.Pool, sql string, args ...any) (T, error) {
+	rows, err := query(ctx, pool, sql, args...)
+	if err != nil {
+		var zero T
+		return zero, translateError(err)
+	}
+	val, err := pgx.CollectOneRow(rows, pgx.RowTo[T])
+	return val, translateError(err)
 }
@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventsChannel is the NOTIFY channel events.publish signals on.
+const EventsChannel = "todo_events"
+
+// EventRepo stores todo events and relays their notifications (migration 026).
+type EventRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventRepo(pool *pgxpool.Pool) *EventRepo {
+	return &EventRepo{pool: pool}
+}
+
+// Publish appends an event; every server listening is notified on commit.
+// A user's events commit in id order, so a stream can resume by id.
+func (r *EventRepo) Publish(ctx context.Context, event models.TodoEvent) (*models.TodoEvent, error) {
+	eventType := string(event.Type)
+	payload := EventRequest{
+		UserID: &event.UserID,
+		Type:   &eventType,
+		TodoID: &event.TodoID,
+	}
+	if event.Todo != nil {
+		todo, err := json.Marshal(event.Todo)
+		if err != nil {
+			return nil, err
+		}
+		payload.Todo = todo
+	}
+	return queryOne[models.TodoEvent](ctx, r.pool, "SELECT * FROM events.publish($1)", payload)
+}
+
+func (r *EventRepo) Get(ctx context.Context, id int64) (*models.TodoEvent, error) {
+	payload := EventRequest{ID: &id}
+	return queryOne[models.TodoEvent](ctx, r.pool, "SELECT * FROM events.get($1)", payload)
+}
+
+// Since returns up to limit of the user's events after afterID, oldest first.
+func (r *EventRepo) Since(ctx context.Context, userID int, afterID int64, limit int) ([]models.TodoEvent, error) {
+	payload := EventRequest{
+		UserID:   &userID,
+		AfterID:  &afterID,
+		LimitVal: &limit,
+	}
+	return queryRows[models.TodoEvent](ctx, r.pool, "SELECT * FROM events.since($1)", payload)
+}
+
+// Prune deletes events created before the cut-off.
+func (r *EventRepo) Prune(ctx context.Context, before time.Time) (int, error) {
+	payload := EventRequest{Before: &before}
+	pruned, err := queryValue[int32](ctx, r.pool, "SELECT events.prune($1)", payload)
+	return int(pruned), err
+}
+
+// Listen calls handle with the id and user of every event published from now
+// on, by any server, until ctx is done or the connection fails. It holds a
+// connection of its own for as long as it runs.
+func (r *EventRepo) Listen(ctx context.Context, handle func(id int64, userID int)) error {
+	conn, err := r.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	// A connection left listening must not go back to the pool
+	pgConn := conn.Hijack()
+	defer pgConn.Close(context.Background())
+
+	if _, err := pgConn.Exec(ctx, "LISTEN "+EventsChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := pgConn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var ref struct {
+			ID     int64 `json:"id"`
+			UserID int   `json:"user_id"`
+		}
+		if err := json.Unmarshal([]byte(notification.Payload), &ref); err != nil {
+			return fmt.Errorf("decoding %s notification: %w", EventsChannel, err)
+		}
+		handle(ref.ID, ref.UserID)
+	}
+}
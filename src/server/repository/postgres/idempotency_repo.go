@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepo stores Idempotency-Key responses. A userID of 0 is the
+// anonymous scope used by public routes such as signup.
+type IdempotencyRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewIdempotencyRepo(pool *pgxpool.Pool) *IdempotencyRepo {
+	return &IdempotencyRepo{pool: pool}
+}
+
+// Reserve claims the key for ttl, or returns what is already stored for it.
+func (r *IdempotencyRepo) Reserve(ctx context.Context, userID int, key, fingerprint string, ttl time.Duration) (*models.IdempotencyKey, error) {
+	seconds := int(ttl.Seconds())
+	payload := IdempotencyRequest{
+		UserID:      scopeUserID(userID),
+		Key:         &key,
+		Fingerprint: &fingerprint,
+		TTLSeconds:  &seconds,
+	}
+	return queryOne[models.IdempotencyKey](ctx, r.pool, "SELECT * FROM idempotency.reserve($1)", payload)
+}
+
+// Complete saves the response of the request holding the key.
+func (r *IdempotencyRepo) Complete(ctx context.Context, userID int, key string, status int, headers map[string][]string, body []byte) error {
+	payload := IdempotencyRequest{
+		UserID:     scopeUserID(userID),
+		Key:        &key,
+		StatusCode: &status,
+		Headers:    headers,
+		Body:       body,
+	}
+	_, err := queryValue[bool](ctx, r.pool, "SELECT idempotency.complete($1)", payload)
+	return err
+}
+
+// Release frees a key whose request failed so a retry can run again.
+func (r *IdempotencyRepo) Release(ctx context.Context, userID int, key string) error {
+	payload := IdempotencyRequest{
+		UserID: scopeUserID(userID),
+		Key:    &key,
+	}
+	_, err := queryValue[bool](ctx, r.pool, "SELECT idempotency.release($1)", payload)
+	return err
+}
+
+// PurgeExpired deletes every key past its TTL.
+func (r *IdempotencyRepo) PurgeExpired(ctx context.Context) (int, error) {
+	purged, err := queryValue[int32](ctx, r.pool, "SELECT idempotency.purge_expired()")
+	return int(purged), err
+}
+
+func scopeUserID(userID int) *int {
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
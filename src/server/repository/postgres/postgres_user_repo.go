@@ -4,6 +4,8 @@ import (
 	"context"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,25 +17,44 @@ func NewUserRepo(pool *pgxpool.Pool) *UserRepo {
 	return &UserRepo{pool: pool}
 }
 
-func (r *UserRepo) RegisterUser(ctx context.Context, name, email string) (*models.User, error) {
+func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
 	payload := UserRequest{
-		Name:  &name,
-		Email: &email,
+		ID: &id,
 	}
-	return queryOne[models.User](ctx, r.pool, "SELECT * FROM users.create($1)", payload)
+	return queryOne[models.User](ctx, r.pool, "SELECT * FROM users.get($1)", payload)
 }
 
-func (r *UserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
+func (r *UserRepo) GetAll(ctx context.Context, page pagination.Page) ([]models.User, error) {
+	var payload UserRequest
+	payload.setPage(page)
+	return queryRows[models.User](ctx, r.pool, "SELECT * FROM users.list($1)", payload)
+}
+
+// SetDisabled disables (revoking every session) or re-enables a user.
+func (r *UserRepo) SetDisabled(ctx context.Context, id int, disabled bool) (*models.User, error) {
 	payload := UserRequest{
-		ID: &id,
+		ID:       &id,
+		Disabled: &disabled,
 	}
-	return queryOne[models.User](ctx, r.pool, "SELECT * FROM users.get($1)", payload)
+	return queryOne[models.User](ctx, r.pool, "SELECT * FROM users.set_disabled($1)", payload)
 }
 
-func (r *UserRepo) GetAll(ctx context.Context, limit, offset int) ([]models.User, error) {
+// Delete removes a user together with their todos and sessions.
+func (r *UserRepo) Delete(ctx context.Context, id int) error {
 	payload := UserRequest{
-		LimitVal:  &limit,
-		OffsetVal: &offset,
+		ID: &id,
 	}
-	return queryRows[models.User](ctx, r.pool, "SELECT * FROM users.list($1)", payload)
+	deleted, err := queryValue[bool](ctx, r.pool, "SELECT users.delete($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperr.NotFound("user not found")
+	}
+	return nil
+}
+
+func (r *UserRepo) Count(ctx context.Context) (int, error) {
+	total, err := queryValue[int64](ctx, r.pool, "SELECT users.count($1)", UserRequest{})
+	return int(total), err
 }
@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ProjectRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewProjectRepo(pool *pgxpool.Pool) *ProjectRepo {
+	return &ProjectRepo{pool: pool}
+}
+
+func (r *ProjectRepo) Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error) {
+	payload := ProjectRequest{
+		UserID:      &userID,
+		Name:        &name,
+		Description: description,
+	}
+	return queryOne[models.Project](ctx, r.pool, "SELECT * FROM projects.create($1)", payload)
+}
+
+// List returns the user's projects by name, by limit and offset.
+func (r *ProjectRepo) List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error) {
+	payload := ProjectRequest{
+		UserID:    &userID,
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	return queryRows[models.Project](ctx, r.pool, "SELECT * FROM projects.list($1)", payload)
+}
+
+func (r *ProjectRepo) GetByID(ctx context.Context, projectID, userID int) (*models.Project, error) {
+	payload := ProjectRequest{
+		ID:     &projectID,
+		UserID: &userID,
+	}
+	return queryOne[models.Project](ctx, r.pool, "SELECT * FROM projects.get($1)", payload)
+}
+
+// Update changes the non-nil fields.
+func (r *ProjectRepo) Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error) {
+	payload := ProjectRequest{
+		ID:          &projectID,
+		UserID:      &userID,
+		Name:        name,
+		Description: description,
+	}
+	return queryOne[models.Project](ctx, r.pool, "SELECT * FROM projects.update($1)", payload)
+}
+
+// Delete removes the project and returns how many live todos were moved to
+// the inbox, or to the trash in cascade mode.
+func (r *ProjectRepo) Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error) {
+	m := string(mode)
+	payload := ProjectRequest{
+		ID:         &projectID,
+		UserID:     &userID,
+		DeleteMode: &m,
+	}
+	moved, err := queryValue[int32](ctx, r.pool, "SELECT projects.delete($1)", payload)
+	return int(moved), err
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ShareRepo manages todo shares. A nil todoID addresses the owner's whole list.
+type ShareRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewShareRepo(pool *pgxpool.Pool) *ShareRepo {
+	return &ShareRepo{pool: pool}
+}
+
+// Share grants the user with the given email access, or changes their permission.
+func (r *ShareRepo) Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error) {
+	perm := string(permission)
+	payload := ShareRequest{
+		OwnerID:    &ownerID,
+		TodoID:     todoID,
+		Email:      &email,
+		Permission: &perm,
+	}
+	return queryOne[models.Share](ctx, r.pool, "SELECT * FROM todos.share($1)", payload)
+}
+
+func (r *ShareRepo) Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error {
+	payload := ShareRequest{
+		OwnerID: &ownerID,
+		TodoID:  todoID,
+		UserID:  &userID,
+	}
+	removed, err := queryValue[bool](ctx, r.pool, "SELECT todos.unshare($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return apperr.NotFound("share not found")
+	}
+	return nil
+}
+
+func (r *ShareRepo) ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error) {
+	payload := ShareRequest{
+		OwnerID: &ownerID,
+		TodoID:  todoID,
+	}
+	return queryRows[models.Share](ctx, r.pool, "SELECT * FROM todos.list_shares($1)", payload)
+}
+
+// ListShared returns the todos other users shared with userID, by limit and offset.
+func (r *ShareRepo) ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error) {
+	payload := ShareRequest{
+		UserID:    &userID,
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	return queryRows[models.SharedTodo](ctx, r.pool, "SELECT * FROM todos.list_shared($1)", payload)
+}
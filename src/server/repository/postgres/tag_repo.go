@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type TagRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewTagRepo(pool *pgxpool.Pool) *TagRepo {
+	return &TagRepo{pool: pool}
+}
+
+func (r *TagRepo) Create(ctx context.Context, userID int, name string) (*models.Tag, error) {
+	payload := TagRequest{
+		UserID: &userID,
+		Name:   &name,
+	}
+	return queryOne[models.Tag](ctx, r.pool, "SELECT * FROM tags.create($1)", payload)
+}
+
+// List returns the user's tags by name, by limit and offset.
+func (r *TagRepo) List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error) {
+	payload := TagRequest{
+		UserID:    &userID,
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	return queryRows[models.Tag](ctx, r.pool, "SELECT * FROM tags.list($1)", payload)
+}
+
+func (r *TagRepo) GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error) {
+	payload := TagRequest{
+		ID:     &tagID,
+		UserID: &userID,
+	}
+	tag, err := queryOne[models.Tag](ctx, r.pool, "SELECT * FROM tags.get($1)", payload)
+	return tag, tagNotFound(err)
+}
+
+// Rename changes the name of a tag on every todo it labels.
+func (r *TagRepo) Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error) {
+	payload := TagRequest{
+		ID:     &tagID,
+		UserID: &userID,
+		Name:   &name,
+	}
+	tag, err := queryOne[models.Tag](ctx, r.pool, "SELECT * FROM tags.update($1)", payload)
+	return tag, tagNotFound(err)
+}
+
+// Delete removes a tag from the user's set and from the todos it labels.
+func (r *TagRepo) Delete(ctx context.Context, tagID, userID int) error {
+	payload := TagRequest{
+		ID:     &tagID,
+		UserID: &userID,
+	}
+	deleted, err := queryValue[bool](ctx, r.pool, "SELECT tags.delete($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperr.NotFound("tag not found")
+	}
+	return nil
+}
+
+func tagNotFound(err error) error {
+	if errors.Is(err, apperr.ErrNotFound) {
+		return apperr.NotFound("tag not found")
+	}
+	return err
+}
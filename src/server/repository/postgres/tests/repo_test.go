@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/audit"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/repository/postgres"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -77,7 +79,7 @@ func TestPostgresRepositories(t *testing.T) {
 		// Create Todo
 		title := "Test Todo Repo"
 		desc := "Repo integration test"
-		todo, err := todoRepo.Create(ctx, user.ID, title, &desc)
+		todo, err := todoRepo.Create(ctx, user.ID, &models.Todo{Title: &title, Description: &desc})
 		if err != nil {
 			t.Fatalf("Failed to create todo: %v", err)
 		}
@@ -86,7 +88,7 @@ func TestPostgresRepositories(t *testing.T) {
 		}
 
 		// List Todos
-		todos, err := todoRepo.GetByUser(ctx, user.ID, 10, 0)
+		todos, err := todoRepo.GetByUser(ctx, user.ID, models.TodoFilter{}, pagination.Page{Limit: 10})
 		if err != nil {
 			t.Fatalf("Failed to list todos: %v", err)
 		}
@@ -95,7 +97,7 @@ func TestPostgresRepositories(t *testing.T) {
 		}
 
 		// Toggle Todo
-		updated, err := todoRepo.Toggle(ctx, todo.ID, user.ID)
+		updated, err := todoRepo.Toggle(ctx, todo.ID, user.ID, 0, nil)
 		if err != nil {
 			t.Fatalf("Failed to toggle todo: %v", err)
 		}
@@ -109,4 +111,47 @@ func TestPostgresRepositories(t *testing.T) {
 			t.Fatalf("Failed to delete todo: %v", err)
 		}
 	})
+
+	// 3. Test AuditRepo: events are written by triggers with the request's metadata
+	t.Run("AuditRepo", func(t *testing.T) {
+		authRepo := postgres.NewAuthRepo(pool)
+		todoRepo := postgres.NewTodoRepo(pool)
+		auditRepo := postgres.NewAuditRepo(pool)
+
+		email := fmt.Sprintf("test-audit-%d@example.com", time.Now().UnixNano())
+		user, err := authRepo.Signup(ctx, "Audit Tester", email, "hashed-pwd")
+		if err != nil {
+			t.Fatalf("Signup failed: %v", err)
+		}
+
+		reqCtx := audit.WithActor(audit.WithRequest(ctx, "audit-test", "10.0.0.1"), user.ID)
+		title, renamed := "Before", "After"
+		todo, err := todoRepo.Create(reqCtx, user.ID, &models.Todo{Title: &title})
+		if err != nil {
+			t.Fatalf("Failed to create todo: %v", err)
+		}
+		todo.Title = &renamed
+		if _, err := todoRepo.Update(reqCtx, user.ID, todo); err != nil {
+			t.Fatalf("Failed to update todo: %v", err)
+		}
+
+		events, err := auditRepo.TodoHistory(ctx, todo.ID, user.ID, models.AuditFilter{}, pagination.Page{Limit: 10})
+		if err != nil {
+			t.Fatalf("Failed to read history: %v", err)
+		}
+		if len(events) != 2 || events[0].Action != "update" || events[1].Action != "create" {
+			t.Fatalf("Expected update then create, got %+v", events)
+		}
+		update := events[0]
+		if update.ActorID == nil || *update.ActorID != user.ID || update.RequestID == nil || *update.RequestID != "audit-test" {
+			t.Errorf("Expected the request's actor and ID, got %+v", update)
+		}
+		if string(update.Before) != `{"title": "Before"}` || string(update.After) != `{"title": "After"}` {
+			t.Errorf("Expected only the title in the diff, got %s -> %s", update.Before, update.After)
+		}
+
+		if _, err := auditRepo.TodoHistory(ctx, todo.ID, user.ID+1, models.AuditFilter{}, pagination.Page{Limit: 10}); err == nil {
+			t.Error("Expected another user's history request to fail")
+		}
+	})
 }
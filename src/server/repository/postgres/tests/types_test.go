@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/migrations"
+	"github.com/fayzzzm/go-bro/repository/postgres"
+)
+
+// Every request type a migration creates is the argument of SQL functions
+// the repositories call; pgx cannot encode it unless it is registered.
+func TestCompositeTypesRegistered(t *testing.T) {
+	createType := regexp.MustCompile(`CREATE TYPE (\w+\.\w+_request)\b`)
+
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		t.Fatalf("Listing migrations failed: %v", err)
+	}
+	found := 0
+	for _, name := range files {
+		if strings.HasSuffix(name, ".down.sql") {
+			continue
+		}
+		sql, err := fs.ReadFile(migrations.FS, name)
+		if err != nil {
+			t.Fatalf("Reading %s failed: %v", name, err)
+		}
+		for _, m := range createType.FindAllStringSubmatch(string(sql), -1) {
+			found++
+			if !slices.Contains(postgres.CompositeTypes, m[1]) {
+				t.Errorf("%s creates %s, which is missing from postgres.CompositeTypes", name, m[1])
+			}
+		}
+	}
+	if found == 0 {
+		t.Fatal("Expected migrations to create request types")
+	}
+
+	// The array type is registered after its element type
+	if slices.Index(postgres.CompositeTypes, "todos._todo_request") < slices.Index(postgres.CompositeTypes, "todos.todo_request") {
+		t.Error("Expected todos.todo_request before its array type")
+	}
+}
@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -15,24 +19,37 @@ func NewTodoRepo(pool *pgxpool.Pool) *TodoRepo {
 	return &TodoRepo{pool: pool}
 }
 
-func (r *TodoRepo) Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error) {
+// Create inserts todo for userID; a nil priority defaults to medium. With a
+// recurrence the todo is the first occurrence of a new series.
+func (r *TodoRepo) Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
 	payload := TodoRequest{
 		UserID:      &userID,
-		Title:       &title,
-		Description: description,
+		Title:       todo.Title,
+		Description: todo.Description,
+		ProjectID:   todo.ProjectID,
+		DueAt:       todo.DueAt,
+		Priority:    priority(todo.Priority),
+		RemindAt:    todo.RemindAt,
+		Tags:        todo.Tags,
 	}
+	payload.setRecurrence(todo.Recurrence)
 	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.create($1)", payload)
 }
 
-func (r *TodoRepo) GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error) {
-	payload := TodoRequest{
-		UserID:    &userID,
-		LimitVal:  &limit,
-		OffsetVal: &offset,
-	}
+func (r *TodoRepo) GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+	payload := TodoRequest{UserID: &userID}
+	payload.setFilter(filter)
+	payload.setPage(page)
 	return queryRows[models.Todo](ctx, r.pool, "SELECT * FROM todos.list($1)", payload)
 }
 
+func (r *TodoRepo) Count(ctx context.Context, userID int, filter models.TodoFilter) (int, error) {
+	payload := TodoRequest{UserID: &userID}
+	payload.setFilter(filter)
+	total, err := queryValue[int64](ctx, r.pool, "SELECT todos.count($1)", payload)
+	return int(total), err
+}
+
 func (r *TodoRepo) GetByID(ctx context.Context, todoID, userID int) (*models.Todo, error) {
 	payload := TodoRequest{
 		ID:     &todoID,
@@ -41,30 +58,406 @@ func (r *TodoRepo) GetByID(ctx context.Context, todoID, userID int) (*models.Tod
 	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.get($1)", payload)
 }
 
+// Update applies the non-nil fields. A non-zero todo.Version makes the update
+// conditional: a todo changed since then fails with apperr.KindPreconditionFailed.
 func (r *TodoRepo) Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
 	payload := TodoRequest{
-		ID:          &todo.ID,
-		UserID:      &userID,
-		Title:       todo.Title,
-		Description: todo.Description,
-		Completed:   todo.Completed,
+		ID:              &todo.ID,
+		UserID:          &userID,
+		Title:           todo.Title,
+		Description:     todo.Description,
+		Completed:       todo.Completed,
+		ProjectID:       todo.ProjectID,
+		DueAt:           todo.DueAt,
+		Priority:        priority(todo.Priority),
+		RemindAt:        todo.RemindAt,
+		ExpectedVersion: expectedVersion(todo.Version),
+		Tags:            todo.Tags,
 	}
 	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.update($1)", payload)
 }
 
+// UpdateFuture updates a todo like Update and, for it and the occurrences
+// after it, its series; see todos.update_future.
+func (r *TodoRepo) UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:              &todo.ID,
+		UserID:          &userID,
+		Title:           todo.Title,
+		Description:     todo.Description,
+		Completed:       todo.Completed,
+		ProjectID:       todo.ProjectID,
+		DueAt:           todo.DueAt,
+		Priority:        priority(todo.Priority),
+		RemindAt:        todo.RemindAt,
+		ExpectedVersion: expectedVersion(todo.Version),
+		Tags:            todo.Tags,
+	}
+	payload.setRecurrence(todo.Recurrence)
+	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.update_future($1)", payload)
+}
+
+// GetSeries returns one of userID's series.
+func (r *TodoRepo) GetSeries(ctx context.Context, seriesID, userID int) (*models.TodoSeries, error) {
+	payload := TodoRequest{
+		SeriesID: &seriesID,
+		UserID:   &userID,
+	}
+	series, err := queryOne[models.TodoSeries](ctx, r.pool, "SELECT * FROM todos.series_get($1)", payload)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, apperr.NotFound("series not found")
+	}
+	return series, err
+}
+
+// DeleteSeries stops a series; its todos stay, unlinked.
+func (r *TodoRepo) DeleteSeries(ctx context.Context, seriesID, userID int) error {
+	payload := TodoRequest{
+		SeriesID: &seriesID,
+		UserID:   &userID,
+	}
+	deleted, err := queryValue[bool](ctx, r.pool, "SELECT todos.series_delete($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperr.NotFound("series not found")
+	}
+	return nil
+}
+
+// ListSubtasks returns the subtasks of todoIDs that userID can see, grouped by
+// todo in checklist order, in one query.
+func (r *TodoRepo) ListSubtasks(ctx context.Context, userID int, todoIDs []int) ([]models.Subtask, error) {
+	payload := SubtaskRequest{
+		UserID:  &userID,
+		TodoIDs: todoIDs,
+	}
+	return queryRows[models.Subtask](ctx, r.pool, "SELECT * FROM todos.subtask_list($1)", payload)
+}
+
+// AddSubtask appends a subtask to the checklist of todoID.
+func (r *TodoRepo) AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error) {
+	payload := SubtaskRequest{
+		TodoID: &todoID,
+		UserID: &userID,
+		Title:  &title,
+	}
+	return queryOne[models.Subtask](ctx, r.pool, "SELECT * FROM todos.subtask_add($1)", payload)
+}
+
+// UpdateSubtask applies the non-nil fields; see todos.subtask_update.
+func (r *TodoRepo) UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error) {
+	payload := SubtaskRequest{
+		ID:             &update.ID,
+		TodoID:         &update.TodoID,
+		UserID:         &userID,
+		Title:          update.Title,
+		Completed:      update.Completed,
+		CompleteParent: &update.CompleteParent,
+	}
+	return queryOne[models.Subtask](ctx, r.pool, "SELECT * FROM todos.subtask_update($1)", payload)
+}
+
+func (r *TodoRepo) DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error {
+	payload := SubtaskRequest{
+		ID:     &subtaskID,
+		TodoID: &todoID,
+		UserID: &userID,
+	}
+	deleted, err := queryValue[bool](ctx, r.pool, "SELECT todos.subtask_delete($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperr.NotFound("subtask not found")
+	}
+	return nil
+}
+
+// ReorderSubtasks puts the checklist of todoID in the order of ids, which must
+// name each of its subtasks once, and returns it.
+func (r *TodoRepo) ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error) {
+	payload := SubtaskRequest{
+		TodoID: &todoID,
+		UserID: &userID,
+		IDs:    ids,
+	}
+	return queryRows[models.Subtask](ctx, r.pool, "SELECT * FROM todos.subtask_reorder($1)", payload)
+}
+
+// ListRevisions returns the revisions of todoID, newest first, by limit and offset.
+func (r *TodoRepo) ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error) {
+	payload := TodoRequest{
+		ID:        &todoID,
+		UserID:    &userID,
+		LimitVal:  &page.Limit,
+		OffsetVal: &page.Offset,
+	}
+	return queryRows[models.TodoRevision](ctx, r.pool, "SELECT * FROM todos.revision_list($1)", payload)
+}
+
+func (r *TodoRepo) GetRevision(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error) {
+	payload := TodoRequest{
+		ID:       &todoID,
+		UserID:   &userID,
+		Revision: &revision,
+	}
+	return queryOne[models.TodoRevision](ctx, r.pool, "SELECT * FROM todos.revision_get($1)", payload)
+}
+
+// RevertRevision gives todoID the content of a revision; the content it
+// replaces becomes a new revision. A non-zero version must match.
+func (r *TodoRepo) RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:              &todoID,
+		UserID:          &userID,
+		Revision:        &revision,
+		ExpectedVersion: expectedVersion(version),
+	}
+	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.revision_revert($1)", payload)
+}
+
+// Patch writes exactly the fields the patch sets, NULL included; see todos.patch.
+func (r *TodoRepo) Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:              &patch.ID,
+		UserID:          &userID,
+		ExpectedVersion: expectedVersion(patch.Version),
+		SetFields:       []string{},
+	}
+	if patch.Title.Set {
+		payload.Title = patch.Title.Value
+		payload.SetFields = append(payload.SetFields, "title")
+	}
+	if patch.Description.Set {
+		payload.Description = patch.Description.Value
+		payload.SetFields = append(payload.SetFields, "description")
+	}
+	if patch.Completed.Set {
+		payload.Completed = patch.Completed.Value
+		payload.SetFields = append(payload.SetFields, "completed")
+	}
+	if patch.ProjectID.Set {
+		payload.ProjectID = patch.ProjectID.Value
+		payload.SetFields = append(payload.SetFields, "project_id")
+	}
+	if patch.DueAt.Set {
+		payload.DueAt = patch.DueAt.Value
+		payload.SetFields = append(payload.SetFields, "due_at")
+	}
+	if patch.Priority.Set {
+		payload.Priority = priority(patch.Priority.Value)
+		payload.SetFields = append(payload.SetFields, "priority")
+	}
+	if patch.RemindAt.Set {
+		payload.RemindAt = patch.RemindAt.Value
+		payload.SetFields = append(payload.SetFields, "remind_at")
+	}
+	if patch.Tags.Set {
+		if patch.Tags.Value != nil {
+			payload.Tags = *patch.Tags.Value
+		}
+		payload.SetFields = append(payload.SetFields, "tags")
+	}
+	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.patch($1)", payload)
+}
+
 func (r *TodoRepo) Delete(ctx context.Context, todoID, userID int) error {
 	payload := TodoRequest{
 		ID:     &todoID,
 		UserID: &userID,
 	}
-	_, err := r.pool.Exec(ctx, "SELECT todos.delete($1)", payload)
-	return err
+	deleted, err := queryValue[bool](ctx, r.pool, "SELECT todos.delete($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return apperr.NotFound("todo not found")
+	}
+	return nil
+}
+
+func (r *TodoRepo) Restore(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:     &todoID,
+		UserID: &userID,
+	}
+	todo, err := queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.restore($1)", payload)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, apperr.NotFound("todo not found in trash")
+	}
+	return todo, err
 }
 
-func (r *TodoRepo) Toggle(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+func (r *TodoRepo) Purge(ctx context.Context, todoID, userID int) error {
 	payload := TodoRequest{
 		ID:     &todoID,
 		UserID: &userID,
 	}
+	purged, err := queryValue[bool](ctx, r.pool, "SELECT todos.purge($1)", payload)
+	if err != nil {
+		return err
+	}
+	if !purged {
+		return apperr.NotFound("todo not found in trash")
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes todos of every user trashed before the given time.
+func (r *TodoRepo) PurgeExpired(ctx context.Context, before time.Time) (int, error) {
+	payload := TodoRequest{DeletedBefore: &before}
+	purged, err := queryValue[int32](ctx, r.pool, "SELECT todos.purge_expired($1)", payload)
+	return int(purged), err
+}
+
+// ClaimReminders returns up to limit reminders whose remind_at is before the
+// given time and marks them sent, so no other caller gets them again.
+func (r *TodoRepo) ClaimReminders(ctx context.Context, before time.Time, limit int) ([]models.Reminder, error) {
+	payload := TodoRequest{RemindBefore: &before, LimitVal: &limit}
+	return queryRows[models.Reminder](ctx, r.pool, "SELECT * FROM todos.claim_reminders($1)", payload)
+}
+
+// ReleaseReminder hands back a claimed reminder whose delivery failed, so the
+// next claim returns it again.
+func (r *TodoRepo) ReleaseReminder(ctx context.Context, reminder models.Reminder) error {
+	payload := TodoRequest{ID: &reminder.TodoID, RemindAt: &reminder.RemindAt}
+	_, err := queryValue[bool](ctx, r.pool, "SELECT todos.release_reminder($1)", payload)
+	return err
+}
+
+// Toggle flips completed; version works as in Update, 0 toggles unconditionally.
+// When that completes an occurrence of a series, next is where the following
+// occurrence is added, in the same statement; nil adds none.
+func (r *TodoRepo) Toggle(ctx context.Context, todoID, userID, version int, next *time.Time) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:              &todoID,
+		UserID:          &userID,
+		ExpectedVersion: expectedVersion(version),
+		NextOccurrence:  next,
+	}
 	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.toggle($1)", payload)
 }
+
+// Move gives a todo a rank between its new neighbours; only the owner can
+// move it. A stale ordering is respaced in the same statement.
+func (r *TodoRepo) Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error) {
+	payload := TodoRequest{
+		ID:       &move.ID,
+		UserID:   &userID,
+		BeforeID: move.BeforeID,
+		AfterID:  move.AfterID,
+	}
+	return queryOne[models.Todo](ctx, r.pool, "SELECT * FROM todos.move($1)", payload)
+}
+
+// expectedVersion maps the zero version to "no precondition".
+func expectedVersion(version int) *int {
+	if version <= 0 {
+		return nil
+	}
+	return &version
+}
+
+// batchRow matches todos.batch_result; the todo columns are NULL unless the operation succeeded.
+type batchRow struct {
+	Idx           int        `db:"idx"`
+	Op            string     `db:"op"`
+	Status        string     `db:"status"`
+	ErrorCode     *string    `db:"error_code"`
+	ErrorMessage  *string    `db:"error_message"`
+	ID            *int       `db:"id"`
+	UserID        *int       `db:"user_id"`
+	Title         *string    `db:"title"`
+	Description   *string    `db:"description"`
+	Completed     *bool      `db:"completed"`
+	CreatedAt     *time.Time `db:"created_at"`
+	UpdatedAt     *time.Time `db:"updated_at"`
+	DeletedAt     *time.Time `db:"deleted_at"`
+	Version       *int       `db:"version"`
+	ProjectID     *int       `db:"project_id"`
+	DueAt         *time.Time `db:"due_at"`
+	Priority      *string    `db:"priority"`
+	RemindAt      *time.Time `db:"remind_at"`
+	SeriesID      *int       `db:"series_id"`
+	OccurrenceAt  *time.Time `db:"occurrence_at"`
+	SubtasksTotal *int       `db:"subtasks_total"`
+	SubtasksDone  *int       `db:"subtasks_done"`
+	Tags          []string   `db:"tags"`
+	Position      *string    `db:"position"`
+}
+
+// Batch applies ops in one transaction through todos.batch. With atomic set,
+// any failure rolls back the whole batch; results are returned either way.
+func (r *TodoRepo) Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error) {
+	payload := BatchRequest{Atomic: &atomic, Ops: make([]TodoRequest, len(ops))}
+	for i, op := range ops {
+		name := string(op.Op)
+		req := TodoRequest{
+			UserID:          &userID,
+			Title:           op.Todo.Title,
+			Description:     op.Todo.Description,
+			Completed:       op.Todo.Completed,
+			ProjectID:       op.Todo.ProjectID,
+			DueAt:           op.Todo.DueAt,
+			Priority:        priority(op.Todo.Priority),
+			RemindAt:        op.Todo.RemindAt,
+			Op:              &name,
+			ExpectedVersion: expectedVersion(op.Todo.Version),
+			Tags:            op.Todo.Tags,
+		}
+		if op.Op != models.BatchCreate {
+			id := op.Todo.ID
+			req.ID = &id
+		}
+		payload.Ops[i] = req
+	}
+
+	rows, err := queryRows[batchRow](ctx, r.pool, "SELECT * FROM todos.batch($1)", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]models.TodoBatchResult, len(rows))
+	for i, row := range rows {
+		res := models.TodoBatchResult{Index: row.Idx, Op: models.BatchOp(row.Op), Status: row.Status}
+		if row.ID != nil {
+			res.Todo = &models.Todo{
+				ID:            *row.ID,
+				UserID:        *row.UserID,
+				Title:         row.Title,
+				Description:   row.Description,
+				Completed:     row.Completed,
+				CreatedAt:     *row.CreatedAt,
+				UpdatedAt:     *row.UpdatedAt,
+				DeletedAt:     row.DeletedAt,
+				Version:       *row.Version,
+				ProjectID:     row.ProjectID,
+				DueAt:         row.DueAt,
+				Priority:      (*models.TodoPriority)(row.Priority),
+				RemindAt:      row.RemindAt,
+				SeriesID:      row.SeriesID,
+				OccurrenceAt:  row.OccurrenceAt,
+				SubtasksTotal: *row.SubtasksTotal,
+				SubtasksDone:  *row.SubtasksDone,
+				Tags:          row.Tags,
+				Position:      *row.Position,
+			}
+		}
+		if row.ErrorCode != nil {
+			res.Error = batchError(*row.ErrorCode, row.ErrorMessage)
+		}
+		results[i] = res
+	}
+	return results, nil
+}
+
+// batchError classifies a failed operation like translateError does for whole queries.
+func batchError(code string, message *string) *models.BatchError {
+	kind := kindForSQLState(code)
+	if kind == apperr.KindInternal || message == nil {
+		return &models.BatchError{Code: kind.String(), Message: "internal error"}
+	}
+	return &models.BatchError{Code: kind.String(), Message: *message}
+}
@@ -1,22 +1,267 @@
 package postgres
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// CompositeTypes are the request types the SQL functions take, registered on
+// every connection so request structs can be encoded into them. Order
+// matters: array and nested types need their element types registered first.
+var CompositeTypes = []string{
+	"users.user_request",
+	"todos.todo_request", "todos._todo_request", "todos.batch_request", "todos.share_request", "todos.subtask_request",
+	"projects.project_request",
+	"tags.tag_request",
+	"audit.event_request",
+	"events.event_request",
+	"auth.session_request",
+	"idempotency.key_request",
+}
+
+// Request structs are encoded positionally into their composite types:
+// field order must match the attribute order in SQL, new fields go last.
+
 // UserRequest matches the PostgreSQL type users.user_request
 type UserRequest struct {
-	ID           *int    `db:"id"`
-	Name         *string `db:"name"`
-	Email        *string `db:"email"`
-	PasswordHash *string `db:"password_hash"`
-	LimitVal     *int    `db:"limit_val"`
-	OffsetVal    *int    `db:"offset_val"`
+	ID              *int       `db:"id"`
+	Name            *string    `db:"name"`
+	Email           *string    `db:"email"`
+	PasswordHash    *string    `db:"password_hash"`
+	LimitVal        *int       `db:"limit_val"`
+	OffsetVal       *int       `db:"offset_val"`
+	CursorCreatedAt *time.Time `db:"cursor_created_at"`
+	CursorID        *int       `db:"cursor_id"`
+	CursorDir       *string    `db:"cursor_dir"`
+	Disabled        *bool      `db:"disabled"`
+}
+
+func (r *UserRequest) setPage(page pagination.Page) {
+	r.LimitVal, r.OffsetVal = &page.Limit, &page.Offset
+	r.CursorCreatedAt, r.CursorID, r.CursorDir = cursorFields(page.Cursor)
 }
 
 // TodoRequest matches the PostgreSQL type todos.todo_request
 type TodoRequest struct {
+	ID              *int       `db:"id"`
+	UserID          *int       `db:"user_id"`
+	Title           *string    `db:"title"`
+	Description     *string    `db:"description"`
+	Completed       *bool      `db:"completed"`
+	LimitVal        *int       `db:"limit_val"`
+	OffsetVal       *int       `db:"offset_val"`
+	CursorCreatedAt *time.Time `db:"cursor_created_at"`
+	CursorID        *int       `db:"cursor_id"`
+	CursorDir       *string    `db:"cursor_dir"`
+	CreatedAfter    *time.Time `db:"created_after"`
+	CreatedBefore   *time.Time `db:"created_before"`
+	UpdatedAfter    *time.Time `db:"updated_after"`
+	UpdatedBefore   *time.Time `db:"updated_before"`
+	Search          *string    `db:"search"`
+	SortBy          *string    `db:"sort_by"`
+	SortDesc        *bool      `db:"sort_desc"`
+	Trashed         *bool      `db:"trashed"`
+	DeletedBefore   *time.Time `db:"deleted_before"`
+	Op              *string    `db:"op"`
+	ExpectedVersion *int       `db:"expected_version"`
+	SetFields       []string   `db:"set_fields"`
+	ProjectID       *int       `db:"project_id"`
+	Inbox           *bool      `db:"inbox"`
+	DueAt           *time.Time `db:"due_at"`
+	Priority        *string    `db:"priority"`
+	RemindAt        *time.Time `db:"remind_at"`
+	DueAfter        *time.Time `db:"due_after"`
+	DueBefore       *time.Time `db:"due_before"`
+	Overdue         *bool      `db:"overdue"`
+	RemindBefore    *time.Time `db:"remind_before"`
+	RRule           *string    `db:"rrule"`
+	Timezone        *string    `db:"timezone"`
+	SeriesID        *int       `db:"series_id"`
+	NextOccurrence  *time.Time `db:"next_occurrence_at"`
+	Tags            []string   `db:"tags"`
+	TagMode         *string    `db:"tag_mode"`
+	BeforeID        *int       `db:"before_id"`
+	AfterID         *int       `db:"after_id"`
+	Revision        *int       `db:"revision"`
+}
+
+func (r *TodoRequest) setFilter(f models.TodoFilter) {
+	r.Completed = f.Completed
+	r.CreatedAfter, r.CreatedBefore = f.CreatedAfter, f.CreatedBefore
+	r.UpdatedAfter, r.UpdatedBefore = f.UpdatedAfter, f.UpdatedBefore
+	if f.Query != "" {
+		r.Search = &f.Query
+	}
+	r.ProjectID = f.ProjectID
+	if f.Inbox {
+		r.Inbox = &f.Inbox
+	}
+	r.Priority = priority(f.Priority)
+	r.DueAfter, r.DueBefore = f.DueAfter, f.DueBefore
+	if f.Overdue {
+		r.Overdue = &f.Overdue
+	}
+	r.SeriesID = f.SeriesID
+	r.Tags = f.Tags
+	if f.AllTags {
+		mode := "all"
+		r.TagMode = &mode
+	}
+	if f.Sort != "" {
+		sort := string(f.Sort)
+		r.SortBy = &sort
+	}
+	desc := !f.Ascending
+	r.SortDesc = &desc
+	if f.Trashed {
+		r.Trashed = &f.Trashed
+	}
+}
+
+func (r *TodoRequest) setPage(page pagination.Page) {
+	r.LimitVal, r.OffsetVal = &page.Limit, &page.Offset
+	r.CursorCreatedAt, r.CursorID, r.CursorDir = cursorFields(page.Cursor)
+}
+
+// BatchRequest matches the PostgreSQL type todos.batch_request
+type BatchRequest struct {
+	Atomic *bool         `db:"atomic"`
+	Ops    []TodoRequest `db:"ops"`
+}
+
+// SubtaskRequest matches the PostgreSQL type todos.subtask_request
+type SubtaskRequest struct {
+	ID             *int    `db:"id"`
+	TodoID         *int    `db:"todo_id"`
+	UserID         *int    `db:"user_id"`
+	Title          *string `db:"title"`
+	Completed      *bool   `db:"completed"`
+	CompleteParent *bool   `db:"complete_parent"`
+	IDs            []int   `db:"ids"`
+	TodoIDs        []int   `db:"todo_ids"`
+}
+
+// ShareRequest matches the PostgreSQL type todos.share_request
+type ShareRequest struct {
+	OwnerID    *int    `db:"owner_id"`
+	TodoID     *int    `db:"todo_id"`
+	UserID     *int    `db:"user_id"`
+	Email      *string `db:"email"`
+	Permission *string `db:"permission"`
+	LimitVal   *int    `db:"limit_val"`
+	OffsetVal  *int    `db:"offset_val"`
+}
+
+// ProjectRequest matches the PostgreSQL type projects.project_request
+type ProjectRequest struct {
 	ID          *int    `db:"id"`
 	UserID      *int    `db:"user_id"`
-	Title       *string `db:"title"`
+	Name        *string `db:"name"`
 	Description *string `db:"description"`
-	Completed   *bool   `db:"completed"`
 	LimitVal    *int    `db:"limit_val"`
 	OffsetVal   *int    `db:"offset_val"`
+	DeleteMode  *string `db:"delete_mode"`
+}
+
+// TagRequest matches the PostgreSQL type tags.tag_request
+type TagRequest struct {
+	ID        *int    `db:"id"`
+	UserID    *int    `db:"user_id"`
+	Name      *string `db:"name"`
+	LimitVal  *int    `db:"limit_val"`
+	OffsetVal *int    `db:"offset_val"`
+}
+
+// AuditRequest matches the PostgreSQL type audit.event_request
+type AuditRequest struct {
+	UserID         *int       `db:"user_id"`
+	TodoID         *int       `db:"todo_id"`
+	ActorID        *int       `db:"actor_id"`
+	Action         *string    `db:"action"`
+	Entity         *string    `db:"entity"`
+	EntityID       *int       `db:"entity_id"`
+	RequestID      *string    `db:"request_id"`
+	OccurredAfter  *time.Time `db:"occurred_after"`
+	OccurredBefore *time.Time `db:"occurred_before"`
+	LimitVal       *int       `db:"limit_val"`
+	OffsetVal      *int       `db:"offset_val"`
+}
+
+func (r *AuditRequest) setFilter(f models.AuditFilter) {
+	r.TodoID, r.ActorID, r.EntityID = f.TodoID, f.ActorID, f.EntityID
+	r.OccurredAfter, r.OccurredBefore = f.OccurredAfter, f.OccurredBefore
+	if f.Action != "" {
+		r.Action = &f.Action
+	}
+	if f.Entity != "" {
+		r.Entity = &f.Entity
+	}
+	if f.RequestID != "" {
+		r.RequestID = &f.RequestID
+	}
+}
+
+// EventRequest matches the PostgreSQL type events.event_request
+type EventRequest struct {
+	ID       *int64          `db:"id"`
+	UserID   *int            `db:"user_id"`
+	Type     *string         `db:"type"`
+	TodoID   *int            `db:"todo_id"`
+	Todo     json.RawMessage `db:"todo"`
+	AfterID  *int64          `db:"after_id"`
+	Before   *time.Time      `db:"before"`
+	LimitVal *int            `db:"limit_val"`
+}
+
+// SessionRequest matches the PostgreSQL type auth.session_request
+type SessionRequest struct {
+	FamilyID     *string `db:"family_id"`
+	UserID       *int    `db:"user_id"`
+	TokenHash    *string `db:"token_hash"`
+	NewTokenHash *string `db:"new_token_hash"`
+	TTLSeconds   *int    `db:"ttl_seconds"`
+	GraceSeconds *int    `db:"grace_seconds"`
+}
+
+// IdempotencyRequest matches the PostgreSQL type idempotency.key_request
+type IdempotencyRequest struct {
+	UserID      *int                `db:"user_id"`
+	Key         *string             `db:"key"`
+	Fingerprint *string             `db:"fingerprint"`
+	TTLSeconds  *int                `db:"ttl_seconds"`
+	StatusCode  *int                `db:"status_code"`
+	Headers     map[string][]string `db:"headers"`
+	Body        []byte              `db:"body"`
+}
+
+func priority(p *models.TodoPriority) *string {
+	if p == nil {
+		return nil
+	}
+	s := string(*p)
+	return &s
+}
+
+// setRecurrence fills rrule and timezone; empty parts stay NULL, i.e. unchanged.
+func (r *TodoRequest) setRecurrence(rec *models.Recurrence) {
+	if rec == nil {
+		return
+	}
+	if rec.RRule != "" {
+		r.RRule = &rec.RRule
+	}
+	if rec.Timezone != "" {
+		r.Timezone = &rec.Timezone
+	}
+}
+
+func cursorFields(c *pagination.Cursor) (*time.Time, *int, *string) {
+	if c == nil {
+		return nil, nil, nil
+	}
+	dir := string(c.Dir)
+	return &c.CreatedAt, &c.ID, &dir
 }
@@ -3,7 +3,7 @@ package routes
 import (
 	"github.com/fayzzzm/go-bro/controller"
 	"github.com/fayzzzm/go-bro/middleware"
-	"github.com/fayzzzm/go-bro/usecase/users"
+	"github.com/fayzzzm/go-bro/models"
 	"github.com/gin-gonic/gin"
 )
 
@@ -12,42 +12,122 @@ func SetupRoutes(
 	userCtrl *controller.UserController,
 	authCtrl *controller.AuthController,
 	todoCtrl *controller.TodoController,
+	shareCtrl *controller.ShareController,
+	projectCtrl *controller.ProjectController,
+	tagCtrl *controller.TagController,
+	auditCtrl *controller.AuditController,
+	streamCtrl *controller.StreamController,
+	wsCtrl *controller.WebSocketController,
+	keysCtrl *controller.KeysController,
+	tokens middleware.TokenValidator,
+	sessions middleware.SessionChecker,
+	idempotency *middleware.Idempotency,
 ) {
+	// Honours Idempotency-Key on POSTs that create resources
+	idempotent := idempotency.Handler()
+
+	// Public signing keys for token verification by other services
+	r.GET("/.well-known/jwks.json", keysCtrl.JWKS)
+
 	// API v1 group
 	api := r.Group("/api/v1")
 
 	// Public routes (no auth required)
 	auth := api.Group("/auth")
 	{
-		auth.POST("/signup", middleware.BindJSON[controller.SignupRequest](), authCtrl.Signup)
+		auth.POST("/signup", idempotency.StatusOnly(), middleware.BindJSON[controller.SignupRequest](), authCtrl.Signup)
 		auth.POST("/login", middleware.BindJSON[controller.LoginRequest](), authCtrl.Login)
+		auth.POST("/refresh", authCtrl.Refresh)
 		auth.POST("/logout", authCtrl.Logout)
 	}
 
-	// User routes (public for now, can be protected later)
-	usersGroup := api.Group("/users")
-	{
-		usersGroup.GET("", userCtrl.ListUsers)
-		usersGroup.GET("/:id", userCtrl.GetUser)
-		usersGroup.POST("", middleware.BindJSON[users.RegisterUserInput](), userCtrl.CreateUser)
-	}
-
 	// Protected routes (require auth)
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware())
+	protected.Use(middleware.AuthMiddleware(tokens, sessions))
 	{
 		// Auth
 		protected.GET("/me", authCtrl.Me)
 
+		// Live editing over a WebSocket, authenticated by the auth_token cookie
+		protected.GET("/ws", wsCtrl.Connect)
+
+		// Users: everyone may read their own profile, admins any
+		protected.GET("/users/:id", userCtrl.GetUser)
+
 		// Todos
 		todos := protected.Group("/todos")
 		{
 			todos.GET("", todoCtrl.List)
-			todos.POST("", middleware.BindJSON[controller.CreateTodoRequest](), todoCtrl.Create)
+			todos.GET("/trash", todoCtrl.Trash)
+			todos.GET("/stream", streamCtrl.Stream)
+			todos.DELETE("/trash/:id", todoCtrl.Purge)
+			todos.POST("", idempotent, middleware.BindJSON[controller.CreateTodoRequest](), todoCtrl.Create)
+			todos.POST("/batch", idempotent, middleware.BindJSON[controller.BatchTodoRequest](), todoCtrl.Batch)
 			todos.GET("/:id", todoCtrl.GetByID)
 			todos.PUT("/:id", middleware.BindJSON[controller.UpdateTodoRequest](), todoCtrl.Update)
+			todos.PATCH("/:id", middleware.ContentType(middleware.MergePatchJSON, "application/json"), middleware.BindJSON[controller.PatchTodoRequest](), todoCtrl.Patch)
 			todos.DELETE("/:id", todoCtrl.Delete)
 			todos.PATCH("/:id/toggle", todoCtrl.Toggle)
+			todos.POST("/:id/restore", todoCtrl.Restore)
+			todos.PATCH("/:id/move", middleware.BindJSON[controller.MoveTodoRequest](), todoCtrl.Move)
+			todos.GET("/:id/history", auditCtrl.TodoHistory)
+
+			// Revisions: earlier contents of a todo, each can be restored
+			todos.GET("/:id/revisions", todoCtrl.ListRevisions)
+			todos.GET("/:id/revisions/:rev", todoCtrl.GetRevision)
+			todos.POST("/:id/revisions/:rev/revert", todoCtrl.RevertRevision)
+
+			// Subtasks: the checklist of one todo
+			todos.GET("/:id/subtasks", todoCtrl.ListSubtasks)
+			todos.POST("/:id/subtasks", middleware.BindJSON[controller.AddSubtaskRequest](), todoCtrl.AddSubtask)
+			todos.PUT("/:id/subtasks/order", middleware.BindJSON[controller.ReorderSubtasksRequest](), todoCtrl.ReorderSubtasks)
+			todos.PATCH("/:id/subtasks/:subtask_id", middleware.BindJSON[controller.UpdateSubtaskRequest](), todoCtrl.UpdateSubtask)
+			todos.DELETE("/:id/subtasks/:subtask_id", todoCtrl.DeleteSubtask)
+
+			// Recurring todos: occurrences are listed with GET /todos?series_id=
+			todos.GET("/series/:id", todoCtrl.GetSeries)
+			todos.DELETE("/series/:id", todoCtrl.DeleteSeries)
+
+			// Sharing: one todo under /:id/shares, the whole list under /shares
+			todos.GET("/shared", shareCtrl.Shared)
+			todos.GET("/shares", shareCtrl.List)
+			todos.POST("/shares", middleware.BindJSON[controller.ShareTodoRequest](), shareCtrl.Share)
+			todos.DELETE("/shares/:user_id", shareCtrl.Unshare)
+			todos.GET("/:id/shares", shareCtrl.List)
+			todos.POST("/:id/shares", middleware.BindJSON[controller.ShareTodoRequest](), shareCtrl.Share)
+			todos.DELETE("/:id/shares/:user_id", shareCtrl.Unshare)
+		}
+
+		// Projects: a project's todos are listed with GET /todos?project_id=
+		projects := protected.Group("/projects")
+		{
+			projects.GET("", projectCtrl.List)
+			projects.POST("", idempotent, middleware.BindJSON[controller.CreateProjectRequest](), projectCtrl.Create)
+			projects.GET("/:id", projectCtrl.GetByID)
+			projects.PUT("/:id", middleware.BindJSON[controller.UpdateProjectRequest](), projectCtrl.Update)
+			projects.DELETE("/:id", projectCtrl.Delete)
+		}
+
+		// Tags: todos are tagged by name and listed with GET /todos?tag=
+		tags := protected.Group("/tags")
+		{
+			tags.GET("", tagCtrl.List)
+			tags.POST("", idempotent, middleware.BindJSON[controller.CreateTagRequest](), tagCtrl.Create)
+			tags.GET("/:id", tagCtrl.GetByID)
+			tags.PUT("/:id", middleware.BindJSON[controller.UpdateTagRequest](), tagCtrl.Update)
+			tags.DELETE("/:id", tagCtrl.Delete)
+		}
+
+		// Admin
+		admin := protected.Group("/admin", middleware.RequireRole(models.RoleAdmin))
+		{
+			admin.GET("/users", userCtrl.ListUsers)
+			admin.GET("/users/:id", userCtrl.GetUser)
+			admin.POST("/users/:id/disable", userCtrl.DisableUser)
+			admin.POST("/users/:id/enable", userCtrl.EnableUser)
+			admin.DELETE("/users/:id", userCtrl.DeleteUser)
+			admin.GET("/users/:id/todos", todoCtrl.ListForUser)
+			admin.GET("/audit", auditCtrl.List)
 		}
 	}
 }
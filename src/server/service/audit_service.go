@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// ErrAuditWindow is returned when occurred_after is not before occurred_before.
+var ErrAuditWindow = apperr.Validation("occurred_after must be before occurred_before")
+
+// AuditRepository is implemented by postgres.AuditRepo.
+type AuditRepository interface {
+	List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+	TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+}
+
+type AuditServicer interface {
+	List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+	TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error)
+}
+
+// AuditService reads the audit trail; the database writes it as rows change.
+type AuditService struct {
+	repo AuditRepository
+}
+
+func NewAuditService(repo AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// List returns events across all users, for admins. It pages by limit and
+// offset; cursors are not supported here.
+func (s *AuditService) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	if err := checkAuditWindow(filter); err != nil {
+		return nil, err
+	}
+	page = page.Normalize()
+	page.Cursor = nil
+	return s.repo.List(ctx, filter, page)
+}
+
+// TodoHistory returns the events of a todo the user owns, trashed or not.
+func (s *AuditService) TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	if err := checkAuditWindow(filter); err != nil {
+		return nil, err
+	}
+	page = page.Normalize()
+	page.Cursor = nil
+	return s.repo.TodoHistory(ctx, todoID, userID, filter, page)
+}
+
+func checkAuditWindow(f models.AuditFilter) error {
+	if f.OccurredAfter != nil && f.OccurredBefore != nil && !f.OccurredAfter.Before(*f.OccurredBefore) {
+		return ErrAuditWindow
+	}
+	return nil
+}
@@ -3,75 +3,180 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
 	"github.com/fayzzzm/go-bro/pkg/auth"
 	"golang.org/x/crypto/bcrypt"
 )
 
+var (
+	// ErrInvalidCredentials hides whether the email or the password was wrong.
+	ErrInvalidCredentials = apperr.Unauthorized("invalid credentials")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown, expired,
+	// revoked or has already been rotated.
+	ErrInvalidRefreshToken = apperr.Unauthorized("invalid refresh token")
+	// ErrAccountDisabled is returned on login once an admin has disabled the account.
+	ErrAccountDisabled = apperr.Forbidden("account disabled")
+)
+
 type AuthRepository interface {
 	Signup(ctx context.Context, name, email, hashedPassword string) (*models.User, error)
 	GetUserByEmail(ctx context.Context, email string) (*models.UserWithPassword, error)
+	CreateSession(ctx context.Context, userID int, tokenHash string, ttl time.Duration) (*models.Session, error)
+	RotateSession(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error)
+	RevokeSession(ctx context.Context, tokenHash string) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
 }
 
 type AuthServicer interface {
-	Signup(ctx context.Context, name, email, password string) (*models.User, string, error)
-	Login(ctx context.Context, email, password string) (*models.User, string, error)
+	Signup(ctx context.Context, name, email, password string) (*models.User, *auth.TokenPair, error)
+	Login(ctx context.Context, email, password string) (*models.User, *auth.TokenPair, error)
+	Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+}
+
+// TokenIssuer signs access tokens; implemented by auth.TokenManager.
+type TokenIssuer interface {
+	GenerateToken(userID int, email, role, sessionID string) (string, error)
 }
 
 type AuthService struct {
-	repo AuthRepository
+	repo   AuthRepository
+	tokens TokenIssuer
 }
 
-func NewAuthService(repo AuthRepository) *AuthService {
-	return &AuthService{repo: repo}
+func NewAuthService(repo AuthRepository, tokens TokenIssuer) *AuthService {
+	return &AuthService{repo: repo, tokens: tokens}
 }
 
-func (s *AuthService) Signup(ctx context.Context, name, email, password string) (*models.User, string, error) {
+func (s *AuthService) Signup(ctx context.Context, name, email, password string) (*models.User, *auth.TokenPair, error) {
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Create user
 	user, err := s.repo.Signup(ctx, name, email, string(hashedPassword))
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(user.ID, user.Email)
+	// Start a session
+	tokens, err := s.startSession(ctx, user)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	return user, token, nil
+	return user, tokens, nil
 }
 
-func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, string, error) {
+func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, *auth.TokenPair, error) {
 	// Get user with password
 	userWithPassword, err := s.repo.GetUserByEmail(ctx, email)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil, ErrInvalidCredentials
+	}
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, nil, err
 	}
 
 	// Verify password
 	err = bcrypt.CompareHashAndPassword([]byte(userWithPassword.PasswordHash), []byte(password))
 	if err != nil {
-		return nil, "", errors.New("invalid credentials")
+		return nil, nil, ErrInvalidCredentials
 	}
 
-	// Generate token
-	token, err := auth.GenerateToken(userWithPassword.ID, userWithPassword.Email)
-	if err != nil {
-		return nil, "", err
+	// Only tell a disabled user after the password proved who they are
+	if userWithPassword.DisabledAt != nil {
+		return nil, nil, ErrAccountDisabled
 	}
 
-	return &models.User{
+	user := &models.User{
 		ID:        userWithPassword.ID,
 		Name:      userWithPassword.Name,
 		Email:     userWithPassword.Email,
 		CreatedAt: userWithPassword.CreatedAt,
-	}, token, nil
+		Role:      userWithPassword.Role,
+	}
+
+	// Start a session
+	tokens, err := s.startSession(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokens, nil
+}
+
+// Refresh exchanges a refresh token for a new pair. Each refresh token is single-use;
+// presenting a rotated one revokes every token issued from the same login, unless
+// it comes within auth.RefreshReuseGrace of the rotation (two tabs, or a retry).
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*auth.TokenPair, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	newToken, newHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.RotateSession(ctx, auth.HashRefreshToken(refreshToken), newHash, auth.RefreshTokenTTL, auth.RefreshReuseGrace)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokens.GenerateToken(session.UserID, session.Email, string(session.Role), session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: newToken,
+		SessionID:    session.FamilyID,
+	}, nil
+}
+
+// Logout revokes the session the refresh token belongs to.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+	return s.repo.RevokeSession(ctx, auth.HashRefreshToken(refreshToken))
+}
+
+func (s *AuthService) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return s.repo.IsSessionActive(ctx, sessionID)
+}
+
+// startSession creates a new refresh token family and an access token bound to it.
+func (s *AuthService) startSession(ctx context.Context, user *models.User) (*auth.TokenPair, error) {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := s.repo.CreateSession(ctx, user.ID, hash, auth.RefreshTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.tokens.GenerateToken(user.ID, user.Email, string(user.Role), session.FamilyID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &auth.TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		SessionID:    session.FamilyID,
+	}, nil
 }
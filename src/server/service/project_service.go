@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// Project errors caught before reaching the database.
+var (
+	ErrProjectName       = apperr.Validation("project name cannot be empty")
+	ErrProjectDeleteMode = apperr.Validation("delete mode must be inbox or cascade")
+)
+
+// ProjectRepository is implemented by postgres.ProjectRepo.
+type ProjectRepository interface {
+	Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error)
+	GetByID(ctx context.Context, projectID, userID int) (*models.Project, error)
+	Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error)
+	Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error)
+}
+
+type ProjectServicer interface {
+	Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error)
+	GetByID(ctx context.Context, projectID, userID int) (*models.Project, error)
+	Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error)
+	Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error)
+}
+
+// ProjectService manages the projects todos are grouped into. Todos are put
+// in a project through TodoService.
+type ProjectService struct {
+	repo ProjectRepository
+}
+
+func NewProjectService(repo ProjectRepository) *ProjectService {
+	return &ProjectService{repo: repo}
+}
+
+func (s *ProjectService) Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrProjectName
+	}
+	return s.repo.Create(ctx, userID, name, description)
+}
+
+// List pages by limit and offset; cursors are not supported here.
+func (s *ProjectService) List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error) {
+	page = page.Normalize()
+	page.Cursor = nil
+	return s.repo.List(ctx, userID, page)
+}
+
+func (s *ProjectService) GetByID(ctx context.Context, projectID, userID int) (*models.Project, error) {
+	return s.repo.GetByID(ctx, projectID, userID)
+}
+
+func (s *ProjectService) Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error) {
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		if trimmed == "" {
+			return nil, ErrProjectName
+		}
+		name = &trimmed
+	}
+	return s.repo.Update(ctx, projectID, userID, name, description)
+}
+
+// Delete defaults to moving the project's todos to the inbox.
+func (s *ProjectService) Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error) {
+	if mode == "" {
+		mode = models.DeleteToInbox
+	}
+	if mode != models.DeleteToInbox && mode != models.DeleteCascade {
+		return 0, ErrProjectDeleteMode
+	}
+	return s.repo.Delete(ctx, projectID, userID, mode)
+}
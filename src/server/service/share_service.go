@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// Share errors caught before reaching the database.
+var (
+	ErrShareEmail      = apperr.Validation("email is required")
+	ErrSharePermission = apperr.Validation("permission must be viewer or editor")
+)
+
+// ShareRepository is implemented by postgres.ShareRepo. A nil todoID
+// addresses the owner's whole list.
+type ShareRepository interface {
+	Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error)
+	Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error
+	ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error)
+	ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error)
+}
+
+type ShareServicer interface {
+	Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error)
+	Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error
+	ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error)
+	ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error)
+}
+
+// ShareService manages who else may see and edit a user's todos. What each
+// permission allows is enforced by the todos.* SQL functions.
+type ShareService struct {
+	repo ShareRepository
+}
+
+func NewShareService(repo ShareRepository) *ShareService {
+	return &ShareService{repo: repo}
+}
+
+func (s *ShareService) Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, ErrShareEmail
+	}
+	if permission != models.PermissionViewer && permission != models.PermissionEditor {
+		return nil, ErrSharePermission
+	}
+	return s.repo.Share(ctx, ownerID, todoID, email, permission)
+}
+
+func (s *ShareService) Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error {
+	return s.repo.Unshare(ctx, ownerID, todoID, userID)
+}
+
+func (s *ShareService) ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error) {
+	return s.repo.ListShares(ctx, ownerID, todoID)
+}
+
+// ListShared pages by limit and offset; cursors are not supported here.
+func (s *ShareService) ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error) {
+	page = page.Normalize()
+	page.Cursor = nil
+	return s.repo.ListShared(ctx, userID, page)
+}
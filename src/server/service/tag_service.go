@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// MaxTodoTags caps how many tags one todo can carry.
+const MaxTodoTags = 20
+
+// Tag errors caught before reaching the database.
+var (
+	ErrTagName     = apperr.Validation("tag name cannot be empty")
+	ErrTooManyTags = apperr.Validation("a todo can have at most 20 tags")
+)
+
+// TagRepository is implemented by postgres.TagRepo.
+type TagRepository interface {
+	Create(ctx context.Context, userID int, name string) (*models.Tag, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error)
+	GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error)
+	Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error)
+	Delete(ctx context.Context, tagID, userID int) error
+}
+
+type TagServicer interface {
+	Create(ctx context.Context, userID int, name string) (*models.Tag, error)
+	List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error)
+	GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error)
+	Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error)
+	Delete(ctx context.Context, tagID, userID int) error
+}
+
+// TagService manages a user's tags. Todos are tagged by name through
+// TodoService, which creates missing tags on the way.
+type TagService struct {
+	repo TagRepository
+}
+
+func NewTagService(repo TagRepository) *TagService {
+	return &TagService{repo: repo}
+}
+
+func (s *TagService) Create(ctx context.Context, userID int, name string) (*models.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrTagName
+	}
+	return s.repo.Create(ctx, userID, name)
+}
+
+// List pages by limit and offset; cursors are not supported here.
+func (s *TagService) List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error) {
+	page = page.Normalize()
+	page.Cursor = nil
+	return s.repo.List(ctx, userID, page)
+}
+
+func (s *TagService) GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error) {
+	return s.repo.GetByID(ctx, tagID, userID)
+}
+
+func (s *TagService) Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, ErrTagName
+	}
+	return s.repo.Rename(ctx, tagID, userID, name)
+}
+
+func (s *TagService) Delete(ctx context.Context, tagID, userID int) error {
+	return s.repo.Delete(ctx, tagID, userID)
+}
+
+// normalizeTags trims the names a todo is given and drops repeats, ignoring
+// case. nil stays nil, so the todo's tags are left alone.
+func normalizeTags(names []string) ([]string, error) {
+	if names == nil {
+		return nil, nil
+	}
+	out := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, ErrTagName
+		}
+		if key := strings.ToLower(name); !seen[key] {
+			seen[key] = true
+			out = append(out, name)
+		}
+	}
+	if len(out) > MaxTodoTags {
+		return nil, ErrTooManyTags
+	}
+	return out, nil
+}
+
+// tagFilter lowercases and dedupes the tags a listing filters by.
+func tagFilter(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key != "" && !seen[key] {
+			seen[key] = true
+			out = append(out, key)
+		}
+	}
+	return out
+}
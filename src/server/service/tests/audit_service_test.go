@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// MockAuditRepository records what reached the repository
+type MockAuditRepository struct {
+	called bool
+	todoID int
+	userID int
+	page   pagination.Page
+}
+
+func (m *MockAuditRepository) List(ctx context.Context, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	m.called, m.page = true, page
+	return nil, nil
+}
+
+func (m *MockAuditRepository) TodoHistory(ctx context.Context, todoID, userID int, filter models.AuditFilter, page pagination.Page) ([]models.AuditEvent, error) {
+	m.called, m.todoID, m.userID, m.page = true, todoID, userID, page
+	return nil, nil
+}
+
+func TestAuditService_List(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Normalizes the page", func(t *testing.T) {
+		repo := &MockAuditRepository{}
+		page := pagination.Page{Offset: -3, Cursor: &pagination.Cursor{ID: 1}}
+		if _, err := service.NewAuditService(repo).List(ctx, models.AuditFilter{}, page); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if repo.page.Limit != pagination.DefaultLimit || repo.page.Offset != 0 || repo.page.Cursor != nil {
+			t.Errorf("Expected the default page without a cursor, got %+v", repo.page)
+		}
+	})
+
+	t.Run("Empty time window", func(t *testing.T) {
+		repo := &MockAuditRepository{}
+		now := time.Now()
+		filter := models.AuditFilter{OccurredAfter: &now, OccurredBefore: &now}
+		_, err := service.NewAuditService(repo).List(ctx, filter, pagination.Page{})
+		if !errors.Is(err, service.ErrAuditWindow) {
+			t.Errorf("Expected ErrAuditWindow, got %v", err)
+		}
+		if repo.called {
+			t.Error("Expected the repository not to be called")
+		}
+	})
+}
+
+func TestAuditService_TodoHistory(t *testing.T) {
+	repo := &MockAuditRepository{}
+	if _, err := service.NewAuditService(repo).TodoHistory(context.Background(), 5, 1, models.AuditFilter{}, pagination.Page{Limit: 10}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.todoID != 5 || repo.userID != 1 || repo.page.Limit != 10 {
+		t.Errorf("Expected todo 5 of user 1 with limit 10, got todo %d user %d page %+v", repo.todoID, repo.userID, repo.page)
+	}
+}
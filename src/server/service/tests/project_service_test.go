@@ -0,0 +1,114 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// MockProjectRepository records what reached the repository
+type MockProjectRepository struct {
+	name *string
+	mode models.ProjectDeleteMode
+	page pagination.Page
+}
+
+func (m *MockProjectRepository) Create(ctx context.Context, userID int, name string, description *string) (*models.Project, error) {
+	m.name = &name
+	return &models.Project{ID: 1, UserID: userID, Name: name, Description: description}, nil
+}
+
+func (m *MockProjectRepository) List(ctx context.Context, userID int, page pagination.Page) ([]models.Project, error) {
+	m.page = page
+	return nil, nil
+}
+
+func (m *MockProjectRepository) GetByID(ctx context.Context, projectID, userID int) (*models.Project, error) {
+	return &models.Project{ID: projectID, UserID: userID}, nil
+}
+
+func (m *MockProjectRepository) Update(ctx context.Context, projectID, userID int, name, description *string) (*models.Project, error) {
+	m.name = name
+	return &models.Project{ID: projectID, UserID: userID}, nil
+}
+
+func (m *MockProjectRepository) Delete(ctx context.Context, projectID, userID int, mode models.ProjectDeleteMode) (int, error) {
+	m.mode = mode
+	return 2, nil
+}
+
+func TestProjectService_Create(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Trims the name", func(t *testing.T) {
+		repo := &MockProjectRepository{}
+		project, err := service.NewProjectService(repo).Create(ctx, 1, "  Home  ", nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if project.Name != "Home" {
+			t.Errorf("Expected name Home, got %q", project.Name)
+		}
+	})
+
+	t.Run("Blank name", func(t *testing.T) {
+		repo := &MockProjectRepository{}
+		_, err := service.NewProjectService(repo).Create(ctx, 1, "   ", nil)
+		if !errors.Is(err, service.ErrProjectName) {
+			t.Errorf("Expected ErrProjectName, got %v", err)
+		}
+		if repo.name != nil {
+			t.Error("Expected the repository not to be called")
+		}
+	})
+}
+
+func TestProjectService_Update_BlankName(t *testing.T) {
+	repo := &MockProjectRepository{}
+	blank := " "
+	_, err := service.NewProjectService(repo).Update(context.Background(), 1, 1, &blank, nil)
+	if !errors.Is(err, service.ErrProjectName) {
+		t.Errorf("Expected ErrProjectName, got %v", err)
+	}
+}
+
+func TestProjectService_Delete_Modes(t *testing.T) {
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		mode     models.ProjectDeleteMode
+		wantMode models.ProjectDeleteMode
+		wantErr  error
+	}{
+		{name: "Defaults to inbox", mode: "", wantMode: models.DeleteToInbox},
+		{name: "Cascade", mode: models.DeleteCascade, wantMode: models.DeleteCascade},
+		{name: "Unknown mode", mode: "archive", wantErr: service.ErrProjectDeleteMode},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MockProjectRepository{}
+			_, err := service.NewProjectService(repo).Delete(ctx, 1, 1, tc.mode)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if repo.mode != tc.wantMode {
+				t.Errorf("Expected mode %q to reach the repository, got %q", tc.wantMode, repo.mode)
+			}
+		})
+	}
+}
+
+func TestProjectService_List_Defaults(t *testing.T) {
+	repo := &MockProjectRepository{}
+	service.NewProjectService(repo).List(context.Background(), 1, pagination.Page{Offset: -5})
+
+	if repo.page.Limit != pagination.DefaultLimit || repo.page.Offset != 0 {
+		t.Errorf("Expected default page, got %+v", repo.page)
+	}
+}
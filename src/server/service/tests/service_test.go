@@ -2,17 +2,32 @@ package tests
 
 import (
 	"context"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/auth"
+	"github.com/fayzzzm/go-bro/pkg/optional"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/service"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// newTestTokens signs and validates tokens with a fixed HS256 key
+func newTestTokens() *auth.TokenManager {
+	keys, _ := auth.NewStaticKeyProvider(auth.NewHMACKey("test", []byte("test-secret")))
+	return auth.NewTokenManager(keys)
+}
+
 // MockAuthRepository implements service.AuthRepository
 type MockAuthRepository struct {
 	SignupFunc         func(ctx context.Context, name, email, hashedPassword string) (*models.User, error)
 	GetUserByEmailFunc func(ctx context.Context, email string) (*models.UserWithPassword, error)
+	RotateSessionFunc  func(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error)
+	RevokedHashes      []string
 }
 
 func (m *MockAuthRepository) Signup(ctx context.Context, name, email, hashedPassword string) (*models.User, error) {
@@ -23,15 +38,32 @@ func (m *MockAuthRepository) GetUserByEmail(ctx context.Context, email string) (
 	return m.GetUserByEmailFunc(ctx, email)
 }
 
+func (m *MockAuthRepository) CreateSession(ctx context.Context, userID int, tokenHash string, ttl time.Duration) (*models.Session, error) {
+	return &models.Session{ID: 1, FamilyID: "family-1", UserID: userID}, nil
+}
+
+func (m *MockAuthRepository) RotateSession(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error) {
+	return m.RotateSessionFunc(ctx, tokenHash, newTokenHash, ttl, grace)
+}
+
+func (m *MockAuthRepository) RevokeSession(ctx context.Context, tokenHash string) error {
+	m.RevokedHashes = append(m.RevokedHashes, tokenHash)
+	return nil
+}
+
+func (m *MockAuthRepository) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	return true, nil
+}
+
 func TestAuthService_Signup(t *testing.T) {
 	mockRepo := &MockAuthRepository{
 		SignupFunc: func(ctx context.Context, name, email, hashedPassword string) (*models.User, error) {
 			return &models.User{ID: 1, Name: name, Email: email}, nil
 		},
 	}
-	authService := service.NewAuthService(mockRepo)
+	authService := service.NewAuthService(mockRepo, newTestTokens())
 
-	user, token, err := authService.Signup(context.Background(), "Test User", "test@example.com", "password123")
+	user, tokens, err := authService.Signup(context.Background(), "Test User", "test@example.com", "password123")
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -39,8 +71,11 @@ func TestAuthService_Signup(t *testing.T) {
 	if user.Name != "Test User" {
 		t.Errorf("Expected name Test User, got %s", user.Name)
 	}
-	if token == "" {
-		t.Error("Expected token, got empty string")
+	if tokens.AccessToken == "" || tokens.RefreshToken == "" {
+		t.Error("Expected access and refresh tokens, got empty string")
+	}
+	if tokens.SessionID != "family-1" {
+		t.Errorf("Expected session ID family-1, got %s", tokens.SessionID)
 	}
 }
 
@@ -58,9 +93,9 @@ func TestAuthService_Login(t *testing.T) {
 				}, nil
 			},
 		}
-		authService := service.NewAuthService(mockRepo)
+		authService := service.NewAuthService(mockRepo, newTestTokens())
 
-		user, token, err := authService.Login(context.Background(), "test@example.com", "password123")
+		user, tokens, err := authService.Login(context.Background(), "test@example.com", "password123")
 
 		if err != nil {
 			t.Fatalf("Unexpected error: %v", err)
@@ -68,7 +103,7 @@ func TestAuthService_Login(t *testing.T) {
 		if user.Email != "test@example.com" {
 			t.Errorf("Expected email test@example.com, got %s", user.Email)
 		}
-		if token == "" {
+		if tokens.AccessToken == "" {
 			t.Error("Expected token, got empty string")
 		}
 	})
@@ -84,49 +119,213 @@ func TestAuthService_Login(t *testing.T) {
 				}, nil
 			},
 		}
-		authService := service.NewAuthService(mockRepo)
+		authService := service.NewAuthService(mockRepo, newTestTokens())
 
 		_, _, err := authService.Login(context.Background(), "test@example.com", "wrong-password")
 
-		if err == nil || err.Error() != "invalid credentials" {
+		if !errors.Is(err, service.ErrInvalidCredentials) {
 			t.Errorf("Expected invalid credentials error, got %v", err)
 		}
 	})
+	t.Run("DisabledAccount", func(t *testing.T) {
+		disabledAt := time.Now()
+		mockRepo := &MockAuthRepository{
+			GetUserByEmailFunc: func(ctx context.Context, email string) (*models.UserWithPassword, error) {
+				h, _ := bcrypt.GenerateFromPassword([]byte("password123"), 10)
+				return &models.UserWithPassword{
+					ID:           1,
+					Email:        email,
+					PasswordHash: string(h),
+					DisabledAt:   &disabledAt,
+				}, nil
+			},
+		}
+		authService := service.NewAuthService(mockRepo, newTestTokens())
+
+		_, _, err := authService.Login(context.Background(), "test@example.com", "password123")
+
+		if !errors.Is(err, service.ErrAccountDisabled) {
+			t.Errorf("Expected account disabled error, got %v", err)
+		}
+	})
+}
+
+func TestAuthService_Refresh(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var gotHash, gotNewHash string
+		var gotGrace time.Duration
+		mockRepo := &MockAuthRepository{
+			RotateSessionFunc: func(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error) {
+				gotHash, gotNewHash, gotGrace = tokenHash, newTokenHash, grace
+				return &models.Session{ID: 2, FamilyID: "family-1", UserID: 1, Email: "test@example.com"}, nil
+			},
+		}
+		authService := service.NewAuthService(mockRepo, newTestTokens())
+
+		tokens, err := authService.Refresh(context.Background(), "old-refresh-token")
+
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if gotHash != auth.HashRefreshToken("old-refresh-token") {
+			t.Error("Expected the presented token to be looked up by its hash")
+		}
+		if tokens.RefreshToken == "old-refresh-token" || auth.HashRefreshToken(tokens.RefreshToken) != gotNewHash {
+			t.Error("Expected a new refresh token whose hash was stored")
+		}
+		if gotGrace != auth.RefreshReuseGrace {
+			t.Errorf("Expected a reuse grace of %s, got %s", auth.RefreshReuseGrace, gotGrace)
+		}
+
+		claims, err := newTestTokens().ValidateToken(tokens.AccessToken)
+		if err != nil {
+			t.Fatalf("Failed to validate refreshed access token: %v", err)
+		}
+		if claims.SessionID != "family-1" {
+			t.Errorf("Expected session ID family-1, got %s", claims.SessionID)
+		}
+	})
+
+	t.Run("ReusedToken", func(t *testing.T) {
+		mockRepo := &MockAuthRepository{
+			RotateSessionFunc: func(ctx context.Context, tokenHash, newTokenHash string, ttl, grace time.Duration) (*models.Session, error) {
+				return nil, apperr.ErrNotFound
+			},
+		}
+		authService := service.NewAuthService(mockRepo, newTestTokens())
+
+		_, err := authService.Refresh(context.Background(), "rotated-refresh-token")
+
+		if !errors.Is(err, service.ErrInvalidRefreshToken) {
+			t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+
+	t.Run("MissingToken", func(t *testing.T) {
+		authService := service.NewAuthService(&MockAuthRepository{}, newTestTokens())
+
+		_, err := authService.Refresh(context.Background(), "")
+
+		if !errors.Is(err, service.ErrInvalidRefreshToken) {
+			t.Errorf("Expected ErrInvalidRefreshToken, got %v", err)
+		}
+	})
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	mockRepo := &MockAuthRepository{}
+	authService := service.NewAuthService(mockRepo, newTestTokens())
+
+	if err := authService.Logout(context.Background(), "refresh-token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(mockRepo.RevokedHashes) != 1 || mockRepo.RevokedHashes[0] != auth.HashRefreshToken("refresh-token") {
+		t.Errorf("Expected session to be revoked by token hash, got %v", mockRepo.RevokedHashes)
+	}
 }
 
 // MockTodoRepository implements service.TodoRepository
 type MockTodoRepository struct {
-	CreateFunc    func(ctx context.Context, userID int, title string, description *string) (*models.Todo, error)
-	GetByUserFunc func(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error)
+	CreateFunc    func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetByUserFunc func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error)
 	UpdateFunc    func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	CountFunc     func(ctx context.Context, userID int, filter models.TodoFilter) (int, error)
+	PatchFunc     func(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error)
+	GetByIDFunc   func(ctx context.Context, todoID, userID int) (*models.Todo, error)
+	ToggleFunc    func(ctx context.Context, todoID, userID, version int, next *time.Time) (*models.Todo, error)
+	GetSeriesFunc func(ctx context.Context, seriesID, userID int) (*models.TodoSeries, error)
+	// ListSubtasksFunc defaults to no subtasks
+	ListSubtasksFunc  func(ctx context.Context, userID int, todoIDs []int) ([]models.Subtask, error)
+	UpdateSubtaskFunc func(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error)
+	GetRevisionFunc   func(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error)
 }
 
-func (m *MockTodoRepository) Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error) {
-	return m.CreateFunc(ctx, userID, title, description)
+func (m *MockTodoRepository) Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	return m.CreateFunc(ctx, userID, todo)
 }
-func (m *MockTodoRepository) GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error) {
-	return m.GetByUserFunc(ctx, userID, limit, offset)
+func (m *MockTodoRepository) GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+	return m.GetByUserFunc(ctx, userID, filter, page)
+}
+func (m *MockTodoRepository) Count(ctx context.Context, userID int, filter models.TodoFilter) (int, error) {
+	if m.CountFunc == nil {
+		return 0, nil
+	}
+	return m.CountFunc(ctx, userID, filter)
 }
 func (m *MockTodoRepository) GetByID(ctx context.Context, todoID, userID int) (*models.Todo, error) {
-	return nil, nil
+	if m.GetByIDFunc == nil {
+		return nil, nil
+	}
+	return m.GetByIDFunc(ctx, todoID, userID)
 }
 func (m *MockTodoRepository) Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
 	return m.UpdateFunc(ctx, userID, todo)
 }
+func (m *MockTodoRepository) Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+	return m.PatchFunc(ctx, userID, patch)
+}
 func (m *MockTodoRepository) Delete(ctx context.Context, todoID, userID int) error { return nil }
-func (m *MockTodoRepository) Toggle(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+func (m *MockTodoRepository) Toggle(ctx context.Context, todoID, userID, version int, next *time.Time) (*models.Todo, error) {
+	return m.ToggleFunc(ctx, todoID, userID, version, next)
+}
+func (m *MockTodoRepository) Restore(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+	return nil, nil
+}
+func (m *MockTodoRepository) Purge(ctx context.Context, todoID, userID int) error { return nil }
+func (m *MockTodoRepository) Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error) {
+	return nil, nil
+}
+func (m *MockTodoRepository) UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	return m.UpdateFunc(ctx, userID, todo)
+}
+func (m *MockTodoRepository) GetSeries(ctx context.Context, seriesID, userID int) (*models.TodoSeries, error) {
+	return m.GetSeriesFunc(ctx, seriesID, userID)
+}
+func (m *MockTodoRepository) DeleteSeries(ctx context.Context, seriesID, userID int) error {
+	return nil
+}
+func (m *MockTodoRepository) ListSubtasks(ctx context.Context, userID int, todoIDs []int) ([]models.Subtask, error) {
+	if m.ListSubtasksFunc == nil {
+		return nil, nil
+	}
+	return m.ListSubtasksFunc(ctx, userID, todoIDs)
+}
+func (m *MockTodoRepository) AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error) {
+	return &models.Subtask{TodoID: todoID, Title: title}, nil
+}
+func (m *MockTodoRepository) UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error) {
+	return m.UpdateSubtaskFunc(ctx, userID, update)
+}
+func (m *MockTodoRepository) DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error {
+	return nil
+}
+func (m *MockTodoRepository) Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error) {
+	return &models.Todo{ID: move.ID, UserID: userID}, nil
+}
+func (m *MockTodoRepository) ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error) {
+	return nil, nil
+}
+func (m *MockTodoRepository) ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error) {
 	return nil, nil
 }
+func (m *MockTodoRepository) GetRevision(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error) {
+	return m.GetRevisionFunc(ctx, todoID, userID, revision)
+}
+func (m *MockTodoRepository) RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error) {
+	return &models.Todo{ID: todoID, UserID: userID, Version: version + 1}, nil
+}
 
 func TestTodoService_Create(t *testing.T) {
 	mockRepo := &MockTodoRepository{
-		CreateFunc: func(ctx context.Context, userID int, title string, description *string) (*models.Todo, error) {
-			return &models.Todo{ID: 1, UserID: userID, Title: &title}, nil
+		CreateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			return &models.Todo{ID: 1, UserID: userID, Title: todo.Title}, nil
 		},
 	}
-	todoService := service.NewTodoService(mockRepo)
+	todoService := service.NewTodoService(mockRepo, nil)
 
-	todo, err := todoService.Create(context.Background(), 1, "Buy Milk", nil)
+	title := "Buy Milk"
+	todo, err := todoService.Create(context.Background(), 1, &models.Todo{Title: &title})
 
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
@@ -139,16 +338,191 @@ func TestTodoService_Create(t *testing.T) {
 func TestTodoService_GetByUser_Defaults(t *testing.T) {
 	var capturedLimit int
 	mockRepo := &MockTodoRepository{
-		GetByUserFunc: func(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error) {
-			capturedLimit = limit
+		GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+			capturedLimit = page.Limit
 			return []models.Todo{}, nil
 		},
 	}
-	todoService := service.NewTodoService(mockRepo)
+	todoService := service.NewTodoService(mockRepo, nil)
 
-	todoService.GetByUser(context.Background(), 1, 0, -1)
+	todoService.GetByUser(context.Background(), 1, models.TodoFilter{}, pagination.Page{Offset: -1})
 
-	if capturedLimit != 100 {
-		t.Errorf("Expected limit to default to 100, got %d", capturedLimit)
+	// One extra row is fetched to tell whether a next page exists
+	if capturedLimit != 101 {
+		t.Errorf("Expected limit to default to 100 (+1 probe row), got %d", capturedLimit)
+	}
+}
+
+func TestTodoService_GetByUser_Cursors(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rows := func(ids ...int) []models.Todo {
+		todos := make([]models.Todo, len(ids))
+		for i, id := range ids {
+			todos[i] = models.Todo{ID: id, CreatedAt: base.Add(time.Duration(id) * time.Minute)}
+		}
+		return todos
+	}
+
+	t.Run("first page has only a next cursor", func(t *testing.T) {
+		mockRepo := &MockTodoRepository{
+			GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+				return rows(5, 4, 3), nil
+			},
+			CountFunc: func(ctx context.Context, userID int, filter models.TodoFilter) (int, error) {
+				return 5, nil
+			},
+		}
+		res, err := service.NewTodoService(mockRepo, nil).GetByUser(context.Background(), 1, models.TodoFilter{}, pagination.Page{Limit: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(res.Items) != 2 || res.Items[1].ID != 4 {
+			t.Fatalf("Expected todos 5,4, got %+v", res.Items)
+		}
+		if res.Next == nil || res.Next.ID != 4 || res.Next.Dir != pagination.Next {
+			t.Errorf("Expected next cursor at todo 4, got %+v", res.Next)
+		}
+		if res.Prev != nil {
+			t.Errorf("Expected no prev cursor on the first page, got %+v", res.Prev)
+		}
+		if res.Total == nil || *res.Total != 5 {
+			t.Errorf("Expected total 5 across pages, got %v", res.Total)
+		}
+	})
+
+	t.Run("skips the count on request", func(t *testing.T) {
+		mockRepo := &MockTodoRepository{
+			GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+				return rows(5), nil
+			},
+			CountFunc: func(ctx context.Context, userID int, filter models.TodoFilter) (int, error) {
+				t.Error("Expected no count query")
+				return 0, nil
+			},
+		}
+		res, err := service.NewTodoService(mockRepo, nil).GetByUser(context.Background(), 1, models.TodoFilter{}, pagination.Page{SkipTotal: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.Total != nil {
+			t.Errorf("Expected no total, got %d", *res.Total)
+		}
+	})
+
+	t.Run("backward page drops the extra newest row", func(t *testing.T) {
+		var captured pagination.Page
+		mockRepo := &MockTodoRepository{
+			GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+				captured = page
+				return rows(8, 7, 6), nil
+			},
+		}
+		cursor := &pagination.Cursor{CreatedAt: base.Add(5 * time.Minute), ID: 5, Dir: pagination.Prev}
+		res, err := service.NewTodoService(mockRepo, nil).GetByUser(context.Background(), 1, models.TodoFilter{}, pagination.Page{Limit: 2, Offset: 40, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if captured.Offset != 0 {
+			t.Errorf("Expected offset to be ignored with a cursor, got %d", captured.Offset)
+		}
+		if len(res.Items) != 2 || res.Items[0].ID != 7 || res.Items[1].ID != 6 {
+			t.Fatalf("Expected todos 7,6, got %+v", res.Items)
+		}
+		if res.Prev == nil || res.Prev.ID != 7 || res.Prev.Dir != pagination.Prev {
+			t.Errorf("Expected prev cursor at todo 7, got %+v", res.Prev)
+		}
+		if res.Next == nil || res.Next.ID != 6 || res.Next.Dir != pagination.Next {
+			t.Errorf("Expected next cursor at todo 6, got %+v", res.Next)
+		}
+	})
+
+	t.Run("custom sort pages by offset only", func(t *testing.T) {
+		var captured models.TodoFilter
+		mockRepo := &MockTodoRepository{
+			GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+				captured = filter
+				return rows(1, 2, 3), nil
+			},
+		}
+		todoService := service.NewTodoService(mockRepo, nil)
+		filter := models.TodoFilter{Sort: models.SortByTitle, Ascending: true, Query: "milk"}
+
+		res, err := todoService.GetByUser(context.Background(), 1, filter, pagination.Page{Limit: 2, Offset: 2})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !reflect.DeepEqual(captured, filter) {
+			t.Errorf("Expected filter %+v to reach the repository, got %+v", filter, captured)
+		}
+		if res.Next != nil || res.Prev != nil {
+			t.Errorf("Expected no cursors for a custom sort, got next=%+v prev=%+v", res.Next, res.Prev)
+		}
+
+		cursor := &pagination.Cursor{CreatedAt: base, ID: 1, Dir: pagination.Next}
+		_, err = todoService.GetByUser(context.Background(), 1, filter, pagination.Page{Cursor: cursor})
+		if !errors.Is(err, service.ErrCursorSort) {
+			t.Errorf("Expected ErrCursorSort, got %v", err)
+		}
+	})
+}
+
+func TestTodoService_Patch(t *testing.T) {
+	var called bool
+	mockRepo := &MockTodoRepository{
+		PatchFunc: func(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+			called = true
+			return &models.Todo{ID: patch.ID}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+
+	testCases := []struct {
+		name    string
+		patch   models.TodoPatch
+		wantErr error
+	}{
+		{"clear description", models.TodoPatch{ID: 1, Description: optional.Null[string]()}, nil},
+		{"null title", models.TodoPatch{ID: 1, Title: optional.Null[string]()}, service.ErrPatchTitle},
+		{"blank title", models.TodoPatch{ID: 1, Title: optional.Of("  ")}, service.ErrPatchTitle},
+		{"null completed", models.TodoPatch{ID: 1, Completed: optional.Null[bool]()}, service.ErrPatchCompleted},
+		{"null priority", models.TodoPatch{ID: 1, Priority: optional.Null[models.TodoPriority]()}, service.ErrPatchPriority},
+		{"unknown priority", models.TodoPatch{ID: 1, Priority: optional.Of(models.TodoPriority("asap"))}, service.ErrTodoPriority},
+		{"clear due date", models.TodoPatch{ID: 1, DueAt: optional.Null[time.Time]()}, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			called = false
+			_, err := todoService.Patch(context.Background(), 1, tc.patch)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+			if called != (tc.wantErr == nil) {
+				t.Errorf("Expected repository call only for valid patches, called=%v", called)
+			}
+		})
+	}
+}
+
+func TestTodoService_Move(t *testing.T) {
+	todoService := service.NewTodoService(&MockTodoRepository{}, nil)
+	two, five := 2, 5
+
+	testCases := []struct {
+		name    string
+		move    models.TodoMove
+		wantErr error
+	}{
+		{"after", models.TodoMove{ID: 5, AfterID: &two}, nil},
+		{"no neighbour", models.TodoMove{ID: 5}, service.ErrMoveTarget},
+		{"next to itself", models.TodoMove{ID: 5, BeforeID: &five}, service.ErrMoveSelf},
+		{"same neighbours", models.TodoMove{ID: 5, BeforeID: &two, AfterID: &two}, service.ErrMoveSelf},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := todoService.Move(context.Background(), 1, tc.move); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+		})
 	}
 }
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// MockShareRepository records what reached the repository
+type MockShareRepository struct {
+	shared int
+	page   pagination.Page
+}
+
+func (m *MockShareRepository) Share(ctx context.Context, ownerID int, todoID *int, email string, permission models.SharePermission) (*models.Share, error) {
+	m.shared++
+	return &models.Share{TodoID: todoID, Email: email, Permission: permission}, nil
+}
+
+func (m *MockShareRepository) Unshare(ctx context.Context, ownerID int, todoID *int, userID int) error {
+	return nil
+}
+
+func (m *MockShareRepository) ListShares(ctx context.Context, ownerID int, todoID *int) ([]models.Share, error) {
+	return nil, nil
+}
+
+func (m *MockShareRepository) ListShared(ctx context.Context, userID int, page pagination.Page) ([]models.SharedTodo, error) {
+	m.page = page
+	return nil, nil
+}
+
+func TestShareService_Share(t *testing.T) {
+	ctx := context.Background()
+	todoID := 7
+
+	testCases := []struct {
+		name       string
+		email      string
+		permission models.SharePermission
+		wantErr    error
+	}{
+		{name: "Viewer", email: "bob@example.com", permission: models.PermissionViewer},
+		{name: "Editor", email: " bob@example.com ", permission: models.PermissionEditor},
+		{name: "Blank email", email: "  ", permission: models.PermissionViewer, wantErr: service.ErrShareEmail},
+		{name: "Owner is not grantable", email: "bob@example.com", permission: "owner", wantErr: service.ErrSharePermission},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := &MockShareRepository{}
+			svc := service.NewShareService(repo)
+
+			share, err := svc.Share(ctx, 1, &todoID, tc.email, tc.permission)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) || repo.shared != 0 {
+					t.Errorf("Expected %v before reaching the repository, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if share.Email != "bob@example.com" {
+				t.Errorf("Expected a trimmed email, got %q", share.Email)
+			}
+		})
+	}
+}
+
+func TestShareService_ListShared_Defaults(t *testing.T) {
+	repo := &MockShareRepository{}
+	svc := service.NewShareService(repo)
+
+	if _, err := svc.ListShared(context.Background(), 1, pagination.Page{Offset: -3}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if repo.page.Limit != pagination.DefaultLimit || repo.page.Offset != 0 {
+		t.Errorf("Expected the default page, got %+v", repo.page)
+	}
+}
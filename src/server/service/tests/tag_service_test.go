@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/optional"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// MockTagRepository records what reached the repository
+type MockTagRepository struct {
+	name *string
+	page pagination.Page
+}
+
+func (m *MockTagRepository) Create(ctx context.Context, userID int, name string) (*models.Tag, error) {
+	m.name = &name
+	return &models.Tag{ID: 1, UserID: userID, Name: name}, nil
+}
+
+func (m *MockTagRepository) List(ctx context.Context, userID int, page pagination.Page) ([]models.Tag, error) {
+	m.page = page
+	return nil, nil
+}
+
+func (m *MockTagRepository) GetByID(ctx context.Context, tagID, userID int) (*models.Tag, error) {
+	return &models.Tag{ID: tagID, UserID: userID}, nil
+}
+
+func (m *MockTagRepository) Rename(ctx context.Context, tagID, userID int, name string) (*models.Tag, error) {
+	m.name = &name
+	return &models.Tag{ID: tagID, UserID: userID, Name: name}, nil
+}
+
+func (m *MockTagRepository) Delete(ctx context.Context, tagID, userID int) error {
+	return nil
+}
+
+func TestTagService_Names(t *testing.T) {
+	ctx := context.Background()
+	repo := &MockTagRepository{}
+	tags := service.NewTagService(repo)
+
+	tag, err := tags.Create(ctx, 1, "  Errands ")
+	if err != nil || tag.Name != "Errands" {
+		t.Errorf("Expected the name trimmed, got %+v %v", tag, err)
+	}
+
+	repo.name = nil
+	if _, err := tags.Create(ctx, 1, "  "); !errors.Is(err, service.ErrTagName) {
+		t.Errorf("Expected ErrTagName, got %v", err)
+	}
+	if _, err := tags.Rename(ctx, 1, 1, ""); !errors.Is(err, service.ErrTagName) {
+		t.Errorf("Expected ErrTagName on rename, got %v", err)
+	}
+	if repo.name != nil {
+		t.Error("Expected the repository not to be called")
+	}
+}
+
+func TestTagService_ListIgnoresCursor(t *testing.T) {
+	repo := &MockTagRepository{}
+	cursor := &pagination.Cursor{ID: 3}
+	service.NewTagService(repo).List(context.Background(), 1, pagination.Page{Cursor: cursor})
+
+	if repo.page.Cursor != nil || repo.page.Limit != pagination.DefaultLimit {
+		t.Errorf("Expected a default offset page, got %+v", repo.page)
+	}
+}
+
+func TestTodoService_NormalizesTags(t *testing.T) {
+	var created *models.Todo
+	var patched models.TodoPatch
+	mockRepo := &MockTodoRepository{
+		CreateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			created = todo
+			return todo, nil
+		},
+		PatchFunc: func(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+			patched = patch
+			return &models.Todo{ID: patch.ID}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+	title := "Groceries"
+
+	todo := &models.Todo{Title: &title, Tags: []string{" Home ", "errands", "home", "Errands"}}
+	if _, err := todoService.Create(context.Background(), 1, todo); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if want := []string{"Home", "errands"}; !reflect.DeepEqual(created.Tags, want) {
+		t.Errorf("Expected %v, got %v", want, created.Tags)
+	}
+
+	tags := []string{"work", " Work"}
+	if _, err := todoService.Patch(context.Background(), 1, models.TodoPatch{ID: 1, Tags: optional.Field[[]string]{Set: true, Value: &tags}}); err != nil {
+		t.Fatalf("Patch failed: %v", err)
+	}
+	if got := *patched.Tags.Value; !reflect.DeepEqual(got, []string{"work"}) {
+		t.Errorf("Expected patched tags [work], got %v", got)
+	}
+
+	many := make([]string, service.MaxTodoTags+1)
+	for i := range many {
+		many[i] = fmt.Sprintf("tag%d", i)
+	}
+	testCases := []struct {
+		name    string
+		tags    []string
+		wantErr error
+	}{
+		{"blank", []string{"home", " "}, service.ErrTagName},
+		{"too many", many, service.ErrTooManyTags},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			todo := &models.Todo{Title: &title, Tags: tc.tags}
+			if _, err := todoService.Create(context.Background(), 1, todo); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTodoService_TagFilterIsCaseInsensitive(t *testing.T) {
+	var captured models.TodoFilter
+	mockRepo := &MockTodoRepository{
+		GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+			captured = filter
+			return nil, nil
+		},
+	}
+	filter := models.TodoFilter{Tags: []string{"Home", " home", "WORK"}, AllTags: true}
+	if _, err := service.NewTodoService(mockRepo, nil).GetByUser(context.Background(), 1, filter, pagination.Page{}); err != nil {
+		t.Fatalf("GetByUser failed: %v", err)
+	}
+	if want := []string{"home", "work"}; !reflect.DeepEqual(captured.Tags, want) || !captured.AllTags {
+		t.Errorf("Expected all of %v, got %v all=%v", want, captured.Tags, captured.AllTags)
+	}
+}
@@ -0,0 +1,84 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// MockEventPublisher records every event it is given
+type MockEventPublisher struct {
+	events []models.TodoEvent
+	err    error
+}
+
+func (m *MockEventPublisher) Publish(ctx context.Context, event models.TodoEvent) error {
+	m.events = append(m.events, event)
+	return m.err
+}
+
+func TestTodoService_PublishesEvents(t *testing.T) {
+	publisher := &MockEventPublisher{}
+	mockRepo := &MockTodoRepository{
+		CreateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			return &models.Todo{ID: 1, UserID: userID, Title: todo.Title}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+			return &models.Todo{ID: todoID, UserID: 2}, nil
+		},
+		ToggleFunc: func(ctx context.Context, todoID, userID, version int, next *time.Time) (*models.Todo, error) {
+			// A todo shared with the caller: its owner is told
+			return &models.Todo{ID: todoID, UserID: 2}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, publisher)
+	ctx := context.Background()
+
+	title := "Buy Milk"
+	if _, err := todoService.Create(ctx, 1, &models.Todo{Title: &title}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if _, err := todoService.Toggle(ctx, 4, 1, 0); err != nil {
+		t.Fatalf("Toggle failed: %v", err)
+	}
+	if err := todoService.Delete(ctx, 1, 1); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	want := []models.TodoEvent{
+		{Type: models.TodoCreated, UserID: 1, TodoID: 1},
+		{Type: models.TodoToggled, UserID: 2, TodoID: 4},
+		{Type: models.TodoDeleted, UserID: 1, TodoID: 1},
+	}
+	if len(publisher.events) != len(want) {
+		t.Fatalf("Expected %d events, got %+v", len(want), publisher.events)
+	}
+	for i, w := range want {
+		got := publisher.events[i]
+		if got.Type != w.Type || got.UserID != w.UserID || got.TodoID != w.TodoID {
+			t.Errorf("Event %d: expected %+v, got %+v", i, w, got)
+		}
+	}
+	if publisher.events[0].Todo == nil || publisher.events[2].Todo != nil {
+		t.Error("Expected the todo on created and none on deleted")
+	}
+}
+
+func TestTodoService_PublishFailureKeepsChange(t *testing.T) {
+	publisher := &MockEventPublisher{err: errors.New("connection refused")}
+	mockRepo := &MockTodoRepository{
+		CreateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			return &models.Todo{ID: 1, UserID: userID}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, publisher)
+
+	title := "Buy Milk"
+	if _, err := todoService.Create(context.Background(), 1, &models.Todo{Title: &title}); err != nil {
+		t.Errorf("Expected the todo to be created despite the publish failure, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+func TestTodoService_DiffRevision(t *testing.T) {
+	old, current := "Old", "New"
+	desc := "details"
+
+	testCases := []struct {
+		name string
+		todo models.Todo
+		want []string
+	}{
+		{"Title and description changed", models.Todo{Title: &current, Description: &desc, Completed: boolPtr(false)}, []string{"description", "title"}},
+		{"Completed since", models.Todo{Title: &old, Completed: boolPtr(true)}, []string{"completed"}},
+		{"Same content", models.Todo{Title: &old, Completed: boolPtr(false)}, []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			todo := tc.todo
+			repo := &MockTodoRepository{
+				GetRevisionFunc: func(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error) {
+					return &models.TodoRevision{TodoID: todoID, Revision: revision, Title: old}, nil
+				},
+				GetByIDFunc: func(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+					return &todo, nil
+				},
+			}
+
+			diff, err := service.NewTodoService(repo, nil).DiffRevision(context.Background(), 5, 1, 2)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if diff.Revision.Revision != 2 {
+				t.Errorf("Expected revision 2, got %d", diff.Revision.Revision)
+			}
+			got := []string{}
+			for _, field := range []string{"completed", "description", "title"} {
+				if _, ok := diff.Changes[field]; ok {
+					got = append(got, field)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Expected changes to %v, got %v", tc.want, diff.Changes)
+			}
+		})
+	}
+}
+
+func TestTodoService_DiffRevision_Values(t *testing.T) {
+	old, current := "Old", "New"
+	repo := &MockTodoRepository{
+		GetRevisionFunc: func(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error) {
+			return &models.TodoRevision{TodoID: todoID, Revision: revision, Title: old}, nil
+		},
+		GetByIDFunc: func(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+			return &models.Todo{ID: todoID, Title: &current, Completed: boolPtr(false)}, nil
+		},
+	}
+
+	diff, err := service.NewTodoService(repo, nil).DiffRevision(context.Background(), 5, 1, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	change := diff.Changes["title"]
+	if change.From != "Old" || change.To != &current {
+		t.Errorf("Expected title from Old to New, got %+v", change)
+	}
+}
+
+func boolPtr(b bool) *bool { return &b }
@@ -0,0 +1,180 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+// seriesRepo serves one todo and its series and records where Toggle adds the next occurrence
+func seriesRepo(todo *models.Todo, series *models.TodoSeries, next **time.Time) *MockTodoRepository {
+	return &MockTodoRepository{
+		GetByIDFunc: func(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+			return todo, nil
+		},
+		GetSeriesFunc: func(ctx context.Context, seriesID, userID int) (*models.TodoSeries, error) {
+			if seriesID != series.ID || userID != series.UserID {
+				return nil, apperr.NotFound("series not found")
+			}
+			return series, nil
+		},
+		ToggleFunc: func(ctx context.Context, todoID, userID, version int, n *time.Time) (*models.Todo, error) {
+			*next = n
+			return todo, nil
+		},
+	}
+}
+
+func TestTodoService_ToggleAddsNextOccurrence(t *testing.T) {
+	slot := time.Now().Add(48 * time.Hour).Truncate(time.Hour).UTC()
+	seriesID := 7
+	todo := &models.Todo{ID: 1, UserID: 2, SeriesID: &seriesID, OccurrenceAt: &slot, DueAt: &slot}
+	series := &models.TodoSeries{ID: 7, UserID: 2, RRule: "FREQ=DAILY", Timezone: "UTC", DTStart: slot.AddDate(0, 0, -3)}
+
+	var next *time.Time
+	todoService := service.NewTodoService(seriesRepo(todo, series, &next), nil)
+
+	// Toggled by a shared editor; the series is looked up for its owner
+	if _, err := todoService.Toggle(context.Background(), 1, 1, 0); err != nil {
+		t.Fatalf("Toggle failed: %v", err)
+	}
+	if want := slot.AddDate(0, 0, 1); next == nil || !next.Equal(want) {
+		t.Errorf("Expected the next occurrence at %v, got %v", want, next)
+	}
+}
+
+func TestTodoService_ToggleLateCompletionSkipsPastOccurrences(t *testing.T) {
+	slot := time.Now().AddDate(0, 0, -20).Truncate(time.Hour).UTC()
+	seriesID := 7
+	todo := &models.Todo{ID: 1, UserID: 2, SeriesID: &seriesID, OccurrenceAt: &slot, DueAt: &slot}
+	series := &models.TodoSeries{ID: 7, UserID: 2, RRule: "FREQ=WEEKLY", Timezone: "UTC", DTStart: slot}
+
+	var next *time.Time
+	todoService := service.NewTodoService(seriesRepo(todo, series, &next), nil)
+
+	if _, err := todoService.Toggle(context.Background(), 1, 2, 0); err != nil {
+		t.Fatalf("Toggle failed: %v", err)
+	}
+	if next == nil || !next.After(time.Now()) || next.Sub(time.Now()) > 7*24*time.Hour {
+		t.Errorf("Expected the first occurrence after now, got %v", next)
+	}
+	if next != nil && next.Sub(slot)%(7*24*time.Hour) != 0 {
+		t.Errorf("Expected a weekly slot, got %v", next)
+	}
+}
+
+func TestTodoService_ToggleWithoutNextOccurrence(t *testing.T) {
+	slot := time.Now().Add(time.Hour).Truncate(time.Hour).UTC()
+	seriesID := 7
+	done := true
+	series := &models.TodoSeries{ID: 7, UserID: 2, RRule: "FREQ=DAILY;COUNT=1", Timezone: "UTC", DTStart: slot}
+
+	testCases := []struct {
+		name string
+		todo *models.Todo
+	}{
+		{"not recurring", &models.Todo{ID: 1, UserID: 2}},
+		{"reopening", &models.Todo{ID: 1, UserID: 2, SeriesID: &seriesID, OccurrenceAt: &slot, Completed: &done}},
+		{"series ended", &models.Todo{ID: 1, UserID: 2, SeriesID: &seriesID, OccurrenceAt: &slot}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			marker := time.Time{}
+			next := &marker
+			todoService := service.NewTodoService(seriesRepo(tc.todo, series, &next), nil)
+
+			if _, err := todoService.Toggle(context.Background(), 1, 2, 0); err != nil {
+				t.Fatalf("Toggle failed: %v", err)
+			}
+			if next != nil {
+				t.Errorf("Expected no next occurrence, got %v", next)
+			}
+		})
+	}
+}
+
+func TestTodoService_CreateRecurring(t *testing.T) {
+	var created *models.Todo
+	mockRepo := &MockTodoRepository{
+		CreateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			created = todo
+			return todo, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+	title := "Weekly report"
+	due := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	todo := &models.Todo{Title: &title, DueAt: &due, Recurrence: &models.Recurrence{RRule: "rrule:freq=weekly;interval=1;byday=mo", Timezone: "Europe/Berlin"}}
+	if _, err := todoService.Create(context.Background(), 1, todo); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if created.Recurrence.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("Expected the rule in canonical form, got %q", created.Recurrence.RRule)
+	}
+
+	testCases := []struct {
+		name    string
+		todo    *models.Todo
+		wantErr error
+	}{
+		{"no due date", &models.Todo{Title: &title, Recurrence: &models.Recurrence{RRule: "FREQ=DAILY"}}, service.ErrRecurrenceDueAt},
+		{"bad rule", &models.Todo{Title: &title, DueAt: &due, Recurrence: &models.Recurrence{RRule: "FREQ=HOURLY"}}, apperr.ErrValidation},
+		{"bad zone", &models.Todo{Title: &title, DueAt: &due, Recurrence: &models.Recurrence{RRule: "FREQ=DAILY", Timezone: "Mars/Olympus"}}, service.ErrRecurrenceTimezone},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := todoService.Create(context.Background(), 1, tc.todo); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTodoService_UpdateScope(t *testing.T) {
+	mockRepo := &MockTodoRepository{
+		UpdateFunc: func(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+			return todo, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+	rec := &models.Recurrence{RRule: "FREQ=MONTHLY"}
+
+	if _, err := todoService.Update(context.Background(), 1, &models.Todo{ID: 1, Recurrence: rec}); !errors.Is(err, service.ErrRecurrenceScope) {
+		t.Errorf("Expected %v for a single occurrence, got %v", service.ErrRecurrenceScope, err)
+	}
+	if _, err := todoService.UpdateFuture(context.Background(), 1, &models.Todo{ID: 1, Recurrence: rec}); err != nil {
+		t.Errorf("Expected the rule to change for future occurrences, got %v", err)
+	}
+	if _, err := todoService.UpdateFuture(context.Background(), 1, &models.Todo{ID: 1, Recurrence: &models.Recurrence{RRule: "FREQ=DAILY;COUNT=0"}}); !errors.Is(err, apperr.ErrValidation) {
+		t.Errorf("Expected a validation error for a bad rule, got %v", err)
+	}
+}
+
+func TestTodoService_GetSeriesUpcoming(t *testing.T) {
+	start := time.Now().AddDate(0, 0, -2).Truncate(time.Minute).UTC()
+	series := &models.TodoSeries{ID: 7, UserID: 1, RRule: "FREQ=DAILY", Timezone: "UTC", DTStart: start}
+	var next *time.Time
+	todoService := service.NewTodoService(seriesRepo(nil, series, &next), nil)
+
+	got, err := todoService.GetSeries(context.Background(), 7, 1, 3)
+	if err != nil {
+		t.Fatalf("GetSeries failed: %v", err)
+	}
+	if len(got.Upcoming) != 3 {
+		t.Fatalf("Expected 3 upcoming occurrences, got %v", got.Upcoming)
+	}
+	if first := got.Upcoming[0]; !first.Equal(start.AddDate(0, 0, 3)) {
+		t.Errorf("Expected the next daily slot after now, got %v", first)
+	}
+
+	if _, err := todoService.GetSeries(context.Background(), 7, 2, 3); !errors.Is(err, apperr.ErrNotFound) {
+		t.Errorf("Expected another user's series to be not found, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+	"github.com/fayzzzm/go-bro/service"
+)
+
+func TestTodoService_GetByUserNestsSubtasks(t *testing.T) {
+	var calls [][]int
+	mockRepo := &MockTodoRepository{
+		GetByUserFunc: func(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error) {
+			return []models.Todo{{ID: 1, SubtasksTotal: 2}, {ID: 2}, {ID: 3, SubtasksTotal: 1}}, nil
+		},
+		ListSubtasksFunc: func(ctx context.Context, userID int, todoIDs []int) ([]models.Subtask, error) {
+			calls = append(calls, todoIDs)
+			return []models.Subtask{
+				{ID: 10, TodoID: 1, Position: 1},
+				{ID: 11, TodoID: 1, Position: 2},
+				{ID: 12, TodoID: 3, Position: 1},
+			}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+
+	res, err := todoService.GetByUser(context.Background(), 1, models.TodoFilter{WithSubtasks: true}, pagination.Page{})
+	if err != nil {
+		t.Fatalf("GetByUser failed: %v", err)
+	}
+	if len(calls) != 1 || len(calls[0]) != 2 || calls[0][0] != 1 || calls[0][1] != 3 {
+		t.Fatalf("Expected one lookup for todos 1 and 3, got %v", calls)
+	}
+	todos := res.Items
+	if len(todos[0].Subtasks) != 2 || todos[0].Subtasks[1].ID != 11 {
+		t.Errorf("Expected todo 1 to carry subtasks 10 and 11, got %+v", todos[0].Subtasks)
+	}
+	if todos[1].Subtasks != nil {
+		t.Errorf("Expected todo 2 without subtasks, got %+v", todos[1].Subtasks)
+	}
+	if len(todos[2].Subtasks) != 1 {
+		t.Errorf("Expected todo 3 to carry one subtask, got %+v", todos[2].Subtasks)
+	}
+
+	calls = nil
+	if _, err := todoService.GetByUser(context.Background(), 1, models.TodoFilter{}, pagination.Page{}); err != nil {
+		t.Fatalf("GetByUser failed: %v", err)
+	}
+	if len(calls) != 0 {
+		t.Errorf("Expected no subtask lookup unless requested, got %v", calls)
+	}
+}
+
+func TestTodoService_UpdateSubtask(t *testing.T) {
+	var got models.SubtaskUpdate
+	mockRepo := &MockTodoRepository{
+		UpdateSubtaskFunc: func(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error) {
+			got = update
+			return &models.Subtask{ID: update.ID}, nil
+		},
+	}
+	todoService := service.NewTodoService(mockRepo, nil)
+	blank, title, done := "  ", "  Buy milk ", true
+
+	testCases := []struct {
+		name    string
+		update  models.SubtaskUpdate
+		wantErr error
+	}{
+		{"nothing to change", models.SubtaskUpdate{ID: 1, TodoID: 2}, service.ErrSubtaskEmpty},
+		{"blank title", models.SubtaskUpdate{ID: 1, TodoID: 2, Title: &blank}, service.ErrSubtaskTitle},
+		{"complete", models.SubtaskUpdate{ID: 1, TodoID: 2, Completed: &done, CompleteParent: true}, nil},
+		{"rename", models.SubtaskUpdate{ID: 1, TodoID: 2, Title: &title}, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := todoService.UpdateSubtask(context.Background(), 1, tc.update); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+	if got.Title == nil || *got.Title != "Buy milk" {
+		t.Errorf("Expected the title trimmed, got %v", got.Title)
+	}
+
+	if _, err := todoService.AddSubtask(context.Background(), 1, 2, " "); !errors.Is(err, service.ErrSubtaskTitle) {
+		t.Errorf("Expected %v for a blank title, got %v", service.ErrSubtaskTitle, err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/service"
 )
 
@@ -18,29 +19,6 @@ type MockUserRepo struct {
 	err   error
 }
 
-func (m *MockUserRepo) RegisterUser(ctx context.Context, name, email string) (*models.User, error) {
-	if m.err != nil {
-		return nil, m.err
-	}
-
-	// Simulate SQL function validation
-	if name == "" {
-		return nil, errors.New("name cannot be empty")
-	}
-	if email == "" || !contains(email, "@") {
-		return nil, errors.New("invalid email address")
-	}
-
-	user := &models.User{
-		ID:        len(m.users) + 1,
-		Name:      name,
-		Email:     email,
-		CreatedAt: time.Now(),
-	}
-	m.users[user.ID] = user
-	return user, nil
-}
-
 func (m *MockUserRepo) GetByID(ctx context.Context, id int) (*models.User, error) {
 	if m.err != nil {
 		return nil, m.err
@@ -52,7 +30,7 @@ func (m *MockUserRepo) GetByID(ctx context.Context, id int) (*models.User, error
 	return user, nil
 }
 
-func (m *MockUserRepo) GetAll(ctx context.Context, limit, offset int) ([]models.User, error) {
+func (m *MockUserRepo) GetAll(ctx context.Context, page pagination.Page) ([]models.User, error) {
 	if m.err != nil {
 		return nil, m.err
 	}
@@ -63,63 +41,29 @@ func (m *MockUserRepo) GetAll(ctx context.Context, limit, offset int) ([]models.
 	return res, nil
 }
 
-// Helper function since we can't import strings in a test mock
-func contains(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
+func (m *MockUserRepo) Count(ctx context.Context) (int, error) {
+	if m.err != nil {
+		return 0, m.err
 	}
-	return false
+	return len(m.users), nil
 }
 
-func TestUserService_RegisterUser(t *testing.T) {
-	ctx := context.Background()
-
-	t.Run("successful registration", func(t *testing.T) {
-		mockRepo := &MockUserRepo{users: make(map[int]*models.User)}
-		svc := service.NewUserService(mockRepo)
-
-		user, err := svc.RegisterUser(ctx, "John Doe", "john@example.com")
-
-		if err != nil {
-			t.Errorf("expected no error, got %v", err)
-		}
-		if user.Name != "John Doe" {
-			t.Errorf("expected name John Doe, got %s", user.Name)
-		}
-		if len(mockRepo.users) != 1 {
-			t.Errorf("expected 1 user in repo, got %d", len(mockRepo.users))
-		}
-	})
-
-	t.Run("invalid email - validation by SQL function", func(t *testing.T) {
-		mockRepo := &MockUserRepo{users: make(map[int]*models.User)}
-		svc := service.NewUserService(mockRepo)
-
-		_, err := svc.RegisterUser(ctx, "John Doe", "invalid-email")
-
-		if err == nil {
-			t.Error("expected error for invalid email, got nil")
-		}
-		if err.Error() != "invalid email address" {
-			t.Errorf("expected 'invalid email address' error, got %v", err)
-		}
-	})
-
-	t.Run("empty name - validation by SQL function", func(t *testing.T) {
-		mockRepo := &MockUserRepo{users: make(map[int]*models.User)}
-		svc := service.NewUserService(mockRepo)
-
-		_, err := svc.RegisterUser(ctx, "", "john@example.com")
+func (m *MockUserRepo) SetDisabled(ctx context.Context, id int, disabled bool) (*models.User, error) {
+	user, ok := m.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	user.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+	return user, nil
+}
 
-		if err == nil {
-			t.Error("expected error for empty name, got nil")
-		}
-		if err.Error() != "name cannot be empty" {
-			t.Errorf("expected 'name cannot be empty' error, got %v", err)
-		}
-	})
+func (m *MockUserRepo) Delete(ctx context.Context, id int) error {
+	delete(m.users, id)
+	return nil
 }
 
 func TestUserService_GetUser(t *testing.T) {
@@ -163,13 +107,44 @@ func TestUserService_ListUsers(t *testing.T) {
 		}}
 		svc := service.NewUserService(mockRepo)
 
-		users, err := svc.ListUsers(ctx, 100, 0)
+		result, err := svc.ListUsers(ctx, pagination.Page{Limit: 100})
 
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if len(users) != 2 {
-			t.Errorf("expected 2 users, got %d", len(users))
+		if len(result.Items) != 2 {
+			t.Errorf("expected 2 users, got %d", len(result.Items))
+		}
+		if result.Total == nil || *result.Total != 2 {
+			t.Errorf("expected total 2, got %v", result.Total)
+		}
+		if result.Next != nil || result.Prev != nil {
+			t.Errorf("expected no cursors on a single page, got next=%v prev=%v", result.Next, result.Prev)
 		}
 	})
 }
+
+func TestUserService_Moderation(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := &MockUserRepo{users: map[int]*models.User{
+		1: {ID: 1, Name: "Admin", Role: models.RoleAdmin},
+		2: {ID: 2, Name: "User", Role: models.RoleUser},
+	}}
+	svc := service.NewUserService(mockRepo)
+
+	user, err := svc.SetUserDisabled(ctx, 1, 2, true)
+	if err != nil || user.DisabledAt == nil {
+		t.Fatalf("expected user 2 to be disabled, got %+v, %v", user, err)
+	}
+
+	if _, err := svc.SetUserDisabled(ctx, 1, 1, true); !errors.Is(err, service.ErrSelfModeration) {
+		t.Errorf("expected ErrSelfModeration when disabling yourself, got %v", err)
+	}
+	if err := svc.DeleteUser(ctx, 1, 1); !errors.Is(err, service.ErrSelfModeration) {
+		t.Errorf("expected ErrSelfModeration when deleting yourself, got %v", err)
+	}
+
+	if err := svc.DeleteUser(ctx, 1, 2); err != nil || len(mockRepo.users) != 1 {
+		t.Errorf("expected user 2 to be deleted, got %v (%d users left)", err, len(mockRepo.users))
+	}
+}
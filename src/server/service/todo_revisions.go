@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// ListRevisions returns the revisions of a todo the user can see, newest
+// first. It pages by limit and offset; cursors are not supported here.
+func (s *TodoService) ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error) {
+	page = page.Normalize()
+	page.Cursor = nil
+	revisions, err := s.repo.ListRevisions(ctx, todoID, userID, page)
+	if err != nil {
+		return nil, err
+	}
+	if revisions == nil {
+		revisions = []models.TodoRevision{}
+	}
+	return revisions, nil
+}
+
+// DiffRevision returns a revision with what changed since: each field that
+// differs, from the revision's value to the todo's current one.
+func (s *TodoService) DiffRevision(ctx context.Context, todoID, userID, revision int) (*models.RevisionDiff, error) {
+	rev, err := s.repo.GetRevision(ctx, todoID, userID, revision)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.repo.GetByID(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.RevisionDiff{Revision: *rev, Changes: revisionChanges(rev, todo)}, nil
+}
+
+// RevertRevision restores a revision's content; the content it replaces is
+// kept as a new revision. A non-zero version must match the todo's.
+func (s *TodoService) RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error) {
+	todo, err := s.repo.RevertRevision(ctx, todoID, userID, revision, version)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoUpdated, todo)
+	return todo, nil
+}
+
+func revisionChanges(rev *models.TodoRevision, todo *models.Todo) map[string]models.FieldChange {
+	changes := map[string]models.FieldChange{}
+	if todo.Title == nil || *todo.Title != rev.Title {
+		changes["title"] = models.FieldChange{From: rev.Title, To: todo.Title}
+	}
+	if !equalStrings(rev.Description, todo.Description) {
+		changes["description"] = models.FieldChange{From: rev.Description, To: todo.Description}
+	}
+	if todo.Completed == nil || *todo.Completed != rev.Completed {
+		changes["completed"] = models.FieldChange{From: rev.Completed, To: todo.Completed}
+	}
+	return changes
+}
+
+func equalStrings(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/rrule"
+)
+
+// Recurrence errors; an invalid rule is reported with the parser's message.
+var (
+	ErrRecurrenceDueAt    = apperr.Validation("a recurring todo needs a due_at")
+	ErrRecurrenceTimezone = apperr.Validation("timezone must be an IANA time zone such as Europe/Berlin")
+	ErrRecurrenceScope    = apperr.Validation("rrule and timezone can only change for this and future occurrences")
+)
+
+// MaxUpcoming caps how many upcoming occurrences GetSeries expands.
+const MaxUpcoming = 50
+
+// UpdateFuture updates this occurrence of a series like Update and changes the
+// series for it and every later occurrence. A new rule, zone or due_at
+// restarts the series here.
+func (s *TodoService) UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	if todo.Priority != nil && !todo.Priority.Valid() {
+		return nil, ErrTodoPriority
+	}
+	if err := setTags(todo); err != nil {
+		return nil, err
+	}
+	if todo.Recurrence != nil {
+		if err := normalizeRecurrence(todo.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	todo, err := s.repo.UpdateFuture(ctx, userID, todo)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoUpdated, todo)
+	return todo, nil
+}
+
+// GetSeries returns a series with up to upcoming occurrences after now.
+func (s *TodoService) GetSeries(ctx context.Context, seriesID, userID, upcoming int) (*models.TodoSeries, error) {
+	series, err := s.repo.GetSeries(ctx, seriesID, userID)
+	if err != nil {
+		return nil, err
+	}
+	rule, loc, err := parseSeries(series)
+	if err != nil {
+		return nil, err
+	}
+
+	series.Upcoming = []time.Time{}
+	now := time.Now()
+	it := rule.Iterator(series.DTStart.In(loc))
+	for len(series.Upcoming) < min(upcoming, MaxUpcoming) {
+		t, ok := it.Next()
+		if !ok {
+			break
+		}
+		if t.After(now) {
+			series.Upcoming = append(series.Upcoming, t)
+		}
+	}
+	return series, nil
+}
+
+func (s *TodoService) DeleteSeries(ctx context.Context, seriesID, userID int) error {
+	return s.repo.DeleteSeries(ctx, seriesID, userID)
+}
+
+// nextOccurrence is where toggling todoID should add the next occurrence: nil
+// unless that completes an occurrence of a live series. The next one follows
+// the latest of the todo's slot, its due date and now, so a late completion
+// does not leave a trail of overdue todos and a postponed one is not doubled.
+func (s *TodoService) nextOccurrence(ctx context.Context, todoID, userID int) (*time.Time, error) {
+	todo, err := s.repo.GetByID(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if todo.SeriesID == nil || (todo.Completed != nil && *todo.Completed) {
+		return nil, nil
+	}
+
+	// Shared editors complete occurrences too; the series is the owner's
+	series, err := s.repo.GetSeries(ctx, *todo.SeriesID, todo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	rule, loc, err := parseSeries(series)
+	if err != nil {
+		return nil, err
+	}
+
+	after := time.Now()
+	for _, t := range []*time.Time{todo.OccurrenceAt, todo.DueAt} {
+		if t != nil && t.After(after) {
+			after = *t
+		}
+	}
+	next, ok := rule.After(series.DTStart.In(loc), after)
+	if !ok {
+		return nil, nil
+	}
+	return &next, nil
+}
+
+// normalizeRecurrence validates the given parts of rec and rewrites the rule in
+// canonical form.
+func normalizeRecurrence(rec *models.Recurrence) error {
+	if rec.RRule != "" {
+		rule, err := rrule.Parse(rec.RRule)
+		if err != nil {
+			return apperr.Wrap(apperr.KindValidation, err.Error(), err)
+		}
+		rec.RRule = rule.String()
+	}
+	if rec.Timezone != "" {
+		if _, err := loadLocation(rec.Timezone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSeries reads the stored rule and zone; they were validated on write.
+func parseSeries(series *models.TodoSeries) (*rrule.Rule, *time.Location, error) {
+	rule, err := rrule.Parse(series.RRule)
+	if err != nil {
+		return nil, nil, err
+	}
+	loc, err := loadLocation(series.Timezone)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rule, loc, nil
+}
+
+// loadLocation accepts IANA names only, not the server's "Local".
+func loadLocation(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, ErrRecurrenceTimezone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrRecurrenceTimezone
+	}
+	return loc, nil
+}
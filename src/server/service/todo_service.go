@@ -2,62 +2,324 @@ package service
 
 import (
 	"context"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
+)
+
+// ErrCursorSort is returned when a cursor is combined with a non-default sort.
+var ErrCursorSort = apperr.Validation("cursor pagination requires the default created_at descending sort")
+
+// ErrTodoPriority is returned for a priority other than low, medium, high or urgent.
+var ErrTodoPriority = apperr.Validation("priority must be low, medium, high or urgent")
+
+// Move errors: a todo is moved next to at least one other todo.
+var (
+	ErrMoveTarget = apperr.Validation("before_id or after_id is required")
+	ErrMoveSelf   = apperr.Validation("before_id and after_id must be two other todos")
+)
+
+// Patch errors: title, completed and priority cannot be cleared.
+var (
+	ErrPatchTitle     = apperr.Validation("title cannot be removed or empty")
+	ErrPatchCompleted = apperr.Validation("completed cannot be removed")
+	ErrPatchPriority  = apperr.Validation("priority cannot be removed")
 )
 
 type TodoRepository interface {
-	Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error)
-	GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error)
+	Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) ([]models.Todo, error)
+	Count(ctx context.Context, userID int, filter models.TodoFilter) (int, error)
 	GetByID(ctx context.Context, todoID, userID int) (*models.Todo, error)
 	Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error)
 	Delete(ctx context.Context, todoID, userID int) error
-	Toggle(ctx context.Context, todoID, userID int) (*models.Todo, error)
+	Toggle(ctx context.Context, todoID, userID, version int, next *time.Time) (*models.Todo, error)
+	Restore(ctx context.Context, todoID, userID int) (*models.Todo, error)
+	Purge(ctx context.Context, todoID, userID int) error
+	Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error)
+	UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetSeries(ctx context.Context, seriesID, userID int) (*models.TodoSeries, error)
+	DeleteSeries(ctx context.Context, seriesID, userID int) error
+	ListSubtasks(ctx context.Context, userID int, todoIDs []int) ([]models.Subtask, error)
+	AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error)
+	UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error)
+	DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error
+	ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error)
+	Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error)
+	ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error)
+	GetRevision(ctx context.Context, todoID, userID, revision int) (*models.TodoRevision, error)
+	RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error)
 }
 
 type TodoServicer interface {
-	Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error)
-	GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error)
+	Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) (*pagination.Result[models.Todo], error)
 	GetByID(ctx context.Context, todoID, userID int) (*models.Todo, error)
 	Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error)
 	Delete(ctx context.Context, todoID, userID int) error
-	Toggle(ctx context.Context, todoID, userID int) (*models.Todo, error)
+	Toggle(ctx context.Context, todoID, userID, version int) (*models.Todo, error)
+	Restore(ctx context.Context, todoID, userID int) (*models.Todo, error)
+	Purge(ctx context.Context, todoID, userID int) error
+	Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error)
+	UpdateFuture(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error)
+	GetSeries(ctx context.Context, seriesID, userID, upcoming int) (*models.TodoSeries, error)
+	DeleteSeries(ctx context.Context, seriesID, userID int) error
+	ListSubtasks(ctx context.Context, todoID, userID int) ([]models.Subtask, error)
+	AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error)
+	UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error)
+	DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error
+	ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error)
+	Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error)
+	ListRevisions(ctx context.Context, todoID, userID int, page pagination.Page) ([]models.TodoRevision, error)
+	DiffRevision(ctx context.Context, todoID, userID, revision int) (*models.RevisionDiff, error)
+	RevertRevision(ctx context.Context, todoID, userID, revision, version int) (*models.Todo, error)
+}
+
+// EventPublisher is implemented by events.Bus.
+type EventPublisher interface {
+	Publish(ctx context.Context, event models.TodoEvent) error
 }
 
 type TodoService struct {
-	repo TodoRepository
+	repo   TodoRepository
+	events EventPublisher
 }
 
-func NewTodoService(repo TodoRepository) *TodoService {
-	return &TodoService{repo: repo}
+// NewTodoService publishes every change to events; a nil publisher publishes nothing.
+func NewTodoService(repo TodoRepository, events EventPublisher) *TodoService {
+	return &TodoService{repo: repo, events: events}
 }
 
-func (s *TodoService) Create(ctx context.Context, userID int, title string, description *string) (*models.Todo, error) {
-	return s.repo.Create(ctx, userID, title, description)
+func (s *TodoService) Create(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
+	if todo.Priority != nil && !todo.Priority.Valid() {
+		return nil, ErrTodoPriority
+	}
+	if err := setTags(todo); err != nil {
+		return nil, err
+	}
+	if todo.Recurrence != nil {
+		if todo.DueAt == nil {
+			return nil, ErrRecurrenceDueAt
+		}
+		if err := normalizeRecurrence(todo.Recurrence); err != nil {
+			return nil, err
+		}
+	}
+	todo, err := s.repo.Create(ctx, userID, todo)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoCreated, todo)
+	return todo, nil
 }
 
-func (s *TodoService) GetByUser(ctx context.Context, userID int, limit, offset int) ([]models.Todo, error) {
-	if limit <= 0 {
-		limit = 100
+func (s *TodoService) GetByUser(ctx context.Context, userID int, filter models.TodoFilter, page pagination.Page) (*pagination.Result[models.Todo], error) {
+	page = page.Normalize()
+	if page.Cursor != nil && !filter.DefaultOrder() {
+		return nil, ErrCursorSort
 	}
-	if offset < 0 {
-		offset = 0
+	filter.Tags = tagFilter(filter.Tags)
+
+	todos, err := s.repo.GetByUser(ctx, userID, filter, page.Probe())
+	if err != nil {
+		return nil, err
+	}
+	res := pagination.Window(todos, page, todoCursor)
+	if filter.WithSubtasks {
+		if err := s.attachSubtasks(ctx, userID, res.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	// Cursors encode created_at, so other orders page by offset only
+	if !filter.DefaultOrder() {
+		res.Next, res.Prev = nil, nil
 	}
-	return s.repo.GetByUser(ctx, userID, limit, offset)
+
+	if !page.SkipTotal {
+		total, err := s.repo.Count(ctx, userID, filter)
+		if err != nil {
+			return nil, err
+		}
+		res.Total = &total
+	}
+	return &res, nil
+}
+
+func todoCursor(t models.Todo) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
 }
 
 func (s *TodoService) GetByID(ctx context.Context, todoID, userID int) (*models.Todo, error) {
 	return s.repo.GetByID(ctx, todoID, userID)
 }
 
+// Update changes one todo; for a series occurrence, only this one.
 func (s *TodoService) Update(ctx context.Context, userID int, todo *models.Todo) (*models.Todo, error) {
-	return s.repo.Update(ctx, userID, todo)
+	if todo.Priority != nil && !todo.Priority.Valid() {
+		return nil, ErrTodoPriority
+	}
+	if todo.Recurrence != nil {
+		return nil, ErrRecurrenceScope
+	}
+	if err := setTags(todo); err != nil {
+		return nil, err
+	}
+	todo, err := s.repo.Update(ctx, userID, todo)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoUpdated, todo)
+	return todo, nil
+}
+
+// Patch applies a merge patch after checking it leaves the todo valid.
+func (s *TodoService) Patch(ctx context.Context, userID int, patch models.TodoPatch) (*models.Todo, error) {
+	if patch.Title.IsNull() || (patch.Title.Set && strings.TrimSpace(*patch.Title.Value) == "") {
+		return nil, ErrPatchTitle
+	}
+	if patch.Completed.IsNull() {
+		return nil, ErrPatchCompleted
+	}
+	if patch.Priority.IsNull() {
+		return nil, ErrPatchPriority
+	}
+	if patch.Priority.Set && !patch.Priority.Value.Valid() {
+		return nil, ErrTodoPriority
+	}
+	if patch.Tags.Set && patch.Tags.Value != nil {
+		tags, err := normalizeTags(*patch.Tags.Value)
+		if err != nil {
+			return nil, err
+		}
+		patch.Tags.Value = &tags
+	}
+	todo, err := s.repo.Patch(ctx, userID, patch)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoUpdated, todo)
+	return todo, nil
 }
 
+// Delete moves a todo to the trash; only its owner may.
 func (s *TodoService) Delete(ctx context.Context, todoID, userID int) error {
-	return s.repo.Delete(ctx, todoID, userID)
+	if err := s.repo.Delete(ctx, todoID, userID); err != nil {
+		return err
+	}
+	s.publish(ctx, models.TodoEvent{Type: models.TodoDeleted, UserID: userID, TodoID: todoID})
+	return nil
+}
+
+// Toggle flips completed. Completing an occurrence of a series adds the next
+// one in the same statement; batch toggles do not.
+func (s *TodoService) Toggle(ctx context.Context, todoID, userID, version int) (*models.Todo, error) {
+	next, err := s.nextOccurrence(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	todo, err := s.repo.Toggle(ctx, todoID, userID, version, next)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoToggled, todo)
+	return todo, nil
+}
+
+// Move places a todo in its owner's manual order, listed with sort=position.
+func (s *TodoService) Move(ctx context.Context, userID int, move models.TodoMove) (*models.Todo, error) {
+	before, after := move.BeforeID, move.AfterID
+	if before == nil && after == nil {
+		return nil, ErrMoveTarget
+	}
+	if before != nil && *before == move.ID || after != nil && *after == move.ID || before != nil && after != nil && *before == *after {
+		return nil, ErrMoveSelf
+	}
+	todo, err := s.repo.Move(ctx, userID, move)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoUpdated, todo)
+	return todo, nil
+}
+
+// Restore brings a todo back from the trash; streams see it created again.
+func (s *TodoService) Restore(ctx context.Context, todoID, userID int) (*models.Todo, error) {
+	todo, err := s.repo.Restore(ctx, todoID, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.publishTodo(ctx, models.TodoCreated, todo)
+	return todo, nil
+}
+
+func (s *TodoService) Purge(ctx context.Context, todoID, userID int) error {
+	return s.repo.Purge(ctx, todoID, userID)
 }
 
-func (s *TodoService) Toggle(ctx context.Context, todoID, userID int) (*models.Todo, error) {
-	return s.repo.Toggle(ctx, todoID, userID)
+// Batch rejects the whole request when an operation's tags are invalid.
+func (s *TodoService) Batch(ctx context.Context, userID int, ops []models.TodoBatchOp, atomic bool) ([]models.TodoBatchResult, error) {
+	for i := range ops {
+		if err := setTags(&ops[i].Todo); err != nil {
+			return nil, err
+		}
+	}
+	results, err := s.repo.Batch(ctx, userID, ops, atomic)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range results {
+		if res.Status != models.BatchStatusOK {
+			continue
+		}
+		if res.Op == models.BatchDelete {
+			s.publish(ctx, models.TodoEvent{Type: models.TodoDeleted, UserID: userID, TodoID: ops[res.Index].Todo.ID})
+		} else {
+			s.publishTodo(ctx, batchEvents[res.Op], res.Todo)
+		}
+	}
+	return results, nil
+}
+
+// batchEvents maps the batch operations that return a todo to their events.
+var batchEvents = map[models.BatchOp]models.TodoEventType{
+	models.BatchCreate: models.TodoCreated,
+	models.BatchUpdate: models.TodoUpdated,
+	models.BatchToggle: models.TodoToggled,
+}
+
+// publishTodo publishes a change that left the todo as given. Events of a
+// todo go to its owner, whoever made the change.
+func (s *TodoService) publishTodo(ctx context.Context, t models.TodoEventType, todo *models.Todo) {
+	if todo == nil {
+		return
+	}
+	s.publish(ctx, models.TodoEvent{Type: t, UserID: todo.UserID, TodoID: todo.ID, Todo: todo})
+}
+
+// publish tells the owner's streams about a change. The change is already
+// made, so a failure is only logged, and a canceled request still publishes.
+func (s *TodoService) publish(ctx context.Context, event models.TodoEvent) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(context.WithoutCancel(ctx), event); err != nil {
+		log.Printf("⚠️ Warning: %s event for todo %d not published: %v", event.Type, event.TodoID, err)
+	}
+}
+
+// setTags normalizes the tags a todo is given.
+func setTags(todo *models.Todo) error {
+	tags, err := normalizeTags(todo.Tags)
+	if err != nil {
+		return err
+	}
+	todo.Tags = tags
+	return nil
 }
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+)
+
+// Subtask errors.
+var (
+	ErrSubtaskTitle = apperr.Validation("subtask title cannot be empty")
+	ErrSubtaskEmpty = apperr.Validation("set title or completed")
+)
+
+// ListSubtasks returns the checklist of a todo the user can see.
+func (s *TodoService) ListSubtasks(ctx context.Context, todoID, userID int) ([]models.Subtask, error) {
+	if _, err := s.repo.GetByID(ctx, todoID, userID); err != nil {
+		return nil, err
+	}
+	subtasks, err := s.repo.ListSubtasks(ctx, userID, []int{todoID})
+	if err != nil {
+		return nil, err
+	}
+	if subtasks == nil {
+		subtasks = []models.Subtask{}
+	}
+	return subtasks, nil
+}
+
+// AddSubtask appends a subtask to a todo's checklist.
+func (s *TodoService) AddSubtask(ctx context.Context, userID, todoID int, title string) (*models.Subtask, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, ErrSubtaskTitle
+	}
+	return s.repo.AddSubtask(ctx, userID, todoID, title)
+}
+
+// UpdateSubtask renames, completes or reopens a subtask.
+func (s *TodoService) UpdateSubtask(ctx context.Context, userID int, update models.SubtaskUpdate) (*models.Subtask, error) {
+	if update.Title == nil && update.Completed == nil {
+		return nil, ErrSubtaskEmpty
+	}
+	if update.Title != nil {
+		title := strings.TrimSpace(*update.Title)
+		if title == "" {
+			return nil, ErrSubtaskTitle
+		}
+		update.Title = &title
+	}
+	return s.repo.UpdateSubtask(ctx, userID, update)
+}
+
+func (s *TodoService) DeleteSubtask(ctx context.Context, userID, todoID, subtaskID int) error {
+	return s.repo.DeleteSubtask(ctx, userID, todoID, subtaskID)
+}
+
+// ReorderSubtasks puts a todo's checklist in the order of ids.
+func (s *TodoService) ReorderSubtasks(ctx context.Context, userID, todoID int, ids []int) ([]models.Subtask, error) {
+	return s.repo.ReorderSubtasks(ctx, userID, todoID, ids)
+}
+
+// attachSubtasks nests the subtasks of todos with one query for the whole page.
+func (s *TodoService) attachSubtasks(ctx context.Context, userID int, todos []models.Todo) error {
+	ids := make([]int, 0, len(todos))
+	for _, t := range todos {
+		if t.SubtasksTotal > 0 {
+			ids = append(ids, t.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	subtasks, err := s.repo.ListSubtasks(ctx, userID, ids)
+	if err != nil {
+		return err
+	}
+	byTodo := make(map[int][]models.Subtask, len(ids))
+	for _, st := range subtasks {
+		byTodo[st.TodoID] = append(byTodo[st.TodoID], st)
+	}
+	for i := range todos {
+		todos[i].Subtasks = byTodo[todos[i].ID]
+	}
+	return nil
+}
@@ -4,26 +4,36 @@ import (
 	"context"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 )
 
+// ErrSelfModeration stops admins from locking themselves out.
+var ErrSelfModeration = apperr.Validation("admins cannot disable or delete their own account")
+
 // UserRepository defines the contract for user data access.
 // In Hexagonal Architecture, this is an "Output Port".
 // The repository now calls SQL functions for all operations.
 type UserRepository interface {
-	// RegisterUser calls the SQL function fn_register_user
-	RegisterUser(ctx context.Context, name, email string) (*models.User, error)
 	// GetByID calls the SQL function fn_get_user_by_id
 	GetByID(ctx context.Context, id int) (*models.User, error)
 	// GetAll calls the SQL function fn_list_users
-	GetAll(ctx context.Context, limit, offset int) ([]models.User, error)
+	GetAll(ctx context.Context, page pagination.Page) ([]models.User, error)
+	// Count calls the SQL function users.count
+	Count(ctx context.Context) (int, error)
+	// SetDisabled calls the SQL function users.set_disabled
+	SetDisabled(ctx context.Context, id int, disabled bool) (*models.User, error)
+	// Delete calls the SQL function users.delete
+	Delete(ctx context.Context, id int) error
 }
 
 // UserServicer is the interface that use cases depend on.
 // This is the Input Port for the service layer.
 type UserServicer interface {
-	RegisterUser(ctx context.Context, name, email string) (*models.User, error)
 	GetUser(ctx context.Context, id int) (*models.User, error)
-	ListUsers(ctx context.Context, limit, offset int) ([]models.User, error)
+	ListUsers(ctx context.Context, page pagination.Page) (*pagination.Result[models.User], error)
+	SetUserDisabled(ctx context.Context, actorID, id int, disabled bool) (*models.User, error)
+	DeleteUser(ctx context.Context, actorID, id int) error
 }
 
 // UserService is a thin wrapper that delegates to the repository.
@@ -36,15 +46,44 @@ func NewUserService(repo UserRepository) *UserService {
 	return &UserService{repo: repo}
 }
 
-func (s *UserService) RegisterUser(ctx context.Context, name, email string) (*models.User, error) {
-	// All validation and business logic is now in the SQL function fn_register_user
-	return s.repo.RegisterUser(ctx, name, email)
-}
-
 func (s *UserService) GetUser(ctx context.Context, id int) (*models.User, error) {
 	return s.repo.GetByID(ctx, id)
 }
 
-func (s *UserService) ListUsers(ctx context.Context, limit, offset int) ([]models.User, error) {
-	return s.repo.GetAll(ctx, limit, offset)
+func (s *UserService) ListUsers(ctx context.Context, page pagination.Page) (*pagination.Result[models.User], error) {
+	page = page.Normalize()
+	users, err := s.repo.GetAll(ctx, page.Probe())
+	if err != nil {
+		return nil, err
+	}
+	res := pagination.Window(users, page, userCursor)
+
+	if !page.SkipTotal {
+		total, err := s.repo.Count(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res.Total = &total
+	}
+	return &res, nil
+}
+
+// SetUserDisabled disables a user, revoking their sessions, or re-enables them.
+func (s *UserService) SetUserDisabled(ctx context.Context, actorID, id int, disabled bool) (*models.User, error) {
+	if disabled && actorID == id {
+		return nil, ErrSelfModeration
+	}
+	return s.repo.SetDisabled(ctx, id, disabled)
+}
+
+// DeleteUser removes a user and everything they own.
+func (s *UserService) DeleteUser(ctx context.Context, actorID, id int) error {
+	if actorID == id {
+		return ErrSelfModeration
+	}
+	return s.repo.Delete(ctx, id)
+}
+
+func userCursor(u models.User) pagination.Cursor {
+	return pagination.Cursor{CreatedAt: u.CreatedAt, ID: u.ID}
 }
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/usecase/users"
 )
 
@@ -14,26 +16,9 @@ import (
 type MockUserService struct {
 	users map[int]*models.User
 	err   error
-}
-
-func (m *MockUserService) RegisterUser(ctx context.Context, name, email string) (*models.User, error) {
-	if m.err != nil {
-		return nil, m.err
-	}
 
-	// Simulate service behavior
-	if name == "" {
-		return nil, errors.New("name cannot be empty")
-	}
-
-	user := &models.User{
-		ID:        len(m.users) + 1,
-		Name:      name,
-		Email:     email,
-		CreatedAt: time.Now(),
-	}
-	m.users[user.ID] = user
-	return user, nil
+	next     *pagination.Cursor
+	lastPage pagination.Page
 }
 
 func (m *MockUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
@@ -47,63 +32,49 @@ func (m *MockUserService) GetUser(ctx context.Context, id int) (*models.User, er
 	return user, nil
 }
 
-func (m *MockUserService) ListUsers(ctx context.Context, limit, offset int) ([]models.User, error) {
+func (m *MockUserService) ListUsers(ctx context.Context, page pagination.Page) (*pagination.Result[models.User], error) {
 	if m.err != nil {
 		return nil, m.err
 	}
+	m.lastPage = page
 	var res []models.User
 	for _, u := range m.users {
 		res = append(res, *u)
 	}
-	return res, nil
+	result := &pagination.Result[models.User]{Items: res, Next: m.next}
+	if !page.SkipTotal {
+		total := len(m.users)
+		result.Total = &total
+	}
+	return result, nil
 }
 
-func TestUserUseCase_RegisterUser(t *testing.T) {
-	ctx := context.Background()
-
-	t.Run("successful registration returns proper output DTO", func(t *testing.T) {
-		mockSvc := &MockUserService{users: make(map[int]*models.User)}
-		uc := users.NewUseCase(mockSvc)
-
-		input := users.RegisterUserInput{Name: "John Doe", Email: "john@example.com"}
-		output, err := uc.RegisterUser(ctx, input)
-
-		if err != nil {
-			t.Errorf("expected no error, got %v", err)
-		}
-		if !output.Success {
-			t.Error("expected Success to be true")
-		}
-		if output.User == nil {
-			t.Error("expected User to not be nil")
-		}
-		if output.User.Name != "John Doe" {
-			t.Errorf("expected name John Doe, got %s", output.User.Name)
-		}
-		if output.Message != "User registered successfully" {
-			t.Errorf("expected success message, got %s", output.Message)
-		}
-	})
-
-	t.Run("failed registration returns error in output", func(t *testing.T) {
-		mockSvc := &MockUserService{users: make(map[int]*models.User)}
-		uc := users.NewUseCase(mockSvc)
-
-		input := users.RegisterUserInput{Name: "", Email: "john@example.com"}
-		output, err := uc.RegisterUser(ctx, input)
+func (m *MockUserService) SetUserDisabled(ctx context.Context, actorID, id int, disabled bool) (*models.User, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	user, ok := m.users[id]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	user.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		user.DisabledAt = &now
+	}
+	return user, nil
+}
 
-		if err == nil {
-			t.Error("expected error, got nil")
-		}
-		if output.Success {
-			t.Error("expected Success to be false")
-		}
-		if output.User != nil {
-			t.Error("expected User to be nil on failure")
-		}
-	})
+func (m *MockUserService) DeleteUser(ctx context.Context, actorID, id int) error {
+	if m.err != nil {
+		return m.err
+	}
+	delete(m.users, id)
+	return nil
 }
 
+var testCursors = pagination.NewCodec([]byte("test-secret"))
+
 func TestUserUseCase_GetUser(t *testing.T) {
 	ctx := context.Background()
 
@@ -111,9 +82,9 @@ func TestUserUseCase_GetUser(t *testing.T) {
 		mockSvc := &MockUserService{users: map[int]*models.User{
 			1: {ID: 1, Name: "Test User", Email: "test@test.com", CreatedAt: time.Now()},
 		}}
-		uc := users.NewUseCase(mockSvc)
+		uc := users.NewUseCase(mockSvc, testCursors)
 
-		input := users.GetUserInput{ID: 1}
+		input := users.GetUserInput{ID: 1, RequesterID: 1, RequesterRole: models.RoleUser}
 		output, err := uc.GetUser(ctx, input)
 
 		if err != nil {
@@ -129,9 +100,9 @@ func TestUserUseCase_GetUser(t *testing.T) {
 
 	t.Run("user not found returns output with Found=false", func(t *testing.T) {
 		mockSvc := &MockUserService{users: make(map[int]*models.User)}
-		uc := users.NewUseCase(mockSvc)
+		uc := users.NewUseCase(mockSvc, testCursors)
 
-		input := users.GetUserInput{ID: 999}
+		input := users.GetUserInput{ID: 999, RequesterID: 1, RequesterRole: models.RoleAdmin}
 		output, err := uc.GetUser(ctx, input)
 
 		if err == nil {
@@ -141,6 +112,38 @@ func TestUserUseCase_GetUser(t *testing.T) {
 			t.Error("expected Found to be false")
 		}
 	})
+
+	t.Run("users cannot read other profiles, admins can", func(t *testing.T) {
+		mockSvc := &MockUserService{users: map[int]*models.User{
+			2: {ID: 2, Name: "Other", Email: "other@test.com", CreatedAt: time.Now()},
+		}}
+		uc := users.NewUseCase(mockSvc, testCursors)
+
+		_, err := uc.GetUser(ctx, users.GetUserInput{ID: 2, RequesterID: 1, RequesterRole: models.RoleUser})
+		if !errors.Is(err, users.ErrProfileForbidden) {
+			t.Errorf("expected ErrProfileForbidden, got %v", err)
+		}
+
+		output, err := uc.GetUser(ctx, users.GetUserInput{ID: 2, RequesterID: 1, RequesterRole: models.RoleAdmin})
+		if err != nil || !output.Found {
+			t.Errorf("expected admins to read any profile, got %v", err)
+		}
+	})
+}
+
+func TestUserUseCase_SetUserDisabled(t *testing.T) {
+	mockSvc := &MockUserService{users: map[int]*models.User{
+		2: {ID: 2, Name: "User", Email: "user@test.com", CreatedAt: time.Now()},
+	}}
+	uc := users.NewUseCase(mockSvc, testCursors)
+
+	output, err := uc.SetUserDisabled(context.Background(), users.SetUserDisabledInput{ActorID: 1, ID: 2, Disabled: true})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if output.User.DisabledAt == nil || output.Message != "User disabled and signed out" {
+		t.Errorf("expected a disabled user, got %+v", output)
+	}
 }
 
 func TestUserUseCase_ListUsers(t *testing.T) {
@@ -151,7 +154,7 @@ func TestUserUseCase_ListUsers(t *testing.T) {
 			1: {ID: 1, Name: "User 1", Email: "user1@test.com", CreatedAt: time.Now()},
 			2: {ID: 2, Name: "User 2", Email: "user2@test.com", CreatedAt: time.Now()},
 		}}
-		uc := users.NewUseCase(mockSvc)
+		uc := users.NewUseCase(mockSvc, testCursors)
 
 		input := users.ListUsersInput{} // Empty uses defaults
 		output, err := uc.ListUsers(ctx, input)
@@ -159,17 +162,35 @@ func TestUserUseCase_ListUsers(t *testing.T) {
 		if err != nil {
 			t.Errorf("expected no error, got %v", err)
 		}
-		if output.Total != 2 {
-			t.Errorf("expected Total 2, got %d", output.Total)
+		if output.Total == nil || *output.Total != 2 {
+			t.Errorf("expected Total 2, got %v", output.Total)
+		}
+		if output.Count != 2 {
+			t.Errorf("expected Count 2, got %d", output.Count)
 		}
 		if len(output.Users) != 2 {
 			t.Errorf("expected 2 users, got %d", len(output.Users))
 		}
 	})
 
+	t.Run("omits the total when skipped", func(t *testing.T) {
+		mockSvc := &MockUserService{users: map[int]*models.User{
+			1: {ID: 1, Name: "User 1", Email: "user1@test.com", CreatedAt: time.Now()},
+		}}
+		uc := users.NewUseCase(mockSvc, testCursors)
+
+		output, err := uc.ListUsers(ctx, users.ListUsersInput{SkipTotal: true})
+		if err != nil {
+			t.Errorf("expected no error, got %v", err)
+		}
+		if !mockSvc.lastPage.SkipTotal || output.Total != nil {
+			t.Errorf("expected the count to be skipped, got total %v", output.Total)
+		}
+	})
+
 	t.Run("applies default limit when zero", func(t *testing.T) {
 		mockSvc := &MockUserService{users: make(map[int]*models.User)}
-		uc := users.NewUseCase(mockSvc)
+		uc := users.NewUseCase(mockSvc, testCursors)
 
 		input := users.ListUsersInput{Limit: 0, Offset: -5}
 		_, err := uc.ListUsers(ctx, input)
@@ -179,4 +200,36 @@ func TestUserUseCase_ListUsers(t *testing.T) {
 			t.Errorf("expected no error with default limits, got %v", err)
 		}
 	})
+
+	t.Run("round-trips the next cursor", func(t *testing.T) {
+		next := &pagination.Cursor{CreatedAt: time.Now().UTC().Truncate(time.Microsecond), ID: 7, Dir: pagination.Next}
+		mockSvc := &MockUserService{users: make(map[int]*models.User), next: next}
+		uc := users.NewUseCase(mockSvc, testCursors)
+
+		output, err := uc.ListUsers(ctx, users.ListUsersInput{Limit: 1})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if output.NextCursor == "" || output.PrevCursor != "" {
+			t.Fatalf("expected only next_cursor, got next=%q prev=%q", output.NextCursor, output.PrevCursor)
+		}
+
+		if _, err := uc.ListUsers(ctx, users.ListUsersInput{Cursor: output.NextCursor}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got := mockSvc.lastPage.Cursor
+		if got == nil || got.ID != next.ID || !got.CreatedAt.Equal(next.CreatedAt) || got.Dir != pagination.Next {
+			t.Errorf("expected cursor %+v, got %+v", next, got)
+		}
+	})
+
+	t.Run("rejects a tampered cursor", func(t *testing.T) {
+		mockSvc := &MockUserService{users: make(map[int]*models.User)}
+		uc := users.NewUseCase(mockSvc, testCursors)
+
+		_, err := uc.ListUsers(ctx, users.ListUsersInput{Cursor: "eyJ0IjoxfQ.bm9wZQ"})
+		if apperr.KindOf(err) != apperr.KindValidation {
+			t.Errorf("expected validation error, got %v", err)
+		}
+	})
 }
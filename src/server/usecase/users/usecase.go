@@ -4,47 +4,76 @@ import (
 	"context"
 	"time"
 
+	"github.com/fayzzzm/go-bro/models"
+	"github.com/fayzzzm/go-bro/pkg/apperr"
+	"github.com/fayzzzm/go-bro/pkg/pagination"
 	"github.com/fayzzzm/go-bro/service"
 )
 
+// ErrProfileForbidden is returned when a non-admin asks for someone else's profile.
+var ErrProfileForbidden = apperr.Forbidden("you can only view your own profile")
+
 // --- Interfaces ---
 
 // UseCase defines the contract for user-related use cases.
 type UseCase interface {
-	RegisterUser(ctx context.Context, input RegisterUserInput) (*RegisterUserOutput, error)
 	GetUser(ctx context.Context, input GetUserInput) (*GetUserOutput, error)
 	ListUsers(ctx context.Context, input ListUsersInput) (*ListUsersOutput, error)
+	SetUserDisabled(ctx context.Context, input SetUserDisabledInput) (*SetUserDisabledOutput, error)
+	DeleteUser(ctx context.Context, input DeleteUserInput) error
 }
 
 // --- DTOs ---
 
 // Input DTOs
-type RegisterUserInput struct {
-	Name  string `json:"name"`
-	Email string `json:"email"`
-}
 
+// GetUserInput carries who is asking: only admins may read other users.
 type GetUserInput struct {
-	ID int `json:"id"`
+	ID            int         `json:"id"`
+	RequesterID   int         `json:"-"`
+	RequesterRole models.Role `json:"-"`
+}
+
+// SetUserDisabledInput is an admin disabling or re-enabling the user ID.
+type SetUserDisabledInput struct {
+	ActorID  int  `json:"-"`
+	ID       int  `json:"id"`
+	Disabled bool `json:"disabled"`
 }
 
+// DeleteUserInput is an admin deleting the user ID.
+type DeleteUserInput struct {
+	ActorID int `json:"-"`
+	ID      int `json:"id"`
+}
+
+// ListUsersInput pages by Cursor when set, otherwise by Limit/Offset.
 type ListUsersInput struct {
-	Limit  int `json:"limit"`
-	Offset int `json:"offset"`
+	Limit     int    `json:"limit"`
+	Offset    int    `json:"offset"`
+	Cursor    string `json:"cursor"`
+	SkipTotal bool   `json:"skip_total"`
 }
 
 // Output DTOs
 type UserOutput struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         int         `json:"id"`
+	Name       string      `json:"name"`
+	Email      string      `json:"email"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Role       models.Role `json:"role"`
+	DisabledAt *time.Time  `json:"disabled_at,omitempty"`
 }
 
-type RegisterUserOutput struct {
-	User    *UserOutput `json:"user"`
-	Success bool        `json:"success"`
-	Message string      `json:"message,omitempty"`
+func newUserOutput(u *models.User) *UserOutput {
+	return &UserOutput{
+		ID:         u.ID,
+		Name:       u.Name,
+		Email:      u.Email,
+		CreatedAt:  u.CreatedAt,
+		Role:       u.Role,
+		DisabledAt: u.DisabledAt,
+	}
 }
 
 type GetUserOutput struct {
@@ -52,43 +81,36 @@ type GetUserOutput struct {
 	Found bool        `json:"found"`
 }
 
+type SetUserDisabledOutput struct {
+	User    *UserOutput `json:"user"`
+	Message string      `json:"message"`
+}
+
+// ListUsersOutput.Total counts all users, not just this page; nil when skipped.
 type ListUsersOutput struct {
-	Users []UserOutput `json:"users"`
-	Total int          `json:"total"`
+	Users      []UserOutput `json:"users"`
+	Count      int          `json:"count"`
+	Total      *int         `json:"total,omitempty"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+	PrevCursor string       `json:"prev_cursor,omitempty"`
 }
 
 // --- Implementation ---
 
 type UseCaseImpl struct {
 	userService service.UserServicer
+	cursors     *pagination.Codec
 }
 
-func NewUseCase(svc service.UserServicer) *UseCaseImpl {
-	return &UseCaseImpl{userService: svc}
+func NewUseCase(svc service.UserServicer, cursors *pagination.Codec) *UseCaseImpl {
+	return &UseCaseImpl{userService: svc, cursors: cursors}
 }
 
-func (uc *UseCaseImpl) RegisterUser(ctx context.Context, input RegisterUserInput) (*RegisterUserOutput, error) {
-	user, err := uc.userService.RegisterUser(ctx, input.Name, input.Email)
-	if err != nil {
-		return &RegisterUserOutput{
-			Success: false,
-			Message: err.Error(),
-		}, err
+func (uc *UseCaseImpl) GetUser(ctx context.Context, input GetUserInput) (*GetUserOutput, error) {
+	if input.RequesterRole != models.RoleAdmin && input.RequesterID != input.ID {
+		return &GetUserOutput{Found: false}, ErrProfileForbidden
 	}
 
-	return &RegisterUserOutput{
-		User: &UserOutput{
-			ID:        user.ID,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
-		Success: true,
-		Message: "User registered successfully",
-	}, nil
-}
-
-func (uc *UseCaseImpl) GetUser(ctx context.Context, input GetUserInput) (*GetUserOutput, error) {
 	user, err := uc.userService.GetUser(ctx, input.ID)
 	if err != nil {
 		return &GetUserOutput{
@@ -97,44 +119,59 @@ func (uc *UseCaseImpl) GetUser(ctx context.Context, input GetUserInput) (*GetUse
 	}
 
 	return &GetUserOutput{
-		User: &UserOutput{
-			ID:        user.ID,
-			Name:      user.Name,
-			Email:     user.Email,
-			CreatedAt: user.CreatedAt,
-		},
+		User:  newUserOutput(user),
 		Found: true,
 	}, nil
 }
 
 func (uc *UseCaseImpl) ListUsers(ctx context.Context, input ListUsersInput) (*ListUsersOutput, error) {
-	limit := input.Limit
-	if limit <= 0 {
-		limit = 100
-	}
-	offset := input.Offset
-	if offset < 0 {
-		offset = 0
+	page := pagination.Page{Limit: input.Limit, Offset: input.Offset, SkipTotal: input.SkipTotal}
+	if input.Cursor != "" {
+		cursor, err := uc.cursors.Decode(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		page.Cursor = cursor
 	}
 
-	users, err := uc.userService.ListUsers(ctx, limit, offset)
+	result, err := uc.userService.ListUsers(ctx, page)
 	if err != nil {
 		return nil, err
 	}
 
+	users := result.Items
 	output := &ListUsersOutput{
 		Users: make([]UserOutput, len(users)),
-		Total: len(users),
+		Count: len(users),
+		Total: result.Total,
+	}
+	if result.Next != nil {
+		output.NextCursor = uc.cursors.Encode(*result.Next)
+	}
+	if result.Prev != nil {
+		output.PrevCursor = uc.cursors.Encode(*result.Prev)
 	}
 
-	for i, u := range users {
-		output.Users[i] = UserOutput{
-			ID:        u.ID,
-			Name:      u.Name,
-			Email:     u.Email,
-			CreatedAt: u.CreatedAt,
-		}
+	for i := range users {
+		output.Users[i] = *newUserOutput(&users[i])
 	}
 
 	return output, nil
 }
+
+func (uc *UseCaseImpl) SetUserDisabled(ctx context.Context, input SetUserDisabledInput) (*SetUserDisabledOutput, error) {
+	user, err := uc.userService.SetUserDisabled(ctx, input.ActorID, input.ID, input.Disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	message := "User enabled"
+	if input.Disabled {
+		message = "User disabled and signed out"
+	}
+	return &SetUserDisabledOutput{User: newUserOutput(user), Message: message}, nil
+}
+
+func (uc *UseCaseImpl) DeleteUser(ctx context.Context, input DeleteUserInput) error {
+	return uc.userService.DeleteUser(ctx, input.ActorID, input.ID)
+}